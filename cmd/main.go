@@ -3,13 +3,15 @@ package main
 import (
 	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/internal/exitcode"
 	"github.com/oldmonad/ec2Drift/pkg/config/env"
 	"github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports/cli"
 	"github.com/oldmonad/ec2Drift/pkg/ports/rest"
+	"github.com/oldmonad/ec2Drift/pkg/schedule"
 	"github.com/oldmonad/ec2Drift/pkg/utils/validator"
 	"go.uber.org/zap"
 )
@@ -17,16 +19,18 @@ import (
 func main() {
 	logger.Init(true)
 	defer logger.Log.Sync()
-	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
+	// Load environment variables from the .env file when there is one
+	if err := env.LoadDotEnv(".env"); err != nil {
 		logger.Log.Error("failed to load .env", zap.Error(err))
-		os.Exit(1)
+		os.Exit(int(exitcode.FromError(err)))
 	}
 
 	// Load and parse application configurations from environment variables
 	configurations, err := env.SetupConfigurations()
 	if err != nil {
-		logger.Log.Fatal(errors.NewErrConfigSetup(err).Error(), zap.Error(err))
+		err = errors.NewErrConfigSetup(err)
+		logger.Log.Error(err.Error(), zap.Error(err))
+		os.Exit(int(exitcode.FromError(err)))
 	}
 
 	// Create core application instance with loaded configurations
@@ -35,8 +39,35 @@ func main() {
 	// Initialize input validator
 	validator := validator.NewValidator()
 
-	// Initialize HTTP server that exposes drift detection via REST API
-	httpServer := rest.NewServer(app, validator)
+	// Initialize HTTP server that exposes drift detection via REST API,
+	// running scheduled checks of every attribute when SCHEDULE is set
+	timeouts := configurations.HttpTimeouts
+	serverOpts := []rest.ServerOption{
+		rest.WithTimeouts(timeouts.Read, timeouts.Write, timeouts.Idle),
+		rest.WithMaxReports(configurations.MaxReports),
+		// SIGHUP reloads .env and the environment, e.g. rotated credentials
+		rest.WithReload(func() error {
+			if err := env.ReloadDotEnv(".env"); err != nil {
+				return err
+			}
+			reloaded, err := env.SetupConfigurations()
+			if err != nil {
+				return err
+			}
+			app.Reload(*reloaded)
+			return nil
+		}),
+	}
+	if configurations.Schedule != "" {
+		spec, err := schedule.Parse(configurations.Schedule)
+		if err != nil {
+			logger.Log.Fatal("invalid schedule", zap.Error(err))
+		}
+		attrs, _ := validator.ValidateAttributes(nil)
+		format := parser.DetectFormat(configurations.StatePath)
+		serverOpts = append(serverOpts, rest.WithScheduler(rest.NewScheduler(app, spec, attrs, format)))
+	}
+	httpServer := rest.NewServer(app, validator, serverOpts...)
 
 	// Prepare CLI command handler with all dependencies injected
 	command := cli.NewCommand(app, validator, httpServer, configurations)
@@ -44,8 +75,14 @@ func main() {
 	// Construct root command that wires together CLI interface
 	rootCmd := command.InitiateCommands()
 
-	// Execute the root command (CLI entrypoint)
+	// Execute the root command (CLI entrypoint) and exit with the code of
+	// its outcome, e.g. 2 when drift was detected
 	if err := rootCmd.Execute(); err != nil {
-		logger.Log.Fatal("command failed", zap.Error(err))
+		code := exitcode.FromError(err)
+		if code != exitcode.Drift && code != exitcode.NoMatch {
+			logger.Log.Error("command failed", zap.Error(err), zap.Int("exit_code", int(code)))
+		}
+		logger.Log.Sync()
+		os.Exit(int(code))
 	}
 }
@@ -1,74 +1,738 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/baseline"
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
 	"github.com/oldmonad/ec2Drift/pkg/cloud/aws"
 	"github.com/oldmonad/ec2Drift/pkg/cloud/gcp"
 	config "github.com/oldmonad/ec2Drift/pkg/config/cloud"
+	awsConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
+	gcpConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/gcp"
 	"github.com/oldmonad/ec2Drift/pkg/config/env"
 	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/hook"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
 	"github.com/oldmonad/ec2Drift/pkg/output"
 	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
+	"github.com/oldmonad/ec2Drift/pkg/source"
 	"go.uber.org/zap"
 )
 
 type App struct {
 	Logger         *zap.Logger
-	configurations env.Configurations
+	configurations env.Configurations // Guarded by mu, swapped by Reload
+	mu             sync.RWMutex
+	providers      map[config.ProviderType]cloud.CloudProvider // Set with SetCloudProvider
+	builtIn        map[config.ProviderType]cloud.CloudProvider // Built on first use, dropped by Reload
+	providersMu    sync.Mutex                                  // Guards providers and builtIn
+	parsers        *parser.Registry
+	out            io.Writer // Reports and explanations, stdout by default
+	errOut         io.Writer // Exit summary, stderr by default
+	fsys           fs.FS     // Local config and state files, the OS filesystem by default
 }
 
 // AppRunner defines the contract for running the core application logic
 type AppRunner interface {
-	Run(ctx context.Context, attrs []string, format parser.ParserType, runtype ports.Runtype) error
+	Run(ctx context.Context, attrs []string, format parser.ParserType, runtype ports.Runtype, opts RunOptions) error
+}
+
+// DriftChecker runs a drift check and hands back the reports instead of
+// printing them, for callers such as the scheduled checks of the server
+type DriftChecker interface {
+	Check(ctx context.Context, attrs []string, format parser.ParserType, opts RunOptions) ([]driftchecker.DriftReport, error)
+}
+
+// StateComparer compares two state files offline, reporting the drift from
+// the old one to the new one
+type StateComparer interface {
+	Compare(ctx context.Context, oldPath, newPath string, attrs []string, format parser.ParserType, runtype ports.Runtype, opts RunOptions) error
+}
+
+// StateExporter writes the live instances to a file without comparing
+// them, as a baseline for later offline comparisons
+type StateExporter interface {
+	Export(ctx context.Context, path string, attrs []string, opts RunOptions) error
+}
+
+// BaselineRefresher replaces a baseline file with the live instances and a
+// metadata header, atomically so concurrent runs never read a partial file
+type BaselineRefresher interface {
+	RefreshBaseline(ctx context.Context, path string, attrs []string, opts RunOptions) (baseline.Metadata, error)
+}
+
+// StateFetcher returns the live instances as the provider reports them,
+// without loading a desired config or comparing anything
+type StateFetcher interface {
+	Fetch(ctx context.Context, attrs []string, opts RunOptions) ([]cloud.Instance, error)
+}
+
+// ReportTitler labels drift results, e.g. "prod us-east-1", so reports of
+// several environments can be told apart
+type ReportTitler interface {
+	ReportTitle() string
+}
+
+// RunDescriber records the inputs of a drift check, so results served
+// without a report, e.g. over REST, carry the same meta object
+type RunDescriber interface {
+	RunMeta(attrs []string, format parser.ParserType, opts RunOptions) output.Meta
+}
+
+// FormatLister lists the desired config formats the app can parse, e.g.
+// to validate the format of REST requests
+type FormatLister interface {
+	InputFormats() []string
+}
+
+// ConfigurationReader exposes the resolved configuration settings, e.g.
+// to show which ones are in effect
+type ConfigurationReader interface {
+	Configurations() env.Configurations
+}
+
+// DriftStreamer runs a drift check and sends each report as soon as it is
+// ready, for clients that consume results incrementally
+type DriftStreamer interface {
+	Stream(ctx context.Context, attrs []string, format parser.ParserType, opts RunOptions) (<-chan driftchecker.DriftReport, error)
+}
+
+// RunOptions holds optional per-run settings that refine how drift
+// reports are produced after detection
+type RunOptions struct {
+	Only        []string      // Drift categories or attribute names to keep in the output
+	UnmanagedOK bool          // Report live instances missing from the config as unmanaged, not drift
+	Explain     bool          // Print how each live instance was matched to the config
+	NoExpand    bool          // Skip ${VAR} expansion of the desired config
+	PageSize    int32         // Page size for cloud API listing calls, zero uses the provider default
+	CallTimeout time.Duration // Deadline for each cloud API call, zero disables it
+	Region      string        // Region to fetch from instead of the configured one, AWS only
+	EndpointURL string        // Endpoint to fetch from instead of AWS_ENDPOINT_URL, AWS only
+
+	AutoAttributes    bool // Compare only the attributes each desired instance sets
+	DefaultAttributes bool // The attributes are the default set rather than a selection of the caller
+	StrictMatch       bool // Report desired instances that cannot be matched as instance_missing drift
+	IncludeNoDrift    bool // Also report matched instances without drift, with status ok
+	IgnoreCase        bool // Compare string values ignoring case, e.g. GP2 and gp2
+
+	ExpectedState string // State desired instances without instance_state are expected in, empty for running
+
+	ConfigFiles []string // Config files or directories merged in place of STATE_PATH
+	Baseline    string   // JSON snapshot written by export, compared in place of the desired config
+	Config      []byte   // Desired config content sent with a request, parsed in place of STATE_PATH
+
+	Instances        []string // Live instance IDs to check, empty checks all
+	ExcludeInstances []string // Live instance IDs to skip, applied after Instances
+	VpcID            string   // Only compare instances in this VPC, pushed down to AWS
+	SubnetID         string   // Only compare instances in this subnet, pushed down to AWS
+	MaxInstances     int      // Fail when the live fetch finds more instances, zero is unlimited
+
+	CheckCredExpiry  bool          // Fail before fetching when the credentials expire within CredExpiryBuffer
+	CredExpiryBuffer time.Duration // Credential lifetime a run needs left
+
+	FailOnSeverity driftchecker.Severity // Only drift at or above this severity counts, empty counts all drift
+
+	Output     output.Format // Report format, empty prints a table and infers file formats from the extension
+	OutputFile string        // File to write the report to, overrides OUTPUT_PATH
+	OutputDir  string        // Directory to write one report per application to, in Output or JSON
+	Quiet      bool          // Do not print the report to stdout
+	JSONPretty bool          // Indent JSON reports, which are compact by default
+
+	Template *template.Template // Parsed --template-file of the template output format
+
+	ReportTitle string         // Label for JSON and HTML reports, overrides REPORT_TITLE
+	GroupBy     output.GroupBy // Group table and JSON reports, e.g. by application, empty lists each instance
+
+	ExitSummary bool // Print a one line JSON summary of the run to stderr
+
+	Exec        string        // Shell command run after the check with the JSON report on stdin
+	ExecTimeout time.Duration // Deadline for the Exec command, zero uses hook.DefaultTimeout
+
+	startedAt time.Time               // Set by Run to time the exit summary
+	calls     *cloud.CallCounter      // Set by Run to count the cloud API calls
+	warnings  *cloud.WarningCollector // Set by Run to gather partial data warnings
+	meta      *output.Meta            // Set by Run to record its inputs in JSON reports
 }
 
 // NewApp initializes and returns a new App instance
 func NewApp(configurations env.Configurations) *App {
-	return &App{Logger: logger.Log, configurations: configurations}
+	return &App{
+		Logger:         logger.Log,
+		configurations: configurations,
+		parsers:        parser.DefaultRegistry(),
+		out:            os.Stdout,
+		errOut:         os.Stderr,
+		fsys:           osFS{},
+	}
+}
+
+// SetFS makes the app read local config and state files from fsys instead
+// of the OS filesystem, e.g. embedded fixtures or an fstest.MapFS. Paths
+// are then fs.FS names such as "configs/main.tf". Git references and
+// written reports are not affected.
+func (a *App) SetFS(fsys fs.FS) {
+	a.fsys = fsys
+}
+
+// SetOut makes the app print reports and explanations to w instead of stdout
+func (a *App) SetOut(w io.Writer) {
+	a.out = w
+}
+
+// SetErr makes the app print the exit summary to w instead of stderr
+func (a *App) SetErr(w io.Writer) {
+	a.errOut = w
+}
+
+// stdout returns the writer for reports, falling back to stdout
+func (a *App) stdout() io.Writer {
+	if a.out == nil {
+		return os.Stdout
+	}
+	return a.out
+}
+
+// stderr returns the writer for the exit summary, falling back to stderr
+func (a *App) stderr() io.Writer {
+	if a.errOut == nil {
+		return os.Stderr
+	}
+	return a.errOut
 }
 
 // Configurations returns the application's configuration settings
 func (a *App) Configurations() env.Configurations {
+	return a.config()
+}
+
+// Reload replaces the configuration settings. Checks already running keep
+// the settings they started with; later ones use the new settings.
+func (a *App) Reload(configurations env.Configurations) {
+	a.mu.Lock()
+	a.configurations = configurations
+	a.mu.Unlock()
+
+	// The built-in providers cache clients holding the old credentials
+	a.providersMu.Lock()
+	a.builtIn = nil
+	a.providersMu.Unlock()
+
+	a.Logger.Info("Configuration reloaded",
+		zap.Strings("cloud_providers", providerNames(configurations.ProviderTypes())),
+		zap.String("state_path", configurations.StatePath))
+}
+
+// ReportTitle returns the REPORT_TITLE label, empty when unset
+func (a *App) ReportTitle() string {
+	return a.config().ReportTitle
+}
+
+// config returns a snapshot of the configuration settings
+func (a *App) config() env.Configurations {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.configurations
 }
 
+func providerNames(types []config.ProviderType) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return names
+}
+
 // Run orchestrates the full drift detection workflow:
 // 1. Fetch current cloud state
-// 2. Load desired configuration from file
+// 2. Load desired configuration from file, expanding environment variables
 // 3. Parse desired state
 // 4. Compare actual vs. desired and report drift
-func (a *App) Run(ctx context.Context, attrs []string, format parser.ParserType, runtype ports.Runtype) error {
-	stateInstances, err := a.GetLiveStateInstances(ctx, a.configurations.CloudConfig)
+func (a *App) Run(ctx context.Context, attrs []string, format parser.ParserType, runtype ports.Runtype, opts RunOptions) error {
+	opts.startedAt = time.Now()
+	opts.calls = &cloud.CallCounter{}
+	attrs = a.comparedAttributes(attrs, format, opts)
+	meta := a.RunMeta(attrs, format, opts)
+	opts.meta = &meta
+	ctx = cloud.WithCallCounter(ctx, opts.calls)
+	// Callers such as the REST handler pass a collector to read the
+	// warnings back once the run is over
+	if opts.warnings = cloud.WarningCollectorFrom(ctx); opts.warnings == nil {
+		opts.warnings = &cloud.WarningCollector{}
+		ctx = cloud.WithWarningCollector(ctx, opts.warnings)
+	}
+
+	stateInstances, configInstances, err := a.loadInstances(ctx, attrs, format, opts)
 	if err != nil {
 		return err
 	}
 
-	content, err := a.LoadStateFile()
+	return a.HandleDrift(ctx, stateInstances, configInstances, attrs, runtype, opts)
+}
+
+// RunMeta describes the inputs of a check with these arguments: what was
+// compared, against which provider and region, from which desired state and
+// through which filters. Credentials in state paths are masked.
+func (a *App) RunMeta(attrs []string, format parser.ParserType, opts RunOptions) output.Meta {
+	configurations := a.config()
+	meta := output.Meta{
+		Attributes:  a.comparedAttributes(attrs, format, opts),
+		InputFormat: string(format),
+		Providers:   providerNames(configurations.ProviderTypes()),
+		Region:      opts.Region,
+		Filters: output.MetaFilters{
+			Instances:        opts.Instances,
+			ExcludeInstances: opts.ExcludeInstances,
+			VpcID:            opts.VpcID,
+			SubnetID:         opts.SubnetID,
+			Only:             opts.Only,
+			FailOnSeverity:   string(opts.FailOnSeverity),
+		},
+	}
+	if meta.Region == "" && configurations.CloudConfig != nil {
+		meta.Region = configurations.CloudConfig.GetRegion()
+	}
+
+	switch {
+	case opts.Config != nil:
+		meta.Inline = true
+	case opts.Baseline != "":
+		// Baselines are always read as JSON
+		meta.InputFormat = string(parser.JSON)
+		meta.Baseline = source.RedactPath(opts.Baseline)
+	case len(opts.ConfigFiles) > 0:
+		meta.ConfigFiles = make([]string, len(opts.ConfigFiles))
+		for i, path := range opts.ConfigFiles {
+			meta.ConfigFiles[i] = source.RedactPath(path)
+		}
+	default:
+		meta.StatePath = source.RedactPath(configurations.StatePath)
+	}
+	return meta
+}
+
+// Check runs the same workflow as Run but returns the drift reports
+// without printing or writing them
+func (a *App) Check(ctx context.Context, attrs []string, format parser.ParserType, opts RunOptions) ([]driftchecker.DriftReport, error) {
+	attrs = a.comparedAttributes(attrs, format, opts)
+	stateInstances, configInstances, err := a.loadInstances(ctx, attrs, format, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.detect(ctx, stateInstances, configInstances, attrs, opts), nil
+}
+
+// Stream runs the same workflow as Check but sends the reports on the
+// returned channel as they are detected. Errors loading the instances are
+// returned before any report is sent.
+func (a *App) Stream(ctx context.Context, attrs []string, format parser.ParserType, opts RunOptions) (<-chan driftchecker.DriftReport, error) {
+	attrs = a.comparedAttributes(attrs, format, opts)
+	stateInstances, configInstances, err := a.loadInstances(ctx, attrs, format, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	detected := a.detectStream(ctx, stateInstances, configInstances, attrs, opts)
+	reports := make(chan driftchecker.DriftReport)
+	go func() {
+		defer close(reports)
+		send := func(batch []driftchecker.DriftReport) bool {
+			if opts.UnmanagedOK {
+				batch = driftchecker.MarkUnmanaged(batch)
+			}
+			batch = driftchecker.AssignSeverity(driftchecker.Filter(batch, opts.Only), a.config().Severities)
+			for _, r := range batch {
+				select {
+				case reports <- r:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		for report := range detected {
+			if !send([]driftchecker.DriftReport{report}) {
+				return
+			}
+		}
+		if opts.StrictMatch {
+			send(driftchecker.Unmatched(configInstances))
+		}
+	}()
+	return reports, nil
+}
+
+// loadInstances fetches the live instances and parses the desired ones
+func (a *App) loadInstances(ctx context.Context, attrs []string, format parser.ParserType, opts RunOptions) ([]cloud.Instance, []cloud.Instance, error) {
+	// Comparing nothing would always report "no drift"
+	if len(attrs) == 0 {
+		return nil, nil, errors.NewErrNoAttributesSelected()
+	}
+
+	configurations := a.config()
+	if opts.CheckCredExpiry {
+		if awsCfg, ok := configurations.CloudConfig.(*awsConfig.Config); ok {
+			if err := awsCfg.CheckExpiry(time.Now(), opts.CredExpiryBuffer); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	stateInstances, err := a.GetLiveStateInstances(ctx, withRunSettings(configurations.CloudConfig, attrs, opts))
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.calls != nil {
+		a.Logger.Info("Fetched live instances",
+			zap.Int("instance_count", len(stateInstances)),
+			zap.Int("api_calls", opts.calls.Total()),
+			zap.Any("api_calls_by_operation", opts.calls.Counts()))
+	}
+	if opts.warnings != nil {
+		for _, w := range opts.warnings.Warnings() {
+			a.Logger.Warn("Live instance data is incomplete, drift of the missing attributes may be wrong",
+				zap.String("instance_id", w.InstanceID), zap.String("warning", w.Message))
+		}
+	}
+
+	var configInstances []cloud.Instance
+	switch {
+	case opts.Config != nil:
+		if opts.Baseline != "" || len(opts.ConfigFiles) > 0 || configurations.StatePath != "" {
+			return nil, nil, errors.NewErrConflictingConfigSources()
+		}
+		configInstances, err = a.parseInlineConfig(ctx, opts.Config, format)
+	case opts.Baseline != "":
+		// A snapshot holds literal live values, so nothing is expanded
+		opts.NoExpand = true
+		configInstances, err = a.loadConfigInstances(ctx, opts.Baseline, parser.JSON, opts)
+	case len(opts.ConfigFiles) > 0:
+		configInstances, err = a.loadConfigFiles(ctx, opts.ConfigFiles, format, opts)
+	case configurations.StatePath == "":
+		return nil, nil, errors.NewErrMissingPaths()
+	default:
+		configInstances, err = a.loadConfigInstances(ctx, configurations.StatePath, format, opts)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Baseline == "" {
+		a.warnUnsupportedAttributes(format, attrs)
+	}
+
+	return stateInstances, configInstances, nil
+}
+
+// lookupAttributes cost an extra API call per instance, so they are only
+// compared when the caller selects them
+var lookupAttributes = []string{"disable_api_termination", "user_data"}
+
+// comparedAttributes drops from the default attribute set the lookup
+// attributes and the attributes the parser of the desired config never
+// populates, which would otherwise drift on every instance. Attributes the
+// caller selected are kept, and warned about once the desired config is
+// loaded.
+func (a *App) comparedAttributes(attrs []string, format parser.ParserType, opts RunOptions) []string {
+	if !opts.DefaultAttributes {
+		return attrs
+	}
+	// Baselines are always read as JSON
+	if opts.Baseline != "" {
+		format = parser.JSON
+	}
+	var unsupported []string
+	if p, err := a.parserRegistry().Lookup(format); err == nil {
+		unsupported = parser.UnsupportedAttributes(p, attrs)
+	}
+	return slices.DeleteFunc(slices.Clone(attrs), func(attr string) bool {
+		return slices.Contains(lookupAttributes, attr) || slices.Contains(unsupported, attr)
+	})
+}
+
+// warnUnsupportedAttributes warns about the selected attributes the parser
+// of the desired config never populates, whose drift cannot be trusted
+func (a *App) warnUnsupportedAttributes(format parser.ParserType, attrs []string) {
+	p, err := a.parserRegistry().Lookup(format)
+	if err != nil {
+		return
+	}
+	for _, attr := range parser.UnsupportedAttributes(p, attrs) {
+		a.Logger.Warn("Attribute not supported by parser, its drift cannot be detected",
+			zap.String("attribute", attr),
+			zap.String("parser", string(format)))
+	}
+}
+
+// configExtensions maps input formats to the file extension picked up from
+// config directories
+var configExtensions = map[parser.ParserType]string{
+	parser.Terraform: ".tf",
+	parser.JSON:      ".json",
+}
+
+// loadConfigFiles parses each config file, or each file of the input format
+// in a config directory, and merges the instances. Two instances with the
+// same Name tag would shadow each other when matching, so they are rejected.
+func (a *App) loadConfigFiles(ctx context.Context, paths []string, format parser.ParserType, opts RunOptions) ([]cloud.Instance, error) {
+	files, err := expandConfigPaths(a.fsys, paths, format)
+	if err != nil {
+		a.Logger.Error("Failed to list configuration files", zap.Error(err))
+		return nil, err
+	}
+
+	var merged []cloud.Instance
+	declaredIn := make(map[string]string) // Name tag -> file declaring it
+	for _, path := range files {
+		instances, err := a.loadConfigInstances(ctx, path, format, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, inst := range instances {
+			if name, ok := inst.Tags["Name"]; ok {
+				if first, dup := declaredIn[name]; dup {
+					err := errors.NewErrDuplicateInstanceName(name, first, path)
+					a.Logger.Error("Duplicate instance in configuration files", zap.Error(err))
+					return nil, err
+				}
+				declaredIn[name] = path
+			}
+			merged = append(merged, inst)
+		}
+	}
+
+	a.Logger.Info("Merged configuration files",
+		zap.Strings("files", files), zap.Int("instance_count", len(merged)))
+	return merged, nil
+}
+
+// expandConfigPaths replaces each local directory by the files of the input
+// format directly inside it, in name order. Files and git references are
+// kept as given.
+func expandConfigPaths(fsys fs.FS, paths []string, format parser.ParserType) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		if source.IsGitRef(path) {
+			files = append(files, path)
+			continue
+		}
+		info, err := fs.Stat(fsys, path)
+		if err != nil {
+			return nil, errors.NewReadFileError(err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := fs.ReadDir(fsys, path)
+		if err != nil {
+			return nil, errors.NewReadFileError(err)
+		}
+		ext := configExtensions[format]
+		found := false
+		for _, entry := range entries {
+			if !entry.IsDir() && filepath.Ext(entry.Name()) == ext {
+				files = append(files, filepath.Join(path, entry.Name()))
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.NewErrNoConfigFiles(path, ext)
+		}
+	}
+	return files, nil
+}
+
+// loadConfigInstances reads a state file, expands environment variables
+// unless disabled and parses it
+func (a *App) loadConfigInstances(ctx context.Context, path string, format parser.ParserType, opts RunOptions) ([]cloud.Instance, error) {
+	content, err := a.readStateFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.NoExpand {
+		content, err = parser.ExpandEnv(content)
+		if err != nil {
+			a.Logger.Error("Failed to expand environment variables in configuration file", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	return a.parseConfigInstances(ctx, content, format)
+}
+
+// parseInlineConfig parses desired config content sent with a request.
+// It comes from the client, so environment variables are never expanded
+// into it: the reports would hand the server's values back.
+func (a *App) parseInlineConfig(ctx context.Context, content []byte, format parser.ParserType) ([]cloud.Instance, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, errors.NewErrEmptyStateFile("inline config")
+	}
+	return a.parseConfigInstances(ctx, content, format)
+}
+
+// Compare parses two state files and reports the drift from the old one to
+// the new one the same way Run reports live drift. No cloud provider is
+// contacted.
+func (a *App) Compare(ctx context.Context, oldPath, newPath string, attrs []string, format parser.ParserType, runtype ports.Runtype, opts RunOptions) error {
+	opts.startedAt = time.Now()
+
+	// Comparing nothing would always report "no drift"
+	if len(attrs) == 0 {
+		return errors.NewErrNoAttributesSelected()
+	}
+
+	oldInstances, err := a.loadConfigInstances(ctx, oldPath, format, opts)
+	if err != nil {
+		return err
+	}
+	newInstances, err := a.loadConfigInstances(ctx, newPath, format, opts)
 	if err != nil {
 		return err
 	}
+	a.warnUnsupportedAttributes(format, attrs)
+
+	return a.HandleDrift(ctx, newInstances, oldInstances, attrs, runtype, opts)
+}
 
-	configInstances, err := a.ParseConfigInstances(content, format)
+// Export fetches the live instances and writes them to path as a JSON
+// array of instances, the format the json input format reads back. The
+// attributes only decide which extra per-instance lookups are made.
+func (a *App) Export(ctx context.Context, path string, attrs []string, opts RunOptions) error {
+	instances, err := a.Fetch(ctx, attrs, opts)
 	if err != nil {
 		return err
 	}
 
-	return a.HandleDrift(ctx, stateInstances, configInstances, attrs, runtype)
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+	if err := output.RenderInstances(f, output.JSON, instances); err != nil {
+		f.Close()
+		return errors.NewWriteOutputError(path, err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+
+	a.Logger.Info("Live state exported", zap.String("path", path), zap.Int("instance_count", len(instances)))
+	return nil
+}
+
+// RefreshBaseline fetches the live instances like Export and atomically
+// replaces the baseline at path with them, headed by when, from which
+// provider, region and account they were taken
+func (a *App) RefreshBaseline(ctx context.Context, path string, attrs []string, opts RunOptions) (baseline.Metadata, error) {
+	settings := a.config()
+	providerCfg := withRunSettings(settings.CloudConfig, attrs, opts)
+	instances, err := a.GetLiveStateInstances(ctx, providerCfg)
+	if err != nil {
+		return baseline.Metadata{}, err
+	}
+
+	meta := baseline.Metadata{
+		CreatedAt: time.Now().UTC(),
+		Provider:  string(settings.CloudProviderType),
+		Account:   baselineAccount(instances, providerCfg),
+	}
+	if providerCfg != nil {
+		meta.Region = providerCfg.GetRegion()
+	}
+	doc, err := baseline.New(instances, meta)
+	if err != nil {
+		return baseline.Metadata{}, errors.NewWriteOutputError(path, err)
+	}
+	if err := baseline.WriteFile(path, doc); err != nil {
+		a.Logger.Error("Failed to refresh baseline", zap.String("path", path), zap.Error(err))
+		return baseline.Metadata{}, err
+	}
+
+	a.Logger.Info("Baseline refreshed",
+		zap.String("path", path),
+		zap.Int("instance_count", doc.Metadata.InstanceCount),
+		zap.String("checksum", doc.Metadata.Checksum))
+	return doc.Metadata, nil
+}
+
+// baselineAccount returns the AWS accounts owning the instances, comma
+// separated, or the GCP project, empty when neither is known
+func baselineAccount(instances []cloud.Instance, providerCfg config.ProviderConfig) string {
+	var accounts []string
+	for _, inst := range instances {
+		if inst.AccountID != "" && !slices.Contains(accounts, inst.AccountID) {
+			accounts = append(accounts, inst.AccountID)
+		}
+	}
+	if len(accounts) > 0 {
+		sort.Strings(accounts)
+		return strings.Join(accounts, ",")
+	}
+	if gcpCfg, ok := providerCfg.(*gcpConfig.Config); ok {
+		return gcpCfg.ProjectID
+	}
+	return ""
+}
+
+// Fetch returns the live instances with the run's region, page size and
+// call timeout applied. The attributes only decide which extra
+// per-instance lookups are made.
+func (a *App) Fetch(ctx context.Context, attrs []string, opts RunOptions) ([]cloud.Instance, error) {
+	return a.GetLiveStateInstances(ctx, withRunSettings(a.config().CloudConfig, attrs, opts))
 }
 
 // LoadStateFile reads and returns the contents of the desired state configuration file
-// if I had more time, I would refactor this to use a more robust file reading mechanism
-// which would be part of a separate module that handles file and data operations
 func (a *App) LoadStateFile() ([]byte, error) {
-	path := a.configurations.StatePath
+	return a.loadStateFile(context.Background())
+}
+
+// loadStateFile reads the desired state configured by STATE_PATH
+func (a *App) loadStateFile(ctx context.Context) ([]byte, error) {
+	return a.readStateFile(ctx, a.config().StatePath)
+}
+
+// readStateFile reads a local state file, or from a git repository when
+// the path is a git:: reference. A file without any content is rejected
+// before it reaches a parser.
+func (a *App) readStateFile(ctx context.Context, path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if source.IsGitRef(path) {
+		data, err = a.loadGitStateFile(ctx, path)
+	} else {
+		data, err = a.loadLocalStateFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		a.Logger.Error("Configuration file is empty", zap.String("path", path))
+		return nil, errors.NewErrEmptyStateFile(path)
+	}
+	return data, nil
+}
+
+// loadLocalStateFile reads the desired state from the app's filesystem
+func (a *App) loadLocalStateFile(path string) ([]byte, error) {
 	a.Logger.Info("Reading configuration file", zap.String("path", path))
-	data, err := os.ReadFile(path)
+	data, err := fs.ReadFile(a.fsys, path)
 	if err != nil {
 		a.Logger.Error("Failed to read configuration file", zap.Error(err))
 		return nil, errors.NewReadFileError(err)
@@ -77,56 +741,480 @@ func (a *App) LoadStateFile() ([]byte, error) {
 	return data, nil
 }
 
-// GetLiveStateInstances orchestrates and sets the cloud provider instance data
-// And then proceeds to fetch the live state instances from the cloud provider
-func (a *App) GetLiveStateInstances(ctx context.Context, configurations config.ProviderConfig) ([]cloud.Instance, error) {
+// loadGitStateFile clones the referenced repository and reads the file
+func (a *App) loadGitStateFile(ctx context.Context, path string) ([]byte, error) {
+	ref, err := source.ParseGitRef(path)
+	if err != nil {
+		a.Logger.Error("Invalid git reference for configuration file", zap.Error(err))
+		return nil, err
+	}
+
+	a.Logger.Info("Reading configuration file from git",
+		zap.String("repository", ref.URL),
+		zap.String("path", ref.Path),
+		zap.String("ref", ref.Ref))
+	data, err := source.ReadGitFile(ctx, ref)
+	if err != nil {
+		a.Logger.Error("Failed to read configuration file from git", zap.Error(err))
+		return nil, err
+	}
+	a.Logger.Info("Configuration file read successfully")
+	return data, nil
+}
+
+// SetCloudProvider overrides the cloud provider used for the given provider type
+func (a *App) SetCloudProvider(providerType config.ProviderType, provider cloud.CloudProvider) {
+	a.providersMu.Lock()
+	defer a.providersMu.Unlock()
+	if a.providers == nil {
+		a.providers = make(map[config.ProviderType]cloud.CloudProvider)
+	}
+	a.providers[providerType] = provider
+}
+
+// cloudProvider resolves the cloud provider implementation for a provider
+// type. Built-in providers are kept once built, so the AWS provider reuses
+// its per-region clients and their cached credentials across fetches.
+func (a *App) cloudProvider(providerType config.ProviderType) cloud.CloudProvider {
+	a.providersMu.Lock()
+	defer a.providersMu.Unlock()
+	if provider, ok := a.providers[providerType]; ok {
+		return provider
+	}
+	if provider, ok := a.builtIn[providerType]; ok {
+		return provider
+	}
+
 	var provider cloud.CloudProvider
-	switch a.configurations.CloudProviderType {
-	case config.AWS:
-		provider = &aws.AWSProvider{}
+	switch providerType {
 	case config.GCP:
 		provider = &gcp.GCPProvider{}
 	default:
 		// Default to AWS if provider is not specified
-		provider = &aws.AWSProvider{}
+		provider = aws.NewAWSProvider()
 	}
-	return provider.FetchInstances(ctx, configurations)
+	if a.builtIn == nil {
+		a.builtIn = make(map[config.ProviderType]cloud.CloudProvider)
+	}
+	a.builtIn[providerType] = provider
+	return provider
+}
+
+// GetLiveStateInstances orchestrates and sets the cloud provider instance data
+// And then proceeds to fetch the live state instances from the cloud provider.
+// When several providers are configured they are fetched concurrently, each
+// with its own config, and the results are merged in provider order and
+// tagged with the provider they came from.
+func (a *App) GetLiveStateInstances(ctx context.Context, configurations config.ProviderConfig) ([]cloud.Instance, error) {
+	settings := a.config()
+	providerTypes := settings.ProviderTypes()
+	if len(providerTypes) < 2 {
+		// Instances of a single provider are left untagged
+		return a.cloudProvider(settings.CloudProviderType).FetchInstances(ctx, configurations)
+	}
+
+	results := make([][]cloud.Instance, len(providerTypes))
+	errs := make([]error, len(providerTypes))
+
+	var wg sync.WaitGroup
+	for i, providerType := range providerTypes {
+		providerCfg, ok := settings.CloudConfigs[providerType]
+		if !ok {
+			return nil, errors.NewErrCloudConfigNotInit()
+		}
+		if providerType == settings.CloudProviderType {
+			// The primary config may carry per-run settings such as the page size
+			providerCfg = configurations
+		}
+
+		wg.Add(1)
+		go func(i int, providerType config.ProviderType, providerCfg config.ProviderConfig) {
+			defer wg.Done()
+			results[i], errs[i] = a.fetchInstances(ctx, providerType, providerCfg)
+		}(i, providerType, providerCfg)
+	}
+	wg.Wait()
+
+	var instances []cloud.Instance
+	for i, providerType := range providerTypes {
+		if errs[i] != nil {
+			a.Logger.Error("Failed to fetch instances",
+				zap.String("provider", string(providerType)),
+				zap.Error(errs[i]))
+			return nil, errs[i]
+		}
+		instances = append(instances, results[i]...)
+	}
+	return instances, nil
+}
+
+// fetchInstances fetches the live instances of a single provider and
+// tags each one with the provider it came from
+func (a *App) fetchInstances(ctx context.Context, providerType config.ProviderType, configurations config.ProviderConfig) ([]cloud.Instance, error) {
+	instances, err := a.cloudProvider(providerType).FetchInstances(ctx, configurations)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range instances {
+		instances[i].Provider = string(providerType)
+	}
+	return instances, nil
 }
 
-// ParseConfigInstances parses the desired configuration content into structured instance data
+// withRunSettings returns a copy of the provider config using the page size,
+// call timeout and region of the run, and asking for termination protection and
+// user data only when those attributes are compared. The shared config is
+// left untouched so concurrent runs don't interfere.
+func withRunSettings(providerCfg config.ProviderConfig, attrs []string, opts RunOptions) config.ProviderConfig {
+	terminationProtection := slices.Contains(attrs, "disable_api_termination")
+	userData := slices.Contains(attrs, "user_data")
+	if opts.PageSize == 0 && opts.CallTimeout == 0 && opts.Region == "" && opts.EndpointURL == "" &&
+		opts.VpcID == "" && opts.SubnetID == "" && opts.MaxInstances == 0 && !terminationProtection && !userData {
+		return providerCfg
+	}
+	if awsCfg, ok := providerCfg.(*awsConfig.Config); ok {
+		tuned := *awsCfg
+		tuned.PageSize = opts.PageSize
+		tuned.CallTimeout = opts.CallTimeout
+		tuned.FetchTerminationProtection = terminationProtection
+		tuned.FetchUserData = userData
+		if opts.Region != "" {
+			tuned.Region = opts.Region
+		}
+		if opts.EndpointURL != "" {
+			tuned.EndpointURL = opts.EndpointURL
+		}
+		// Also filtered after the fetch, so providers without the
+		// push down only compare the selected network too
+		tuned.VpcID = opts.VpcID
+		tuned.SubnetID = opts.SubnetID
+		tuned.MaxInstances = opts.MaxInstances
+		return &tuned
+	}
+	return providerCfg
+}
+
+// ParseConfigInstances parses the desired configuration content into
+// structured instance data with the parser registered for the format
 func (a *App) ParseConfigInstances(content []byte, format parser.ParserType) ([]cloud.Instance, error) {
-	var p parser.Parser
-	switch format {
-	case parser.Terraform:
-		p = &parser.TerraformParser{}
-	case parser.JSON:
-		p = &parser.JSONParser{}
-	default:
-		// Default to Terraform parser if format is unrecognized
-		p = &parser.TerraformParser{}
+	return a.parseConfigInstances(context.Background(), content, format)
+}
+
+// parseConfigInstances works like ParseConfigInstances and records the
+// warnings of parsers that skip or partially decode instances on the
+// context's collector, so they reach the exit summary and REST responses
+func (a *App) parseConfigInstances(ctx context.Context, content []byte, format parser.ParserType) ([]cloud.Instance, error) {
+	p, err := a.parserRegistry().Lookup(format)
+	if err != nil {
+		a.Logger.Error("Unsupported configuration format", zap.Error(err))
+		return nil, err
+	}
+
+	wp, ok := p.(parser.WarningParser)
+	if !ok {
+		instances, err := p.Parse(content)
+		if err != nil {
+			return nil, errors.NewErrConfigParse(string(format), err)
+		}
+		return instances, nil
+	}
+
+	instances, warnings, err := wp.ParseWithWarnings(content)
+	if err != nil {
+		return nil, errors.NewErrConfigParse(string(format), err)
+	}
+	for _, w := range warnings {
+		a.Logger.Warn("Desired config is incomplete, drift of the affected instance may be wrong",
+			zap.String("instance_id", w.InstanceID), zap.String("warning", w.Message))
+		cloud.AddWarning(ctx, w.InstanceID, w.Message)
+	}
+	return instances, nil
+}
+
+// RegisterParser makes the app parse the given format with the parser built
+// by factory, adding a format or replacing a built in parser
+func (a *App) RegisterParser(format parser.ParserType, factory parser.Factory) {
+	if a.parsers == nil {
+		a.parsers = parser.DefaultRegistry()
 	}
-	return p.Parse(content)
+	a.parsers.Register(format, factory)
 }
 
-// HandleDrift compares actual vs. desired instances and outputs the drift report
+// InputFormats returns the registered desired config formats in
+// alphabetical order
+func (a *App) InputFormats() []string {
+	return a.parserRegistry().Formats()
+}
+
+// parserRegistry returns the app's parsers, falling back to the built in
+// ones for apps not created by NewApp
+func (a *App) parserRegistry() *parser.Registry {
+	if a.parsers == nil {
+		return parser.DefaultRegistry()
+	}
+	return a.parsers
+}
+
+// HandleDrift compares actual vs. desired instances and outputs the drift
+// report. Drift is returned as ErrDriftDetected whatever the runtype; it
+// never exits the process.
 func (a *App) HandleDrift(
 	ctx context.Context,
 	stateInstances, configInstances []cloud.Instance,
 	attrs []string,
 	runtype ports.Runtype,
+	opts RunOptions,
 ) error {
-	reports := driftchecker.Detect(ctx, stateInstances, configInstances, attrs)
-	if len(reports) > 0 {
-		a.Logger.Info("Drift detected", zap.Int("report_count", len(reports)))
-		output.PrintTable(reports)
+	if opts.Explain {
+		if err := output.WriteExplanations(a.stdout(), driftchecker.Explain(configInstances, stateInstances)); err != nil {
+			a.Logger.Error("Failed to print match explanations", zap.Error(err))
+		}
+	}
+
+	reports := a.detect(ctx, stateInstances, configInstances, attrs, opts)
 
-		// In CLI mode, exit after printing drift
-		if runtype == ports.CLI {
-			os.Exit(0)
+	if len(reports) > 0 && !HasDrift(reports, opts) {
+		a.Logger.Info("Only clean or unmanaged instances or drift below the severity threshold found",
+			zap.Int("report_count", len(reports)),
+			zap.String("fail_on_severity", string(opts.FailOnSeverity)))
+		if err := a.writeReports(reports, opts); err != nil {
+			return err
 		}
+		a.finishRun(ctx, reports, opts)
+		return nil
+	}
+
+	if len(reports) > 0 {
+		a.Logger.Info("Drift detected", zap.Int("report_count", len(reports)), zap.String("runtype", string(runtype)))
+		if err := a.writeReports(reports, opts); err != nil {
+			return err
+		}
+		a.finishRun(ctx, reports, opts)
+
+		// Callers decide what drift means for them, the CLI exits 2
 		return errors.NewDriftDetected()
 	}
 
+	if len(opts.Only) > 0 {
+		// Keep the table header visible so a filtered run with no
+		// matches is distinguishable from a run that printed nothing
+		a.Logger.Info("No drift matched the output filter", zap.Strings("only", opts.Only))
+		if err := a.writeReports(reports, opts); err != nil {
+			return err
+		}
+		a.finishRun(ctx, reports, opts)
+		return nil
+	}
+
+	if selectsInstances(opts) && len(selectedInstances(stateInstances, opts)) == 0 {
+		a.Logger.Info("No live instance matched the instance selection",
+			zap.Strings("instances", opts.Instances),
+			zap.Strings("exclude_instances", opts.ExcludeInstances),
+			zap.String("vpc_id", opts.VpcID),
+			zap.String("subnet_id", opts.SubnetID))
+		if err := a.writeReports(reports, opts); err != nil {
+			return err
+		}
+		if !opts.Quiet {
+			fmt.Fprintln(a.stdout(), "no matching instances")
+		}
+		a.finishRun(ctx, reports, opts)
+		return errors.NewErrNoMatchingInstances()
+	}
+
 	a.Logger.Info("No drift detected")
+	// Clean runs are reported too, so machine readable formats get an empty
+	// document and report files of earlier runs are replaced
+	if err := a.writeReports(reports, opts); err != nil {
+		return err
+	}
+	a.finishRun(ctx, reports, opts)
+	return nil
+}
+
+// finishRun runs the --exec hook and prints the exit summary once the
+// reports are written
+func (a *App) finishRun(ctx context.Context, reports []driftchecker.DriftReport, opts RunOptions) {
+	a.runHook(ctx, reports, opts)
+	a.printExitSummary(reports, opts)
+}
+
+// runHook runs the --exec command with the JSON report on its stdin. Its
+// output is logged; a failing hook only logs a warning so it never changes
+// the outcome of the run.
+func (a *App) runHook(ctx context.Context, reports []driftchecker.DriftReport, opts RunOptions) {
+	if opts.Exec == "" {
+		return
+	}
+
+	var report bytes.Buffer
+	title := a.reportTitle(opts)
+	if err := output.Render(&report, output.JSON, reports, output.WithTitle(title), output.WithMeta(opts.meta)); err != nil {
+		a.Logger.Warn("Failed to render the report for the exec hook", zap.Error(err))
+		return
+	}
+	result := hook.Result{DriftDetected: HasDrift(reports, opts), ReportCount: len(reports), Title: title}
+
+	out, err := hook.Run(ctx, opts.Exec, &report, result, opts.ExecTimeout)
+	if err != nil {
+		a.Logger.Warn("Exec hook failed", zap.String("command", opts.Exec), zap.ByteString("output", out), zap.Error(err))
+		return
+	}
+	a.Logger.Info("Exec hook finished", zap.String("command", opts.Exec), zap.ByteString("output", out))
+}
+
+// printExitSummary prints the JSON exit summary when it was requested. It
+// runs last so the summary is the final line on stderr.
+func (a *App) printExitSummary(reports []driftchecker.DriftReport, opts RunOptions) {
+	if !opts.ExitSummary {
+		return
+	}
+
+	var duration time.Duration
+	if !opts.startedAt.IsZero() {
+		duration = time.Since(opts.startedAt)
+	}
+	summary := output.NewExitSummary(reports, HasDrift(reports, opts), duration)
+	if opts.calls != nil {
+		summary.APICalls = opts.calls.Total()
+		summary.APICallsByOperation = opts.calls.Counts()
+	}
+	if opts.warnings != nil {
+		summary.Warnings = opts.warnings.Warnings()
+	}
+	if err := output.WriteExitSummary(a.stderr(), summary); err != nil {
+		a.Logger.Error("Failed to print exit summary", zap.Error(err))
+	}
+}
+
+// detect compares the instances and applies the per-run report options
+func (a *App) detect(
+	ctx context.Context,
+	stateInstances, configInstances []cloud.Instance,
+	attrs []string,
+	opts RunOptions,
+) []driftchecker.DriftReport {
+	// The desired config is the baseline, so live instances missing from it
+	// are reported as instance_added and config-only ones as instance_removed
+	reports := []driftchecker.DriftReport{}
+	for report := range a.detectStream(ctx, stateInstances, configInstances, attrs, opts) {
+		reports = append(reports, report)
+	}
+	if opts.StrictMatch {
+		reports = append(reports, driftchecker.Unmatched(configInstances)...)
+	}
+	if opts.UnmanagedOK {
+		reports = driftchecker.MarkUnmanaged(reports)
+	}
+	reports = driftchecker.Filter(reports, opts.Only)
+	return driftchecker.AssignSeverity(reports, a.config().Severities)
+}
+
+// HasDrift reports whether the reports should count as drift, honouring
+// the severity threshold of the run
+func HasDrift(reports []driftchecker.DriftReport, opts RunOptions) bool {
+	if opts.FailOnSeverity != "" {
+		return driftchecker.HasDriftAtLeast(reports, opts.FailOnSeverity)
+	}
+	return driftchecker.HasDrift(reports)
+}
+
+// detectStream compares the desired instances against the live ones with
+// the configured comparators. With AutoAttributes each desired instance is
+// only compared on the attributes it sets.
+func (a *App) detectStream(
+	ctx context.Context,
+	stateInstances, configInstances []cloud.Instance,
+	attrs []string,
+	opts RunOptions,
+) <-chan driftchecker.DriftReport {
+	detectOpts := []driftchecker.DetectOption{
+		driftchecker.WithComparators(a.config().Comparators),
+		driftchecker.WithInstances(opts.Instances, opts.ExcludeInstances),
+		driftchecker.WithNetwork(opts.VpcID, opts.SubnetID),
+		driftchecker.WithNoDriftReports(opts.IncludeNoDrift),
+		driftchecker.WithIgnoreCaseValues(opts.IgnoreCase),
+		driftchecker.WithExpectedState(opts.ExpectedState),
+	}
+	if opts.AutoAttributes {
+		return driftchecker.DetectStreamWith(ctx, configInstances, stateInstances, driftchecker.PopulatedAttributes(attrs), detectOpts...)
+	}
+	return driftchecker.DetectStream(ctx, configInstances, stateInstances, attrs, detectOpts...)
+}
+
+// selectsInstances reports whether the run is limited to some live instances
+func selectsInstances(opts RunOptions) bool {
+	return len(opts.Instances) > 0 || len(opts.ExcludeInstances) > 0 || opts.VpcID != "" || opts.SubnetID != ""
+}
+
+// selectedInstances returns the live instances the selection keeps
+func selectedInstances(stateInstances []cloud.Instance, opts RunOptions) []cloud.Instance {
+	selected := driftchecker.SelectInstances(stateInstances, opts.Instances, opts.ExcludeInstances)
+	return driftchecker.SelectNetwork(selected, opts.VpcID, opts.SubnetID)
+}
+
+// reportTitle returns the title of the run, --report-title taking
+// precedence over REPORT_TITLE
+func (a *App) reportTitle(opts RunOptions) string {
+	if opts.ReportTitle != "" {
+		return opts.ReportTitle
+	}
+	return a.ReportTitle()
+}
+
+// writeReports prints the drift reports to stdout unless quiet and writes
+// them to the output file. The --output-file flag takes precedence over
+// OUTPUT_PATH; the file format follows opts.Output or the file extension.
+// With an output directory the reports of each application are also
+// written to a file of their own.
+func (a *App) writeReports(reports []driftchecker.DriftReport, opts RunOptions) error {
+	renderOpts := []output.RenderOption{
+		output.WithPrettyJSON(opts.JSONPretty),
+		output.WithTitle(a.reportTitle(opts)),
+		output.WithGroupBy(opts.GroupBy),
+		output.WithTemplate(opts.Template),
+		output.WithMeta(opts.meta),
+	}
+
+	if !opts.Quiet {
+		format := opts.Output
+		if format == "" {
+			format = output.Table
+		}
+		if err := output.Render(a.stdout(), format, reports, renderOpts...); err != nil {
+			return err
+		}
+	}
+
+	path := opts.OutputFile
+	if path == "" {
+		path = a.config().OutputPath
+	}
+	if path != "" {
+		format := opts.Output
+		if format == "" {
+			format = output.FormatFromPath(path)
+		}
+		if err := output.WriteFile(path, format, reports, renderOpts...); err != nil {
+			a.Logger.Error("Failed to write drift report", zap.String("path", path), zap.Error(err))
+			return err
+		}
+		a.Logger.Info("Drift report written", zap.String("path", path), zap.String("format", string(format)))
+	}
+
+	if opts.OutputDir != "" {
+		format := opts.Output
+		if format == "" {
+			format = output.JSON
+		}
+		paths, err := output.WriteDir(opts.OutputDir, format, reports, renderOpts...)
+		if err != nil {
+			a.Logger.Error("Failed to write drift reports", zap.String("dir", opts.OutputDir), zap.Error(err))
+			return err
+		}
+		a.Logger.Info("Drift reports written", zap.String("dir", opts.OutputDir), zap.Int("files", len(paths)),
+			zap.String("format", string(format)))
+	}
 	return nil
 }
@@ -2,25 +2,40 @@ package app_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
 	"github.com/oldmonad/ec2Drift/pkg/cloud/aws"
 	"github.com/oldmonad/ec2Drift/pkg/cloud/gcp"
 	config "github.com/oldmonad/ec2Drift/pkg/config/cloud"
 	awsConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
+	gcpConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/gcp"
 	"github.com/oldmonad/ec2Drift/pkg/config/env"
 	customErr "github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/output"
 	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func createTempFile(t *testing.T, content []byte) string {
@@ -76,6 +91,127 @@ func TestLoadStateFileNotFound(t *testing.T) {
 	assert.IsType(t, customErr.ErrReadFile{}, err)
 }
 
+func TestLoadStateFileEmpty(t *testing.T) {
+	for name, content := range map[string]string{"empty": "", "whitespace only": " \n\t\r\n "} {
+		t.Run(name, func(t *testing.T) {
+			tmpFile := createTempFile(t, []byte(content))
+			a := app.NewApp(env.Configurations{StatePath: tmpFile})
+
+			_, err := a.LoadStateFile()
+			var emptyErr customErr.ErrEmptyStateFile
+			require.ErrorAs(t, err, &emptyErr)
+			assert.Equal(t, tmpFile, emptyErr.Path)
+		})
+	}
+}
+
+func TestSetFS(t *testing.T) {
+	logger.Init(true)
+
+	fsys := fstest.MapFS{
+		"state/main.tf":     {Data: []byte("resource \"aws_instance\" \"web\" {\n  ami           = \"ami-123\"\n  instance_type = \"t2.micro\"\n}\n")},
+		"configs/web.json":  {Data: []byte(`[{"ami": "ami-123", "tags": {"Name": "web"}}]`)},
+		"configs/db.json":   {Data: []byte(`[{"ami": "ami-123", "tags": {"Name": "db"}}]`)},
+		"configs/notes.txt": {Data: []byte(`not a config`)},
+	}
+
+	live := []cloud.Instance{
+		{InstanceID: "i-1", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "i-2", AMI: "ami-456", Tags: map[string]string{"Name": "db"}},
+	}
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).Return(live, nil)
+
+	a := app.NewApp(env.Configurations{StatePath: "state/main.tf", CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{}})
+	a.SetCloudProvider(config.AWS, mockProvider)
+	a.SetFS(fsys)
+
+	t.Run("state file", func(t *testing.T) {
+		data, err := a.LoadStateFile()
+		require.NoError(t, err)
+		assert.Equal(t, fsys["state/main.tf"].Data, data)
+	})
+
+	t.Run("config directory", func(t *testing.T) {
+		reports, err := a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{ConfigFiles: []string{"configs"}})
+		require.NoError(t, err)
+		require.Len(t, reports, 1, "both files are compared, only db drifts")
+		assert.Equal(t, "db", reports[0].Name)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{ConfigFiles: []string{"configs/api.json"}})
+		assert.ErrorAs(t, err, &customErr.ErrReadFile{})
+	})
+}
+
+func TestCheckWarnsAboutUnsupportedAttributes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.tf":   {Data: []byte("resource \"aws_instance\" \"web\" {\n  ami = \"ami-123\"\n  tags = { Name = \"web\" }\n}\n")},
+		"main.json": {Data: []byte(`[{"ami": "ami-123", "security_groups": ["sg-1"], "tags": {"Name": "web"}}]`)},
+	}
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).
+		Return([]cloud.Instance{{InstanceID: "i-1", AMI: "ami-123", SecurityGroups: []string{"sg-1"}, Tags: map[string]string{"Name": "web"}}}, nil)
+
+	check := func(t *testing.T, statePath string, format parser.ParserType) []observer.LoggedEntry {
+		core, logs := observer.New(zap.WarnLevel)
+		a := app.NewApp(env.Configurations{StatePath: statePath, CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{}})
+		a.Logger = zap.New(core)
+		a.SetCloudProvider(config.AWS, mockProvider)
+		a.SetFS(fsys)
+
+		_, err := a.Check(context.Background(), []string{"ami", "security_groups", "public_ip", "tags.Env"}, format, app.RunOptions{})
+		require.NoError(t, err)
+		return logs.FilterMessage("Attribute not supported by parser, its drift cannot be detected").All()
+	}
+
+	t.Run("terraform", func(t *testing.T) {
+		entries := check(t, "main.tf", parser.Terraform)
+		require.Len(t, entries, 2)
+		assert.Equal(t, map[string]interface{}{"attribute": "security_groups", "parser": "terraform"}, entries[0].ContextMap())
+		assert.Equal(t, map[string]interface{}{"attribute": "public_ip", "parser": "terraform"}, entries[1].ContextMap())
+	})
+
+	t.Run("json populates every attribute", func(t *testing.T) {
+		assert.Empty(t, check(t, "main.json", parser.JSON))
+	})
+}
+
+func TestCheckDefaultAttributesSkipUnsupported(t *testing.T) {
+	logger.Init(true)
+
+	fsys := fstest.MapFS{
+		"main.tf": {Data: []byte("resource \"aws_instance\" \"web\" {\n  ami = \"ami-123\"\n  instance_type = \"t2.micro\"\n  tags = { Name = \"web\" }\n}\n")},
+	}
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).
+		Return([]cloud.Instance{{InstanceID: "i-1", AMI: "ami-123", PublicIP: "54.1.1.1", SecurityGroups: []string{"sg-1"}, Tags: map[string]string{"Name": "web"}}}, nil)
+
+	core, logs := observer.New(zap.WarnLevel)
+	a := app.NewApp(env.Configurations{StatePath: "main.tf", CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{}})
+	a.Logger = zap.New(core)
+	a.SetCloudProvider(config.AWS, mockProvider)
+	a.SetFS(fsys)
+	attrs := []string{"ami", "security_groups", "public_ip"}
+
+	// The terraform parser never sets security groups or public IPs, so they
+	// are left out of the default set instead of drifting on every instance
+	reports, err := a.Check(context.Background(), attrs, parser.Terraform, app.RunOptions{DefaultAttributes: true})
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+	assert.Empty(t, logs.FilterMessage("Attribute not supported by parser, its drift cannot be detected").All())
+	assert.Equal(t, []string{"ami"},
+		a.RunMeta(attrs, parser.Terraform, app.RunOptions{DefaultAttributes: true}).Attributes)
+
+	// A selected attribute is still compared
+	reports, err = a.Check(context.Background(), attrs, parser.Terraform, app.RunOptions{})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Drifts, 1, "the desired public IP is unset")
+	assert.Equal(t, "security_groups", reports[0].Drifts[0].Attribute)
+}
+
 func TestParseConfigInstancesTerraform(t *testing.T) {
 	content := []byte(`
 resource "aws_instance" "test" {
@@ -118,7 +254,7 @@ func TestParseConfigInstancesInvalid(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestParseConfigInstancesDefaultParser(t *testing.T) {
+func TestParseConfigInstancesUnknownParser(t *testing.T) {
 	content := []byte(`
 resource "aws_instance" "test" {
   ami           = "ami-123456"
@@ -126,11 +262,40 @@ resource "aws_instance" "test" {
 }`)
 	configurations := env.Configurations{}
 	a := app.NewApp(configurations)
-	instances, err := a.ParseConfigInstances(content, parser.Unknown) // Invalid parser type to trigger default
+	instances, err := a.ParseConfigInstances(content, parser.Unknown) // Unknown formats are no longer parsed as Terraform
 
-	assert.NoError(t, err)
+	var unknownErr customErr.ErrUnknownParser
+	require.ErrorAs(t, err, &unknownErr)
+	assert.Equal(t, "unknown", unknownErr.Format)
+	assert.Equal(t, []string{"json", "terraform"}, unknownErr.Registered)
+	assert.Nil(t, instances)
+}
+
+// stubParser returns fixed instances whatever the content
+type stubParser struct {
+	instances []cloud.Instance
+}
+
+func (p stubParser) Parse([]byte) ([]cloud.Instance, error) {
+	return p.instances, nil
+}
+
+func TestRegisterParser(t *testing.T) {
+	a := app.NewApp(env.Configurations{})
+	yaml := parser.ParserType("yaml")
+	a.RegisterParser(yaml, func() parser.Parser {
+		return stubParser{instances: []cloud.Instance{{InstanceID: "from-yaml"}}}
+	})
+
+	instances, err := a.ParseConfigInstances([]byte("ignored"), yaml)
+	require.NoError(t, err)
 	require.Len(t, instances, 1)
-	assert.Equal(t, "ami-123456", instances[0].AMI)
+	assert.Equal(t, "from-yaml", instances[0].InstanceID)
+
+	// Built in parsers remain registered
+	_, err = a.ParseConfigInstances([]byte(`[]`), parser.JSON)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"json", "terraform", "yaml"}, a.InputFormats())
 }
 
 type CloudProviderFactory func(providerType config.ProviderType) cloud.CloudProvider
@@ -197,7 +362,7 @@ func (t *TestableApp) ParseConfigInstances(content []byte, format parser.ParserT
 }
 
 // Override Run to use our mocked methods
-func (t *TestableApp) Run(ctx context.Context, attrs []string, format parser.ParserType, runtype ports.Runtype) error {
+func (t *TestableApp) Run(ctx context.Context, attrs []string, format parser.ParserType, runtype ports.Runtype, opts app.RunOptions) error {
 	// Obtain current live cloud state using mocked provider
 	stateInstances, err := t.GetLiveStateInstances(ctx, t.App.Configurations().CloudConfig)
 	if err != nil {
@@ -217,7 +382,7 @@ func (t *TestableApp) Run(ctx context.Context, attrs []string, format parser.Par
 	}
 
 	// Use the real HandleDrift method
-	return t.App.HandleDrift(ctx, stateInstances, configInstances, attrs, runtype)
+	return t.App.HandleDrift(ctx, stateInstances, configInstances, attrs, runtype, opts)
 }
 
 func TestRunEndToEnd(t *testing.T) {
@@ -257,7 +422,7 @@ resource "aws_instance" "test" {
 		}
 
 		testApp := NewTestableApp(configurations, mockProvider)
-		err := testApp.Run(context.Background(), []string{"ami", "instance_type"}, parser.Terraform, ports.HTTP)
+		err := testApp.Run(context.Background(), []string{"ami", "instance_type"}, parser.Terraform, ports.HTTP, app.RunOptions{})
 
 		// Verify no error returned (no drift)
 		assert.NoError(t, err)
@@ -288,7 +453,7 @@ resource "aws_instance" "test" {
 		}
 
 		testApp := NewTestableApp(configurations, mockProvider)
-		err := testApp.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP)
+		err := testApp.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP, app.RunOptions{})
 
 		// Verify provider error propagated
 		assert.Error(t, err)
@@ -319,7 +484,7 @@ resource "aws_instance" "test" {
 		}
 
 		testApp := NewTestableApp(configurations, mockProvider)
-		err := testApp.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP)
+		err := testApp.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP, app.RunOptions{})
 
 		// Verify parser error returned
 		assert.Error(t, err)
@@ -357,8 +522,10 @@ resource "aws_instance" "test" {
 					"Environment": "staging", // Different tag value
 				},
 				RootBlockDevice: struct {
-					VolumeSize int    `json:"volume_size"`
-					VolumeType string `json:"volume_type"`
+					VolumeSize          int    `json:"volume_size"`
+					VolumeType          string `json:"volume_type"`
+					Encrypted           *bool  `json:"encrypted,omitempty"`
+					DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 				}{
 					VolumeSize: 30, // Different volume size
 					VolumeType: "gp2",
@@ -383,7 +550,8 @@ resource "aws_instance" "test" {
 		err := testApp.Run(context.Background(),
 			[]string{"ami", "instance_type", "tags.Environment", "root_block_device.volume_size"},
 			parser.Terraform,
-			ports.HTTP)
+			ports.HTTP,
+			app.RunOptions{})
 
 		// Verify drift error returned
 		require.Error(t, err)
@@ -428,10 +596,1127 @@ resource "aws_instance" "test" {
 		}
 
 		testApp := NewTestableApp(configurations, mockProvider)
-		err := testApp.Run(context.Background(), []string{"ami", "instance_type"}, parser.JSON, ports.HTTP)
+		err := testApp.Run(context.Background(), []string{"ami", "instance_type"}, parser.JSON, ports.HTTP, app.RunOptions{})
 
 		// Verify no error (no drift)
 		assert.NoError(t, err)
 		mockProvider.AssertExpectations(t)
 	})
+	// Test case: Output filter removes all drift
+	t.Run("OnlyFilterWithoutMatches", func(t *testing.T) {
+		content := []byte(`
+resource "aws_instance" "test" {
+  ami           = "ami-123456"
+  instance_type = "t2.micro"
+  tags = {
+    Name = "web-server"
+  }
+}`)
+		tmpFile := createTempFile(t, content)
+
+		// Live instance differs only by AMI, so only "changed" drift exists
+		mockProvider := new(MockCloudProvider)
+		liveInstances := []cloud.Instance{
+			{
+				InstanceID:   "i-123456",
+				AMI:          "ami-654321",
+				InstanceType: "t2.micro",
+				Tags:         map[string]string{"Name": "web-server"},
+			},
+		}
+		mockProvider.On("FetchInstances", mock.Anything, mock.Anything).Return(liveInstances, nil)
+
+		configurations := env.Configurations{
+			StatePath:         tmpFile,
+			CloudProviderType: config.AWS,
+			CloudConfig:       &awsConfig.Config{},
+		}
+
+		testApp := NewTestableApp(configurations, mockProvider)
+
+		err := testApp.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP,
+			app.RunOptions{Only: []string{"added"}})
+		assert.NoError(t, err)
+
+		err = testApp.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP,
+			app.RunOptions{Only: []string{"ami"}})
+		var driftErr customErr.ErrDriftDetected
+		assert.True(t, errors.As(err, &driftErr), "expected error to be of type ErrDriftDetected")
+	})
+}
+
+// TestFetchReusesCloudProvider tests against a stub endpoint that fetches
+// share the AWS provider, and with it the credentials its client resolved,
+// until the configuration is reloaded
+func TestFetchReusesCloudProvider(t *testing.T) {
+	logger.Init(true)
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CA_BUNDLE", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	// The credential process hands out a new key on every call
+	script := filepath.Join(dir, "credentials.sh")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+echo x >> "$(dirname "$0")/calls"
+n=$(wc -l < "$(dirname "$0")/calls" | tr -d ' ')
+printf '{"Version": 1, "AccessKeyId": "AKIDCALL%s", "SecretAccessKey": "secret", "SessionToken": "token"}' "$n"
+`), 0o700))
+	configFile := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(configFile, []byte("[profile ops]\ncredential_process = "+script+"\n"), 0o600))
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	var keys []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, credential, _ := strings.Cut(r.Header.Get("Authorization"), "Credential=")
+		key, _, _ := strings.Cut(credential, "/")
+		mu.Lock()
+		keys = append(keys, key)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">`+
+			`<requestId>req-1</requestId><reservationSet/></DescribeInstancesResponse>`)
+	}))
+	defer server.Close()
+
+	configurations := env.Configurations{
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{Region: "us-east-1", Profile: "ops", EndpointURL: server.URL},
+	}
+	a := app.NewApp(configurations)
+	for range 2 {
+		_, err := a.Fetch(context.Background(), []string{"ami"}, app.RunOptions{})
+		require.NoError(t, err)
+	}
+	a.Reload(configurations)
+	_, err := a.Fetch(context.Background(), []string{"ami"}, app.RunOptions{})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"AKIDCALL1", "AKIDCALL1", "AKIDCALL2"}, keys,
+		"the client is reused until a reload, which may change the credentials")
+}
+
+func TestGetLiveStateInstancesMultipleProviders(t *testing.T) {
+	logger.Init(true)
+
+	awsCfg := &awsConfig.Config{Region: "us-west-2"}
+	gcpCfg := &gcpConfig.Config{ProjectID: "project", Region: "europe-west1"}
+
+	configurations := env.Configurations{
+		CloudProviderType:  config.AWS,
+		CloudProviderTypes: []config.ProviderType{config.AWS, config.GCP},
+		CloudConfig:        awsCfg,
+		CloudConfigs: map[config.ProviderType]config.ProviderConfig{
+			config.AWS: awsCfg,
+			config.GCP: gcpCfg,
+		},
+	}
+
+	t.Run("merges and tags instances", func(t *testing.T) {
+		awsProvider := new(MockCloudProvider)
+		awsProvider.On("FetchInstances", mock.Anything, awsCfg).
+			Return([]cloud.Instance{{InstanceID: "i-123", AMI: "ami-123"}}, nil)
+		gcpProvider := new(MockCloudProvider)
+		gcpProvider.On("FetchInstances", mock.Anything, gcpCfg).
+			Return([]cloud.Instance{{InstanceID: "gcp-1", AMI: "img-1"}}, nil)
+
+		a := app.NewApp(configurations)
+		a.SetCloudProvider(config.AWS, awsProvider)
+		a.SetCloudProvider(config.GCP, gcpProvider)
+
+		instances, err := a.GetLiveStateInstances(context.Background(), awsCfg)
+		require.NoError(t, err)
+		require.Len(t, instances, 2)
+		assert.Equal(t, "i-123", instances[0].InstanceID)
+		assert.Equal(t, "aws", instances[0].Provider)
+		assert.Equal(t, "gcp-1", instances[1].InstanceID)
+		assert.Equal(t, "gcp", instances[1].Provider)
+
+		awsProvider.AssertExpectations(t)
+		gcpProvider.AssertExpectations(t)
+	})
+
+	t.Run("single provider leaves instances untagged", func(t *testing.T) {
+		awsProvider := new(MockCloudProvider)
+		awsProvider.On("FetchInstances", mock.Anything, awsCfg).
+			Return([]cloud.Instance{{InstanceID: "i-123", AMI: "ami-123"}}, nil)
+
+		a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: awsCfg})
+		a.SetCloudProvider(config.AWS, awsProvider)
+
+		instances, err := a.GetLiveStateInstances(context.Background(), awsCfg)
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Empty(t, instances[0].Provider, "reports show no provider column")
+	})
+
+	t.Run("provider error is returned", func(t *testing.T) {
+		expectedErr := errors.New("gcp api error")
+
+		awsProvider := new(MockCloudProvider)
+		awsProvider.On("FetchInstances", mock.Anything, awsCfg).
+			Return([]cloud.Instance{{InstanceID: "i-123"}}, nil)
+		gcpProvider := new(MockCloudProvider)
+		gcpProvider.On("FetchInstances", mock.Anything, gcpCfg).
+			Return([]cloud.Instance{}, expectedErr)
+
+		a := app.NewApp(configurations)
+		a.SetCloudProvider(config.AWS, awsProvider)
+		a.SetCloudProvider(config.GCP, gcpProvider)
+
+		_, err := a.GetLiveStateInstances(context.Background(), awsCfg)
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestHandleDriftUnmanagedInstances(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{
+		{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+	}
+	live := []cloud.Instance{
+		{InstanceID: "i-123", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "i-999", AMI: "ami-999", Tags: map[string]string{"Name": "scratch"}},
+	}
+
+	a := app.NewApp(env.Configurations{})
+
+	// By default a live instance missing from the config is drift
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP, app.RunOptions{})
+	var driftErr customErr.ErrDriftDetected
+	assert.True(t, errors.As(err, &driftErr), "expected error to be of type ErrDriftDetected")
+
+	// With UnmanagedOK it is reported but does not count as drift
+	err = a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+		app.RunOptions{UnmanagedOK: true})
+	assert.NoError(t, err)
+
+	// Real drift is still reported alongside unmanaged instances
+	live[0].AMI = "ami-456"
+	err = a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+		app.RunOptions{UnmanagedOK: true})
+	assert.True(t, errors.As(err, &driftErr), "expected error to be of type ErrDriftDetected")
+}
+
+func TestRunExpandsEnvironmentVariables(t *testing.T) {
+	logger.Init(true)
+
+	content := []byte(`
+resource "aws_instance" "web" {
+  ami           = "${AMI_ID}"
+  instance_type = "t2.micro"
+  tags = {
+    Name = "web"
+  }
+}`)
+	tmpFile := createTempFile(t, content)
+
+	liveInstances := []cloud.Instance{
+		{InstanceID: "i-123", AMI: "ami-123456", InstanceType: "t2.micro", Tags: map[string]string{"Name": "web"}},
+	}
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).Return(liveInstances, nil)
+
+	a := app.NewApp(env.Configurations{
+		StatePath:         tmpFile,
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{},
+	})
+	a.SetCloudProvider(config.AWS, mockProvider)
+
+	t.Run("undefined variable", func(t *testing.T) {
+		err := a.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP, app.RunOptions{})
+		var undefinedErr customErr.ErrUndefinedEnvVars
+		require.ErrorAs(t, err, &undefinedErr)
+		assert.Equal(t, []string{"AMI_ID"}, undefinedErr.Names)
+	})
+
+	t.Run("expanded", func(t *testing.T) {
+		t.Setenv("AMI_ID", "ami-123456")
+		err := a.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP, app.RunOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("expansion disabled", func(t *testing.T) {
+		t.Setenv("AMI_ID", "ami-123456")
+		err := a.Run(context.Background(), []string{"ami"}, parser.Terraform, ports.HTTP, app.RunOptions{NoExpand: true})
+		var driftErr customErr.ErrDriftDetected
+		assert.True(t, errors.As(err, &driftErr), "literal ${AMI_ID} should drift from the live AMI")
+	})
+}
+
+func TestRunNoAttributesSelected(t *testing.T) {
+	a := app.NewApp(env.Configurations{})
+
+	err := a.Run(context.Background(), []string{}, parser.Terraform, ports.HTTP, app.RunOptions{})
+	assert.ErrorAs(t, err, &customErr.ErrNoAttributesSelected{})
+}
+
+func TestHandleDriftOutputFile(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-456", Tags: map[string]string{"Name": "web"}}}
+
+	t.Run("OUTPUT_PATH is used and the format follows the extension", func(t *testing.T) {
+		envPath := filepath.Join(t.TempDir(), "env_report.json")
+		a := app.NewApp(env.Configurations{OutputPath: envPath})
+
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP, app.RunOptions{Quiet: true})
+		assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		data, readErr := os.ReadFile(envPath)
+		require.NoError(t, readErr)
+		assert.Contains(t, string(data), `"instance_id":"i-123"`)
+	})
+
+	t.Run("pretty JSON applies to the output file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "report.json")
+		a := app.NewApp(env.Configurations{})
+
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+			app.RunOptions{OutputFile: path, Quiet: true, JSONPretty: true})
+		assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		data, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		assert.Contains(t, string(data), "\n      \"instance_id\": \"i-123\"")
+	})
+
+	t.Run("flag overrides OUTPUT_PATH", func(t *testing.T) {
+		dir := t.TempDir()
+		envPath := filepath.Join(dir, "env_report.json")
+		flagPath := filepath.Join(dir, "flag_report.csv")
+		a := app.NewApp(env.Configurations{OutputPath: envPath})
+
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+			app.RunOptions{OutputFile: flagPath, Quiet: true})
+		assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		assert.NoFileExists(t, envPath)
+		data, readErr := os.ReadFile(flagPath)
+		require.NoError(t, readErr)
+		assert.Contains(t, string(data), "i-123,web,,ami,ami-123,ami-456")
+	})
+
+	t.Run("explicit format wins over the extension", func(t *testing.T) {
+		flagPath := filepath.Join(t.TempDir(), "report.txt")
+		a := app.NewApp(env.Configurations{})
+
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+			app.RunOptions{OutputFile: flagPath, Output: output.HTML, Quiet: true})
+		assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		data, readErr := os.ReadFile(flagPath)
+		require.NoError(t, readErr)
+		assert.Contains(t, string(data), "<td>i-123</td>")
+	})
+
+	t.Run("unwritable file is an error", func(t *testing.T) {
+		a := app.NewApp(env.Configurations{})
+
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+			app.RunOptions{OutputFile: filepath.Join(t.TempDir(), "missing", "report.json"), Quiet: true})
+		assert.ErrorAs(t, err, &customErr.ErrWriteOutput{})
+	})
+}
+
+func TestHandleDriftExitSummary(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{
+		{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "db", AMI: "ami-123", Tags: map[string]string{"Name": "db"}},
+	}
+	live := []cloud.Instance{
+		{InstanceID: "i-123", AMI: "ami-456", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "i-789", AMI: "ami-123", Tags: map[string]string{"Name": "worker"}},
+	}
+
+	// Capture stderr, which also carries the development logger output
+	old := os.Stderr
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stderr = w
+
+	a := app.NewApp(env.Configurations{})
+	runErr := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+		app.RunOptions{Quiet: true, ExitSummary: true})
+
+	w.Close()
+	os.Stderr = old
+	stderr, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	assert.ErrorAs(t, runErr, &customErr.ErrDriftDetected{})
+
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	var summary map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &summary), "summary must be the last line")
+	assert.Equal(t, map[string]interface{}{
+		"drift_detected": true,
+		"instances":      float64(3),
+		"added":          float64(1),
+		"removed":        float64(1),
+		"changed":        float64(1),
+		"unmanaged":      float64(0),
+		"missing":        float64(0),
+		"api_calls":      float64(0), // HandleDrift alone makes no cloud calls
+		"duration_ms":    float64(0), // HandleDrift is not timed, only Run is
+	}, summary)
+}
+
+func TestCheckAutoAttributes(t *testing.T) {
+	logger.Init(true)
+
+	// Only the AMI is set in the desired config
+	content := []byte(`[{"ami": "ami-123", "tags": {"Name": "web"}}]`)
+	tmpFile := createTempFile(t, content)
+
+	liveInstances := []cloud.Instance{
+		{InstanceID: "i-123", AMI: "ami-456", InstanceType: "t3.large", Tags: map[string]string{"Name": "web"}},
+	}
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).Return(liveInstances, nil)
+
+	a := app.NewApp(env.Configurations{
+		StatePath:         tmpFile,
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{},
+	})
+	a.SetCloudProvider(config.AWS, mockProvider)
+
+	attrs := []string{"ami", "instance_type"}
+
+	reports, err := a.Check(context.Background(), attrs, parser.JSON, app.RunOptions{})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Len(t, reports[0].Drifts, 2)
+
+	reports, err = a.Check(context.Background(), attrs, parser.JSON, app.RunOptions{AutoAttributes: true})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Drifts, 1)
+	assert.Equal(t, "ami", reports[0].Drifts[0].Attribute)
+}
+
+func TestHandleDriftFailOnSeverity(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web", "Env": "prod"}}}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-123", Tags: map[string]string{"Name": "web", "Env": "dev"}}}
+
+	a := app.NewApp(env.Configurations{
+		Severities: map[string]driftchecker.Severity{"ami": driftchecker.SeverityCritical, "tags": driftchecker.SeverityInfo},
+	})
+
+	// Only info level tag drift, which is below the threshold
+	var stderr strings.Builder
+	a.SetErr(&stderr)
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami", "tags"}, ports.HTTP,
+		app.RunOptions{FailOnSeverity: driftchecker.SeverityCritical, Quiet: true, ExitSummary: true})
+	assert.NoError(t, err)
+
+	var summary output.ExitSummary
+	require.NoError(t, json.Unmarshal([]byte(stderr.String()), &summary))
+	assert.False(t, summary.DriftDetected, "the exit summary honours the threshold")
+	assert.Equal(t, 1, summary.Changed)
+
+	// Without a threshold any drift counts
+	err = a.HandleDrift(context.Background(), live, config, []string{"ami", "tags"}, ports.HTTP, app.RunOptions{Quiet: true})
+	assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+	// Critical AMI drift reaches the threshold
+	live[0].AMI = "ami-456"
+	err = a.HandleDrift(context.Background(), live, config, []string{"ami", "tags"}, ports.HTTP,
+		app.RunOptions{FailOnSeverity: driftchecker.SeverityCritical, Quiet: true})
+	assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+	// Reports carry the severity of each drift
+	path := filepath.Join(t.TempDir(), "report.json")
+	err = a.HandleDrift(context.Background(), live, config, []string{"ami", "tags"}, ports.HTTP,
+		app.RunOptions{OutputFile: path, Quiet: true})
+	assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+	data, readErr := os.ReadFile(path)
+	require.NoError(t, readErr)
+	assert.Contains(t, string(data), `"severity":"critical"`)
+	assert.Contains(t, string(data), `"severity":"info"`)
+}
+
+func TestRunRequestsTerminationProtectionOnlyWhenSelected(t *testing.T) {
+	logger.Init(true)
+
+	tmpFile := createTempFile(t, []byte(`[{"ami": "ami-123", "disable_api_termination": true, "tags": {"Name": "web"}}]`))
+	protected := true
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-123", DisableApiTermination: &protected, Tags: map[string]string{"Name": "web"}}}
+
+	run := func(attrs []string, opts app.RunOptions, fetch bool) {
+		mockProvider := new(MockCloudProvider)
+		mockProvider.On("FetchInstances", mock.Anything, mock.MatchedBy(func(cfg config.ProviderConfig) bool {
+			awsCfg, ok := cfg.(*awsConfig.Config)
+			return ok && awsCfg.FetchTerminationProtection == fetch
+		})).Return(live, nil).Once()
+
+		shared := &awsConfig.Config{}
+		a := app.NewApp(env.Configurations{StatePath: tmpFile, CloudProviderType: config.AWS, CloudConfig: shared})
+		a.SetCloudProvider(config.AWS, mockProvider)
+
+		err := a.Run(context.Background(), attrs, parser.JSON, ports.HTTP, opts)
+		assert.NoError(t, err, attrs)
+		mockProvider.AssertExpectations(t)
+		assert.False(t, shared.FetchTerminationProtection, "the shared config is left untouched")
+	}
+
+	for attr, fetch := range map[string]bool{"disable_api_termination": true, "ami": false} {
+		run([]string{attr}, app.RunOptions{}, fetch)
+	}
+	// The default set costs no termination protection lookups
+	run([]string{"ami", "disable_api_termination"}, app.RunOptions{DefaultAttributes: true}, false)
+}
+
+func TestCheckDefaultAttributesSkipUserData(t *testing.T) {
+	logger.Init(true)
+
+	tmpFile := createTempFile(t, []byte(`[{"ami": "ami-123", "user_data": "#!/bin/bash", "tags": {"Name": "web"}}]`))
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+
+	for _, opts := range []app.RunOptions{{DefaultAttributes: true}, {}} {
+		fetch := !opts.DefaultAttributes
+		mockProvider := new(MockCloudProvider)
+		mockProvider.On("FetchInstances", mock.Anything, mock.MatchedBy(func(cfg config.ProviderConfig) bool {
+			awsCfg, ok := cfg.(*awsConfig.Config)
+			return ok && awsCfg.FetchUserData == fetch
+		})).Return(live, nil).Once()
+
+		a := app.NewApp(env.Configurations{StatePath: tmpFile, CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{}})
+		a.SetCloudProvider(config.AWS, mockProvider)
+
+		// The default set costs no user data lookups, a selection does
+		reports, err := a.Check(context.Background(), []string{"ami", "user_data"}, parser.JSON, opts)
+		require.NoError(t, err)
+		mockProvider.AssertExpectations(t)
+		if opts.DefaultAttributes {
+			assert.Empty(t, reports)
+		} else {
+			require.Len(t, reports, 1)
+			assert.Equal(t, "user_data", reports[0].Drifts[0].Attribute)
+		}
+	}
+}
+
+func TestRunExitSummaryWarnings(t *testing.T) {
+	logger.Init(true)
+
+	tmpFile := createTempFile(t, []byte(`[{"ami": "ami-123", "tags": {"Name": "web"}}]`))
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			cloud.AddWarning(args.Get(0).(context.Context), "i-123", "root block device unknown")
+		}).
+		Return(live, nil)
+
+	a := app.NewApp(env.Configurations{
+		StatePath:         tmpFile,
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{},
+	})
+	a.SetCloudProvider(config.AWS, mockProvider)
+	var stderr strings.Builder
+	a.SetErr(&stderr)
+
+	runErr := a.Run(context.Background(), []string{"ami"}, parser.JSON, ports.HTTP,
+		app.RunOptions{Quiet: true, ExitSummary: true})
+	require.NoError(t, runErr, "partial data does not fail the run")
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	var summary output.ExitSummary
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &summary), "summary must be the last line")
+	assert.Equal(t, []cloud.Warning{{InstanceID: "i-123", Message: "root block device unknown"}}, summary.Warnings)
+}
+
+func TestRunCheckCredentialExpiry(t *testing.T) {
+	logger.Init(true)
+
+	mockProvider := new(MockCloudProvider)
+	a := app.NewApp(env.Configurations{
+		StatePath:         createTempFile(t, []byte(`[{"ami": "ami-123", "tags": {"Name": "web"}}]`)),
+		CloudProviderType: config.AWS,
+		CloudConfig: &awsConfig.Config{
+			SessionToken: "token",
+			Expires:      time.Now().Add(5 * time.Minute),
+		},
+	})
+	a.SetCloudProvider(config.AWS, mockProvider)
+
+	err := a.Run(context.Background(), []string{"ami"}, parser.JSON, ports.HTTP,
+		app.RunOptions{CheckCredExpiry: true, CredExpiryBuffer: 15 * time.Minute, Quiet: true})
+	assert.ErrorAs(t, err, &customErr.ErrCredentialsExpiringSoon{})
+	mockProvider.AssertNotCalled(t, "FetchInstances", mock.Anything, mock.Anything)
+
+	// Without the check the same credentials are used as they are
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).
+		Return([]cloud.Instance{{InstanceID: "i-123", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}, nil)
+	err = a.Run(context.Background(), []string{"ami"}, parser.JSON, ports.HTTP, app.RunOptions{Quiet: true})
+	assert.NoError(t, err)
+}
+
+func TestHandleDriftStrictMatch(t *testing.T) {
+	logger.Init(true)
+
+	// The worker config has no Name tag, so no live instance can match it
+	config := []cloud.Instance{
+		{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "worker", AMI: "ami-123"},
+	}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+
+	a := app.NewApp(env.Configurations{})
+
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP, app.RunOptions{Quiet: true})
+	assert.NoError(t, err, "unmatched configs are ignored by default")
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	err = a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.HTTP,
+		app.RunOptions{StrictMatch: true, OutputFile: path, Quiet: true})
+	assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+	data, readErr := os.ReadFile(path)
+	require.NoError(t, readErr)
+	var document output.Document
+	require.NoError(t, json.Unmarshal(data, &document))
+	reports := document.Reports
+	require.Len(t, reports, 1)
+	assert.Equal(t, "worker", reports[0].InstanceID)
+	assert.Equal(t, "instance_missing", reports[0].Drifts[0].Attribute)
+}
+
+func TestHandleDriftReportTitle(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-456", Tags: map[string]string{"Name": "web"}}}
+
+	a := app.NewApp(env.Configurations{ReportTitle: "prod us-east-1"})
+	assert.Equal(t, "prod us-east-1", a.ReportTitle())
+
+	readTitle := func(t *testing.T, opts app.RunOptions) string {
+		opts.OutputFile = filepath.Join(t.TempDir(), "report.json")
+		opts.Quiet = true
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI, opts)
+		require.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		data, readErr := os.ReadFile(opts.OutputFile)
+		require.NoError(t, readErr)
+		var document output.Document
+		require.NoError(t, json.Unmarshal(data, &document))
+		return document.Title
+	}
+
+	assert.Equal(t, "prod us-east-1", readTitle(t, app.RunOptions{}), "REPORT_TITLE applies by default")
+	assert.Equal(t, "staging", readTitle(t, app.RunOptions{ReportTitle: "staging"}), "--report-title overrides REPORT_TITLE")
+}
+
+func TestHandleDriftCleanRunWritesReport(t *testing.T) {
+	logger.Init(true)
+
+	instances := []cloud.Instance{{InstanceID: "i-1", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	path := filepath.Join(t.TempDir(), "report.json")
+	// A report of an earlier run with drift must not survive a clean run
+	require.NoError(t, os.WriteFile(path, []byte(`{"schema_version":1,"reports":[{"instance_id":"i-1"}]}`), 0o644))
+
+	a := app.NewApp(env.Configurations{})
+	var stdout strings.Builder
+	a.SetOut(&stdout)
+	require.NoError(t, a.HandleDrift(context.Background(), instances, instances, []string{"ami"}, ports.CLI,
+		app.RunOptions{Output: output.JSON, OutputFile: path}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var document output.Document
+	require.NoError(t, json.Unmarshal(data, &document))
+	assert.NotNil(t, document.Reports)
+	assert.Empty(t, document.Reports)
+
+	require.NoError(t, json.Unmarshal([]byte(stdout.String()), &document), "the empty report is printed too")
+	assert.Empty(t, document.Reports)
+}
+
+func TestHandleDriftCleanRunWritesSARIF(t *testing.T) {
+	logger.Init(true)
+
+	instances := []cloud.Instance{{InstanceID: "i-1", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	path := filepath.Join(t.TempDir(), "drift.sarif")
+
+	a := app.NewApp(env.Configurations{})
+	var stdout strings.Builder
+	a.SetOut(&stdout)
+	require.NoError(t, a.HandleDrift(context.Background(), instances, instances, []string{"ami"}, ports.CLI,
+		app.RunOptions{Output: output.SARIF, OutputFile: path}))
+
+	// An empty log lets code scanning platforms close earlier alerts
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, raw := range []string{string(data), stdout.String()} {
+		var log output.SARIFLog
+		require.NoError(t, json.Unmarshal([]byte(raw), &log))
+		assert.Equal(t, output.SARIFVersion, log.Version)
+		require.Len(t, log.Runs, 1)
+		assert.NotNil(t, log.Runs[0].Results)
+		assert.Empty(t, log.Runs[0].Results)
+	}
+	assert.Contains(t, string(data), `"results": []`)
+}
+
+func TestHandleDriftCleanRunPrintsSummary(t *testing.T) {
+	logger.Init(true)
+
+	instances := []cloud.Instance{{InstanceID: "i-1", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+
+	a := app.NewApp(env.Configurations{})
+	var stdout strings.Builder
+	a.SetOut(&stdout)
+	require.NoError(t, a.HandleDrift(context.Background(), instances, instances, []string{"ami"}, ports.CLI,
+		app.RunOptions{Output: output.Summary}))
+
+	assert.Equal(t, "Instances with drift: 0\nAdded: 0\nRemoved: 0\nChanged: 0\nAttribute drifts: 0\n", stdout.String())
+}
+
+func TestHandleDriftIncludeNoDrift(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{
+		{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "api", AMI: "ami-123", Tags: map[string]string{"Name": "api"}},
+	}
+	clean := []cloud.Instance{
+		{InstanceID: "i-1", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "i-2", AMI: "ami-123", Tags: map[string]string{"Name": "api"}},
+	}
+	drifted := []cloud.Instance{
+		{InstanceID: "i-1", AMI: "ami-456", Tags: map[string]string{"Name": "web"}},
+		clean[1],
+	}
+
+	a := app.NewApp(env.Configurations{})
+	readReports := func(t *testing.T, live []cloud.Instance, wantDrift bool) []driftchecker.DriftReport {
+		path := filepath.Join(t.TempDir(), "report.json")
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI,
+			app.RunOptions{IncludeNoDrift: true, OutputFile: path, Quiet: true})
+		if wantDrift {
+			require.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+		} else {
+			require.NoError(t, err, "clean instances are not drift")
+		}
+
+		data, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		var document output.Document
+		require.NoError(t, json.Unmarshal(data, &document))
+		sort.Slice(document.Reports, func(i, j int) bool { return document.Reports[i].Name < document.Reports[j].Name })
+		return document.Reports
+	}
+
+	reports := readReports(t, drifted, true)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "api", reports[0].Name)
+	assert.True(t, reports[0].Clean())
+	assert.Equal(t, "web", reports[1].Name)
+	assert.False(t, reports[1].Clean())
+
+	reports = readReports(t, clean, false)
+	require.Len(t, reports, 2)
+	assert.True(t, reports[0].Clean())
+	assert.True(t, reports[1].Clean())
+}
+
+func TestHandleDriftIncludeNoDriftWithOnly(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{
+		{InstanceID: "web", AMI: "ami-123", InstanceType: "t3.micro", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "api", AMI: "ami-123", InstanceType: "t3.micro", Tags: map[string]string{"Name": "api"}},
+		{InstanceID: "db", AMI: "ami-123", InstanceType: "t3.micro", Tags: map[string]string{"Name": "db"}},
+	}
+	live := []cloud.Instance{
+		{InstanceID: "i-1", AMI: "ami-456", InstanceType: "t3.micro", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "i-2", AMI: "ami-123", InstanceType: "t3.micro", Tags: map[string]string{"Name": "api"}},
+		{InstanceID: "i-3", AMI: "ami-123", InstanceType: "t3.large", Tags: map[string]string{"Name": "db"}},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	a := app.NewApp(env.Configurations{})
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami", "instance_type"}, ports.CLI,
+		app.RunOptions{IncludeNoDrift: true, Only: []string{"ami"}, OutputFile: path, Quiet: true})
+	require.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var document output.Document
+	require.NoError(t, json.Unmarshal(data, &document))
+	sort.Slice(document.Reports, func(i, j int) bool { return document.Reports[i].Name < document.Reports[j].Name })
+
+	// The clean api survives the filter, the db drift it filters out is dropped
+	require.Len(t, document.Reports, 2)
+	assert.Equal(t, "api", document.Reports[0].Name)
+	assert.True(t, document.Reports[0].Clean())
+	assert.Equal(t, "web", document.Reports[1].Name)
+	assert.Equal(t, "ami", document.Reports[1].Drifts[0].Attribute)
+}
+
+func TestHandleDriftExecHook(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	live := []cloud.Instance{{InstanceID: "i-1", AMI: "ami-456", Tags: map[string]string{"Name": "web"}}}
+	a := app.NewApp(env.Configurations{ReportTitle: "prod"})
+
+	t.Run("passes the report and verdict to the command", func(t *testing.T) {
+		dir := t.TempDir()
+		command := fmt.Sprintf(`cat > %[1]s/report.json && echo "$EC2DRIFT_DRIFT_DETECTED" > %[1]s/drift`, dir)
+
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI,
+			app.RunOptions{Quiet: true, Exec: command})
+		require.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		drift, readErr := os.ReadFile(filepath.Join(dir, "drift"))
+		require.NoError(t, readErr)
+		assert.Equal(t, "true\n", string(drift))
+
+		data, readErr := os.ReadFile(filepath.Join(dir, "report.json"))
+		require.NoError(t, readErr)
+		var document output.Document
+		require.NoError(t, json.Unmarshal(data, &document))
+		assert.Equal(t, "prod", document.Title)
+		require.Len(t, document.Reports, 1)
+		assert.Equal(t, "i-1", document.Reports[0].InstanceID)
+	})
+
+	t.Run("a failing command does not change the result", func(t *testing.T) {
+		err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI,
+			app.RunOptions{Quiet: true, Exec: "exit 1"})
+		require.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+		err = a.HandleDrift(context.Background(), config, config, []string{"ami"}, ports.CLI,
+			app.RunOptions{Quiet: true, Exec: "exit 1"})
+		require.NoError(t, err)
+	})
+}
+
+func TestCheckMergesConfigFiles(t *testing.T) {
+	logger.Init(true)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web.json"), []byte(`[{"ami": "ami-123", "tags": {"Name": "web"}}]`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db.json"), []byte(`[{"ami": "ami-123", "tags": {"Name": "db"}}]`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`not a config`), 0o644))
+
+	live := []cloud.Instance{
+		{InstanceID: "i-1", AMI: "ami-123", Tags: map[string]string{"Name": "web"}},
+		{InstanceID: "i-2", AMI: "ami-456", Tags: map[string]string{"Name": "db"}},
+	}
+	mockProvider := new(MockCloudProvider)
+	mockProvider.On("FetchInstances", mock.Anything, mock.Anything).Return(live, nil)
+
+	a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{}})
+	a.SetCloudProvider(config.AWS, mockProvider)
+
+	t.Run("files of a directory are merged", func(t *testing.T) {
+		reports, err := a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{ConfigFiles: []string{dir}})
+		require.NoError(t, err)
+		require.Len(t, reports, 1, "both files are compared, only db drifts")
+		assert.Equal(t, "db", reports[0].Name)
+	})
+
+	t.Run("duplicate names are rejected", func(t *testing.T) {
+		other := createTempFile(t, []byte(`[{"ami": "ami-999", "tags": {"Name": "web"}}]`))
+
+		_, err := a.Check(context.Background(), []string{"ami"}, parser.JSON,
+			app.RunOptions{ConfigFiles: []string{filepath.Join(dir, "web.json"), other}})
+		var dup customErr.ErrDuplicateInstanceName
+		require.ErrorAs(t, err, &dup)
+		assert.Equal(t, "web", dup.Name)
+		assert.Equal(t, filepath.Join(dir, "web.json"), dup.First)
+		assert.Equal(t, other, dup.Second)
+	})
+
+	t.Run("directory without config files", func(t *testing.T) {
+		_, err := a.Check(context.Background(), []string{"ami"}, parser.Terraform, app.RunOptions{ConfigFiles: []string{dir}})
+		assert.ErrorAs(t, err, &customErr.ErrNoConfigFiles{})
+	})
+}
+
+func TestHandleDriftWritesToOutput(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-456", Tags: map[string]string{"Name": "web"}}}
+
+	a := app.NewApp(env.Configurations{})
+	var stdout, stderr strings.Builder
+	a.SetOut(&stdout)
+	a.SetErr(&stderr)
+
+	// Drift is returned in CLI mode too instead of exiting the process
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI,
+		app.RunOptions{Output: output.JSON, Explain: true, ExitSummary: true})
+	assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+
+	assert.Contains(t, stdout.String(), `"match_key"`, "explanations go to the output writer")
+	assert.Contains(t, stdout.String(), `"ami-456"`, "the report goes to the output writer")
+	assert.Contains(t, stderr.String(), `"drift_detected":true`)
+}
+
+func TestHandleDriftSummaryOutput(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-456", Tags: map[string]string{"Name": "web"}}}
+
+	a := app.NewApp(env.Configurations{})
+	var stdout strings.Builder
+	a.SetOut(&stdout)
+
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI,
+		app.RunOptions{Output: output.Summary})
+	assert.ErrorAs(t, err, &customErr.ErrDriftDetected{}, "drift is still reported with only the counts printed")
+
+	assert.Equal(t, "Instances with drift: 1\nAdded: 0\nRemoved: 0\nChanged: 1\nAttribute drifts: 1\n", stdout.String())
+}
+
+// TestHandleDriftNoMatchingInstances tests that an instance selection matching nothing keeps the header, says so and returns ErrNoMatchingInstances
+func TestHandleDriftNoMatchingInstances(t *testing.T) {
+	logger.Init(true)
+
+	config := []cloud.Instance{{InstanceID: "web", AMI: "ami-123", Tags: map[string]string{"Name": "web"}}}
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-456", Tags: map[string]string{"Name": "web"}}}
+
+	a := app.NewApp(env.Configurations{})
+	var stdout strings.Builder
+	a.SetOut(&stdout)
+
+	err := a.HandleDrift(context.Background(), live, config, []string{"ami"}, ports.CLI,
+		app.RunOptions{Instances: []string{"i-123"}, ExcludeInstances: []string{"i-123"}})
+	require.ErrorAs(t, err, &customErr.ErrNoMatchingInstances{})
+
+	assert.Contains(t, stdout.String(), "INSTANCE ID")
+	assert.Contains(t, stdout.String(), "no matching instances")
+	assert.NotContains(t, stdout.String(), "ami-456")
+}
+
+// TestRunInvalidConfig tests that a desired config the parser rejects surfaces as ErrConfigParse
+func TestRunInvalidConfig(t *testing.T) {
+	logger.Init(true)
+
+	tests := []struct {
+		name    string
+		format  parser.ParserType
+		content string
+	}{
+		{"Terraform", parser.Terraform, `resource "aws_instance" "web" { ami = `},
+		{"JSON", parser.JSON, `[{"instance_id": "i-1",`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := new(MockCloudProvider)
+			provider.On("FetchInstances", mock.Anything, mock.Anything).Return([]cloud.Instance{}, nil).Maybe()
+
+			a := app.NewApp(env.Configurations{
+				StatePath:         createTempFile(t, []byte(tt.content)),
+				CloudProviderType: config.AWS,
+				CloudConfig:       &awsConfig.Config{Region: "us-east-1"},
+			})
+			a.SetCloudProvider(config.AWS, provider)
+
+			err := a.Run(context.Background(), []string{"ami"}, tt.format, ports.HTTP, app.RunOptions{})
+
+			var parseErr customErr.ErrConfigParse
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, string(tt.format), parseErr.Parser)
+			assert.Error(t, parseErr.Err)
+		})
+	}
+}
+
+// TestRunBaseline tests that an exported snapshot can stand in for the desired config
+func TestRunBaseline(t *testing.T) {
+	logger.Init(true)
+
+	live := []cloud.Instance{{
+		InstanceID:     "i-123",
+		AMI:            "ami-123",
+		InstanceType:   "t3.micro",
+		SecurityGroups: []string{"sg-1"},
+		Tags:           map[string]string{"Name": "web", "Cost": "$team"},
+	}}
+	attrs := []string{"ami", "instance_type", "security_groups", "tags"}
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	newApp := func(instances []cloud.Instance) *app.App {
+		provider := new(MockCloudProvider)
+		provider.On("FetchInstances", mock.Anything, mock.Anything).Return(instances, nil)
+		a := app.NewApp(env.Configurations{
+			StatePath:         "missing.tf",
+			CloudProviderType: config.AWS,
+			CloudConfig:       &awsConfig.Config{Region: "us-east-1"},
+		})
+		a.SetCloudProvider(config.AWS, provider)
+		a.SetOut(io.Discard)
+		return a
+	}
+
+	require.NoError(t, newApp(live).Export(context.Background(), baseline, attrs, app.RunOptions{}))
+
+	t.Run("Unchanged", func(t *testing.T) {
+		err := newApp(live).Run(context.Background(), attrs, parser.Terraform, ports.CLI, app.RunOptions{Baseline: baseline})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		changed := []cloud.Instance{live[0]}
+		changed[0].AMI = "ami-456"
+
+		a := newApp(changed)
+		var stdout strings.Builder
+		a.SetOut(&stdout)
+
+		err := a.Run(context.Background(), attrs, parser.Terraform, ports.CLI,
+			app.RunOptions{Baseline: baseline, Output: output.JSON})
+		assert.ErrorAs(t, err, &customErr.ErrDriftDetected{})
+		assert.Contains(t, stdout.String(), `"expected":"ami-123","actual":"ami-456"`)
+	})
+}
+
+// TestRunJSONReportMeta tests that JSON reports record the inputs of the check
+func TestRunJSONReportMeta(t *testing.T) {
+	logger.Init(true)
+
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-1", InstanceType: "t2.micro", Tags: map[string]string{"Name": "web"}}}
+	provider := new(MockCloudProvider)
+	provider.On("FetchInstances", mock.Anything, mock.Anything).Return(live, nil)
+
+	statePath := createTempFile(t, []byte(`resource "aws_instance" "web" {
+  ami           = "ami-1"
+  instance_type = "t2.micro"
+  tags          = { Name = "web" }
+}`))
+	a := app.NewApp(env.Configurations{
+		StatePath:         statePath,
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{Region: "us-east-1"},
+	})
+	a.SetCloudProvider(config.AWS, provider)
+	var stdout strings.Builder
+	a.SetOut(&stdout)
+
+	opts := app.RunOptions{Output: output.JSON, IncludeNoDrift: true, Instances: []string{"i-123"}, Region: "eu-west-1", FailOnSeverity: "critical"}
+	require.NoError(t, a.Run(context.Background(), []string{"ami", "instance_type"}, parser.Terraform, ports.CLI, opts))
+
+	var document output.Document
+	require.NoError(t, json.Unmarshal([]byte(stdout.String()), &document))
+	require.NotNil(t, document.Meta)
+	assert.Equal(t, output.Meta{
+		Attributes:  []string{"ami", "instance_type"},
+		InputFormat: "terraform",
+		Providers:   []string{"aws"},
+		Region:      "eu-west-1",
+		StatePath:   statePath,
+		Filters:     output.MetaFilters{Instances: []string{"i-123"}, FailOnSeverity: "critical"},
+	}, *document.Meta)
+
+	// Tokens in git state paths are masked
+	a.Reload(env.Configurations{
+		StatePath:         "git::https://ghp_secret@github.com/org/infra.git//main.tf",
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{Region: "us-east-1"},
+	})
+	meta := a.RunMeta([]string{"ami"}, parser.Terraform, app.RunOptions{})
+	assert.Equal(t, "git::https://****@github.com/org/infra.git//main.tf", meta.StatePath)
+	assert.Equal(t, "us-east-1", meta.Region)
+}
+
+// TestCheckInlineConfig tests that a desired config sent with the check is
+// parsed in place of STATE_PATH, without expanding environment variables
+func TestCheckInlineConfig(t *testing.T) {
+	logger.Init(true)
+	t.Setenv("INLINE_AMI", "ami-1")
+
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-1", InstanceType: "t2.micro", Tags: map[string]string{"Name": "web"}}}
+	provider := new(MockCloudProvider)
+	provider.On("FetchInstances", mock.Anything, mock.Anything).Return(live, nil)
+
+	a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+	a.SetCloudProvider(config.AWS, provider)
+
+	inline := []byte(`[{"instance_id": "web", "ami": "${INLINE_AMI}", "instance_type": "t2.micro", "tags": {"Name": "web"}}]`)
+	reports, err := a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{Config: inline})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Drifts, 1)
+	assert.Equal(t, "ami", reports[0].Drifts[0].Attribute)
+	assert.Equal(t, "${INLINE_AMI}", reports[0].Drifts[0].ExpectedValue)
+	assert.Equal(t, "ami-1", reports[0].Drifts[0].ActualValue)
+	assert.True(t, a.RunMeta([]string{"ami"}, parser.JSON, app.RunOptions{Config: inline}).Inline)
+
+	_, err = a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{})
+	assert.ErrorAs(t, err, &customErr.ErrMissingPaths{}, "without STATE_PATH a config must be sent")
+
+	_, err = a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{Config: inline, Baseline: "baseline.json"})
+	assert.ErrorAs(t, err, &customErr.ErrConflictingConfigSources{})
+
+	_, err = a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{Config: []byte(" \n")})
+	assert.ErrorAs(t, err, &customErr.ErrEmptyStateFile{})
+
+	a.Reload(env.Configurations{StatePath: "state.json", CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+	_, err = a.Check(context.Background(), []string{"ami"}, parser.JSON, app.RunOptions{Config: inline})
+	assert.ErrorAs(t, err, &customErr.ErrConflictingConfigSources{}, "an inline config does not replace STATE_PATH")
+}
+
+// TestReload tests that checks started after a reload use the new settings
+func TestReload(t *testing.T) {
+	logger.Init(true)
+
+	live := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-2", Tags: map[string]string{"Name": "web"}}}
+	provider := new(MockCloudProvider)
+	provider.On("FetchInstances", mock.Anything, mock.Anything).Return(live, nil)
+
+	desired := func(ami string) string {
+		return createTempFile(t, []byte(fmt.Sprintf(`resource "aws_instance" "web" {
+  ami           = %q
+  instance_type = "t2.micro"
+  tags          = { Name = "web" }
+}`, ami)))
+	}
+
+	settings := env.Configurations{
+		StatePath:         desired("ami-1"),
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{Region: "us-east-1"},
+	}
+	a := app.NewApp(settings)
+	a.SetCloudProvider(config.AWS, provider)
+	a.SetOut(io.Discard)
+
+	reports, err := a.Check(context.Background(), []string{"ami"}, parser.Terraform, app.RunOptions{})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+
+	settings.StatePath = desired("ami-2")
+	settings.CloudConfig = &awsConfig.Config{Region: "eu-west-1"}
+	a.Reload(settings)
+
+	assert.Equal(t, "eu-west-1", a.Configurations().CloudConfig.GetRegion())
+	reports, err = a.Check(context.Background(), []string{"ami"}, parser.Terraform, app.RunOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+	provider.AssertCalled(t, "FetchInstances", mock.Anything, mock.MatchedBy(func(cfg config.ProviderConfig) bool {
+		return cfg.GetRegion() == "eu-west-1"
+	}))
 }
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"io/fs"
+	"os"
+)
+
+// osFS reads files straight from the operating system. Unlike os.DirFS it
+// accepts the relative and absolute paths STATE_PATH and --config-file
+// hold, which are not valid fs.FS names.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
@@ -0,0 +1,191 @@
+package driftchecker
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Strategy names how the values of an attribute are compared
+type Strategy string
+
+const (
+	StrategyExact           Strategy = "exact"            // Values must be identical
+	StrategySetEqual        Strategy = "set-equal"        // Lists, or comma separated values, match in any order
+	StrategyPrefixIgnore    Strategy = "prefix-ignore"    // A prefix is trimmed from values, or tag keys with it are skipped
+	StrategyCaseInsensitive Strategy = "case-insensitive" // Values match ignoring case
+)
+
+// Strategies returns the supported comparison strategies
+func Strategies() []string {
+	return []string{string(StrategyExact), string(StrategySetEqual), string(StrategyPrefixIgnore), string(StrategyCaseInsensitive)}
+}
+
+// DefaultIgnoredTagPrefix marks the tags AWS manages itself, such as
+// aws:cloudformation:stack-name, which are not compared by default
+const DefaultIgnoredTagPrefix = "aws:"
+
+// Comparator is the comparison strategy of one attribute
+type Comparator struct {
+	Strategy Strategy
+	Prefix   string // Prefix for prefix-ignore
+}
+
+// ComparatorOptions maps attributes to their comparator. The most specific
+// key wins, so tags.Env overrides tags. Attributes without an entry use the
+// fallback comparator if one is set, and otherwise keep their built-in
+// comparison: exact for values and set-equal for lists. Numbers and flags
+// are always compared exactly.
+type ComparatorOptions map[string]Comparator
+
+// fallbackKey holds the comparator of attributes without an entry. Parsed
+// entries always name an attribute, so it never clashes with one.
+const fallbackKey = ""
+
+// withFallback returns a copy of the options that compares attributes
+// without an entry with the comparator
+func (o ComparatorOptions) withFallback(comparator Comparator) ComparatorOptions {
+	options := make(ComparatorOptions, len(o)+1)
+	for attr, c := range o {
+		options[attr] = c
+	}
+	options[fallbackKey] = comparator
+	return options
+}
+
+// ParseComparators parses comma separated attribute=strategy entries such
+// as "ami=case-insensitive,tags=prefix-ignore:aws:". The prefix of
+// prefix-ignore follows the first colon.
+func ParseComparators(raw string) (ComparatorOptions, error) {
+	comparators := make(ComparatorOptions)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		attr, spec, ok := strings.Cut(entry, "=")
+		attr = strings.TrimSpace(attr)
+		if !ok || attr == "" {
+			return nil, errors.NewErrInvalidComparator(entry, Strategies())
+		}
+
+		name, prefix, hasPrefix := strings.Cut(strings.TrimSpace(spec), ":")
+		strategy := Strategy(strings.ToLower(name))
+		if !slices.Contains(Strategies(), string(strategy)) {
+			return nil, errors.NewErrInvalidComparator(entry, Strategies())
+		}
+		// Only prefix-ignore takes an argument, and it needs one
+		if (strategy == StrategyPrefixIgnore) != (hasPrefix && prefix != "") {
+			return nil, errors.NewErrInvalidComparator(entry, Strategies())
+		}
+		comparators[attr] = Comparator{Strategy: strategy, Prefix: prefix}
+	}
+	return comparators, nil
+}
+
+// lookup returns the comparator of the attribute, its closest parent or
+// the fallback
+func (o ComparatorOptions) lookup(attribute string) (Comparator, bool) {
+	for key := attribute; key != ""; {
+		if comparator, ok := o[key]; ok {
+			return comparator, true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	comparator, ok := o[fallbackKey]
+	return comparator, ok
+}
+
+// equalValues compares two values of a scalar attribute
+func (o ComparatorOptions) equalValues(attribute, expected, actual string) bool {
+	comparator, ok := o.lookup(attribute)
+	if !ok {
+		return expected == actual
+	}
+	return comparator.equal(expected, actual)
+}
+
+func (c Comparator) equal(expected, actual string) bool {
+	switch c.Strategy {
+	case StrategyCaseInsensitive:
+		return strings.EqualFold(expected, actual)
+	case StrategyPrefixIgnore:
+		return strings.TrimPrefix(expected, c.Prefix) == strings.TrimPrefix(actual, c.Prefix)
+	case StrategySetEqual:
+		return equalStringSlices(splitValues(expected), splitValues(actual))
+	default:
+		return expected == actual
+	}
+}
+
+// equalLists compares two values of a list attribute
+func (o ComparatorOptions) equalLists(attribute string, expected, actual []string) bool {
+	comparator, ok := o.lookup(attribute)
+	if !ok {
+		return equalStringSlices(expected, actual)
+	}
+
+	switch comparator.Strategy {
+	case StrategyExact:
+		return slices.Equal(expected, actual)
+	case StrategyCaseInsensitive:
+		return equalStringSlices(mapValues(expected, strings.ToLower), mapValues(actual, strings.ToLower))
+	case StrategyPrefixIgnore:
+		trim := func(v string) string { return strings.TrimPrefix(v, comparator.Prefix) }
+		return equalStringSlices(mapValues(expected, trim), mapValues(actual, trim))
+	default:
+		return equalStringSlices(expected, actual)
+	}
+}
+
+// skipsTag reports whether the tag key is left uncompared. Without a tags
+// comparator the aws: tags AWS adds to live instances are skipped; any tags
+// entry replaces that default, so tags=exact compares them too, and a
+// tags.<key> entry always compares its key.
+func (o ComparatorOptions) skipsTag(key string) bool {
+	if _, ok := o["tags."+key]; ok {
+		return false
+	}
+	comparator, ok := o["tags"]
+	if !ok {
+		return strings.HasPrefix(key, DefaultIgnoredTagPrefix)
+	}
+	return comparator.Strategy == StrategyPrefixIgnore && strings.HasPrefix(key, comparator.Prefix)
+}
+
+// equalTagValues compares the values of a tag. prefix-ignore on tags only
+// selects keys, so it does not change how the values are compared.
+func (o ComparatorOptions) equalTagValues(key, expected, actual string) bool {
+	if comparator, ok := o["tags."+key]; ok {
+		return comparator.equal(expected, actual)
+	}
+	if comparator, ok := o["tags"]; ok && comparator.Strategy != StrategyPrefixIgnore {
+		return comparator.equal(expected, actual)
+	}
+	if comparator, ok := o[fallbackKey]; ok {
+		return comparator.equal(expected, actual)
+	}
+	return expected == actual
+}
+
+// splitValues splits a comma separated value, trimming each entry
+func splitValues(value string) []string {
+	if value == "" {
+		return nil
+	}
+	values := strings.Split(value, ",")
+	return mapValues(values, strings.TrimSpace)
+}
+
+func mapValues(values []string, fn func(string) string) []string {
+	mapped := make([]string, len(values))
+	for i, v := range values {
+		mapped[i] = fn(v)
+	}
+	return mapped
+}
@@ -3,6 +3,7 @@ package driftchecker
 import (
 	"context"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -11,20 +12,84 @@ import (
 )
 
 // DriftReport contains details about an EC2 instance drift, including
-// the instance ID, its name, and a list of drift details that specify
-// the attribute that changed and the expected vs actual values.
+// the instance ID, its name, the cloud provider it was fetched from and a
+// list of drift details that specify the attribute that changed and the
+// expected vs actual values. Managed tells whether the instance is declared
+// in the desired config, false for live instances missing from it.
 type DriftReport struct {
-	InstanceID string
-	Name       string
-	Drifts     []DriftDetail
+	InstanceID string        `json:"instance_id"`
+	Name       string        `json:"name"`
+	Provider   string        `json:"provider,omitempty"`
+	Managed    bool          `json:"managed"`
+	Status     string        `json:"status,omitempty"` // StatusOK for matched instances without drift
+	Drifts     []DriftDetail `json:"drifts"`
+}
+
+// StatusOK marks the report of a matched instance found without drift,
+// emitted only with WithNoDriftReports
+const StatusOK = "ok"
+
+// Clean reports whether the report records a checked instance without drift
+func (r DriftReport) Clean() bool {
+	return r.Status == StatusOK
 }
 
 // DriftDetail represents an individual change or drift in a specific attribute
 // of an EC2 instance, comparing the expected value and the actual value.
 type DriftDetail struct {
-	Attribute     string
-	ExpectedValue interface{}
-	ActualValue   interface{}
+	Attribute     string      `json:"attribute"`
+	ExpectedValue interface{} `json:"expected"`
+	ActualValue   interface{} `json:"actual"`
+	Added         []string    `json:"added,omitempty"`    // List entries only in the actual value, e.g. attached security groups
+	Removed       []string    `json:"removed,omitempty"`  // List entries only in the expected value
+	Severity      Severity    `json:"severity,omitempty"` // Set when SEVERITY maps the attribute
+}
+
+// DetectOption customises how Detect compares instances
+type DetectOption func(*detectOptions)
+
+type detectOptions struct {
+	comparators ComparatorOptions
+	include     []string // Live instance IDs to consider, empty considers all
+	exclude     []string // Live instance IDs to skip, applied after include
+	vpcID       string   // VPC the instances must be in, empty for any
+	subnetID    string   // Subnet the instances must be in, empty for any
+	noDrift     bool     // Report matched instances without drift as StatusOK
+	ignoreCase  bool     // Compare string values without a comparator ignoring case
+	state       string   // State desired instances without one are expected in, empty for running
+}
+
+// WithComparators compares attributes with the given strategies instead of
+// their built-in comparison
+func WithComparators(comparators ComparatorOptions) DetectOption {
+	return func(o *detectOptions) {
+		o.comparators = comparators
+	}
+}
+
+// WithNoDriftReports also reports matched instances without drift, with
+// StatusOK and no drift details, so audits can show what was checked
+func WithNoDriftReports(include bool) DetectOption {
+	return func(o *detectOptions) {
+		o.noDrift = include
+	}
+}
+
+// WithIgnoreCaseValues compares string values, list entries and tag values
+// ignoring case, so GP2 matches gp2. Attributes with a comparator keep it,
+// and tag keys are still matched exactly.
+func WithIgnoreCaseValues(ignore bool) DetectOption {
+	return func(o *detectOptions) {
+		o.ignoreCase = ignore
+	}
+}
+
+// WithExpectedState sets the state, e.g. stopped, that desired instances
+// without an instance_state are expected in. An empty state keeps running.
+func WithExpectedState(state string) DetectOption {
+	return func(o *detectOptions) {
+		o.state = state
+	}
 }
 
 // Detect identifies drifts between two EC2 instance states (old and current).
@@ -35,7 +100,59 @@ func Detect(
 	oldState []cloud.Instance, // Previous state of the EC2 instances
 	currentState []cloud.Instance, // Current state of the EC2 instances
 	attributes []string, // List of attributes to check for drift
+	opts ...DetectOption,
 ) []DriftReport {
+	// Aggregate results from the report channel into a single list, draining
+	// it while the comparisons run so the list grows with the reports only
+	driftReports := []DriftReport{}
+	for rep := range DetectStream(ctx, oldState, currentState, attributes, opts...) {
+		driftReports = append(driftReports, rep)
+	}
+
+	return driftReports
+}
+
+// reportBuffer is how many reports DetectStream holds for a slow receiver
+const reportBuffer = 64
+
+// DetectStream works like Detect but sends each DriftReport on the returned
+// channel as soon as it is ready. The channel is closed once every instance
+// has been compared or the context is cancelled.
+func DetectStream(
+	ctx context.Context,
+	oldState []cloud.Instance,
+	currentState []cloud.Instance,
+	attributes []string,
+	opts ...DetectOption,
+) <-chan DriftReport {
+	return DetectStreamWith(ctx, oldState, currentState, func(cloud.Instance) []string {
+		return attributes
+	}, opts...)
+}
+
+// DetectStreamWith works like DetectStream but asks selectAttributes which
+// attributes to compare for each instance of the old state.
+func DetectStreamWith(
+	ctx context.Context,
+	oldState []cloud.Instance,
+	currentState []cloud.Instance,
+	selectAttributes AttributeSelector,
+	opts ...DetectOption,
+) <-chan DriftReport {
+	var options detectOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	cmp := options.comparators
+	if options.ignoreCase {
+		cmp = cmp.withFallback(Comparator{Strategy: StrategyCaseInsensitive})
+	}
+	expectedState := options.state
+	if expectedState == "" {
+		expectedState = DefaultInstanceState
+	}
+	oldState, currentState = restrictInstances(oldState, currentState, options)
+
 	// Create maps of EC2 instances by name for fast lookup
 	oldMap := make(map[string]cloud.Instance, len(oldState))
 	for _, inst := range oldState {
@@ -52,8 +169,9 @@ func Detect(
 
 	// WaitGroup to manage concurrent tasks
 	var wg sync.WaitGroup
-	// Channel to send drift reports
-	reportChan := make(chan DriftReport, len(oldState)+len(currentState))
+	// Channel to send drift reports, bounded so its size does not grow with
+	// the fleet. Comparisons block until the receiver catches up.
+	reportChan := make(chan DriftReport, reportBuffer)
 
 	// Helper function to send reports to the report channel with context cancellation
 	sendReport := func(r DriftReport) {
@@ -86,6 +204,8 @@ func Detect(
 				sendReport(DriftReport{
 					InstanceID: o.InstanceID,
 					Name:       n,
+					Provider:   o.Provider,
+					Managed:    true,
 					Drifts: []DriftDetail{{
 						Attribute:     "instance_removed",
 						ExpectedValue: o,
@@ -108,65 +228,118 @@ func Detect(
 
 			// Initialize an empty list of drift details for each attribute
 			drifts := []DriftDetail{}
-			for _, attr := range attributes {
+			for _, attr := range selectAttributes(o) {
+				// Zero values of data that could not be fetched are no drift
+				if o.IsUnknown(attr) || c.IsUnknown(attr) {
+					continue
+				}
 				parts := strings.Split(attr, ".")
 				switch parts[0] {
 				// Check specific attributes for drift
 				case "ami":
-					if o.AMI != c.AMI {
-						drifts = append(drifts, DriftDetail{attr, o.AMI, c.AMI})
+					if !cmp.equalValues(attr, o.AMI, c.AMI) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.AMI, ActualValue: c.AMI})
 					}
 				case "instance_type":
-					if o.InstanceType != c.InstanceType {
-						drifts = append(drifts, DriftDetail{attr, o.InstanceType, c.InstanceType})
+					if !cmp.equalValues(attr, o.InstanceType, c.InstanceType) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.InstanceType, ActualValue: c.InstanceType})
+					}
+				case "private_ip":
+					// An empty desired IP was not set, AWS assigns one
+					if o.PrivateIP != "" && !cmp.equalValues(attr, o.PrivateIP, c.PrivateIP) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.PrivateIP, ActualValue: c.PrivateIP})
+					}
+				case "public_ip":
+					if o.PublicIP != "" && !cmp.equalValues(attr, o.PublicIP, c.PublicIP) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.PublicIP, ActualValue: c.PublicIP})
+					}
+				case "metadata_options":
+					// http_tokens is the only metadata option compared so far, an
+					// empty desired value leaves it to the account default
+					if o.MetadataHttpTokens != "" && !cmp.equalValues("metadata_options.http_tokens", o.MetadataHttpTokens, c.MetadataHttpTokens) {
+						drifts = append(drifts, DriftDetail{Attribute: "metadata_options.http_tokens", ExpectedValue: o.MetadataHttpTokens, ActualValue: c.MetadataHttpTokens})
+					}
+				case "disable_api_termination":
+					if d, ok := terminationProtectionDrift(o, c); ok {
+						drifts = append(drifts, d)
+					}
+				case "associate_public_ip_address":
+					if d, ok := publicIPAssociationDrift(o, c); ok {
+						drifts = append(drifts, d)
+					}
+				case "user_data":
+					// Hashes keep large scripts out of the report
+					if o.UserDataHash != "" && o.UserDataHash != c.UserDataHash {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.UserDataHash, ActualValue: c.UserDataHash})
+					}
+				case "instance_lifecycle":
+					if !cmp.equalValues(attr, cloud.NormalizeLifecycle(o.InstanceLifecycle), cloud.NormalizeLifecycle(c.InstanceLifecycle)) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: cloud.NormalizeLifecycle(o.InstanceLifecycle), ActualValue: cloud.NormalizeLifecycle(c.InstanceLifecycle)})
+					}
+				case "monitoring":
+					// A state still switching counts as the state it switches to
+					expected, actual := cloud.NormalizeMonitoringState(o.MonitoringState), cloud.NormalizeMonitoringState(c.MonitoringState)
+					if o.MonitoringState != "" && !cmp.equalValues(attr, expected, actual) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: expected, ActualValue: actual})
+					}
+				case "host_id":
+					// A reassigned dedicated host shows up as a different ID
+					if !cmp.equalValues(attr, o.HostID, c.HostID) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.HostID, ActualValue: c.HostID})
+					}
+				case "capacity_reservation_id":
+					// Both empty when the instance runs outside a reservation
+					if !cmp.equalValues(attr, o.CapacityReservationID, c.CapacityReservationID) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.CapacityReservationID, ActualValue: c.CapacityReservationID})
+					}
+				case "affinity":
+					if !cmp.equalValues(attr, o.Affinity, c.Affinity) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.Affinity, ActualValue: c.Affinity})
+					}
+				case "root_device_type":
+					if o.RootDeviceType != "" && !cmp.equalValues(attr, o.RootDeviceType, c.RootDeviceType) {
+						drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: o.RootDeviceType, ActualValue: c.RootDeviceType})
+					}
+				case "instance_state":
+					if drift, ok := instanceStateDrift(cmp, expectedState, o, c); ok {
+						drifts = append(drifts, drift)
 					}
 				case "security_groups":
-					if !equalStringSlices(o.SecurityGroups, c.SecurityGroups) {
-						drifts = append(drifts, DriftDetail{attr, o.SecurityGroups, c.SecurityGroups})
+					if !cmp.equalLists(attr, o.SecurityGroups, c.SecurityGroups) {
+						drifts = append(drifts, listDrift(attr, o.SecurityGroups, c.SecurityGroups))
 					}
 				case "tags":
 					// Compare tags either for specific keys or all keys
 					if len(parts) > 1 {
 						key := parts[1]
-						if key == "Name" {
+						if key == "Name" || cmp.skipsTag(key) {
 							continue
 						}
 						oVal, oOk := o.Tags[key]
 						cVal, cOk := c.Tags[key]
-						if !oOk || !cOk || oVal != cVal {
-							drifts = append(drifts, DriftDetail{attr, oVal, cVal})
+						if !oOk || !cOk || !cmp.equalTagValues(key, oVal, cVal) {
+							drifts = append(drifts, DriftDetail{Attribute: attr, ExpectedValue: oVal, ActualValue: cVal})
 						}
 					} else {
 						for k, ov := range o.Tags {
-							if k == "Name" {
+							if k == "Name" || cmp.skipsTag(k) {
 								continue
 							}
 							cv, ok := c.Tags[k]
-							if !ok || ov != cv {
-								drifts = append(drifts, DriftDetail{"tags." + k, ov, cv})
+							if !ok || !cmp.equalTagValues(k, ov, cv) {
+								drifts = append(drifts, DriftDetail{Attribute: "tags." + k, ExpectedValue: ov, ActualValue: cv})
 							}
 						}
 					}
 				case "root_block_device":
-					// Check root block device attributes (volume size/type)
+					// A bare root_block_device compares every sub-attribute
+					fields := rootBlockDeviceFields
 					if len(parts) > 1 {
-						sub := parts[1]
-						switch sub {
-						case "volume_size":
-							if o.RootBlockDevice.VolumeSize != c.RootBlockDevice.VolumeSize {
-								drifts = append(drifts, DriftDetail{attr, o.RootBlockDevice.VolumeSize, c.RootBlockDevice.VolumeSize})
-							}
-						case "volume_type":
-							if o.RootBlockDevice.VolumeType != c.RootBlockDevice.VolumeType {
-								drifts = append(drifts, DriftDetail{attr, o.RootBlockDevice.VolumeType, c.RootBlockDevice.VolumeType})
-							}
-						}
-					} else {
-						if o.RootBlockDevice.VolumeSize != c.RootBlockDevice.VolumeSize {
-							drifts = append(drifts, DriftDetail{"root_block_device.volume_size", o.RootBlockDevice.VolumeSize, c.RootBlockDevice.VolumeSize})
-						}
-						if o.RootBlockDevice.VolumeType != c.RootBlockDevice.VolumeType {
-							drifts = append(drifts, DriftDetail{"root_block_device.volume_type", o.RootBlockDevice.VolumeType, c.RootBlockDevice.VolumeType})
+						fields = parts[1:2]
+					}
+					for _, field := range fields {
+						if d, ok := rootBlockDeviceDrift(cmp, field, o, c); ok {
+							drifts = append(drifts, d)
 						}
 					}
 				default:
@@ -174,9 +347,22 @@ func Detect(
 				}
 			}
 
+			// Prefer the current side's identity, which is the live instance when
+			// comparing desired config (old) against the cloud (current)
+			report := DriftReport{
+				InstanceID: firstNonEmpty(c.InstanceID, o.InstanceID),
+				Name:       n,
+				Provider:   firstNonEmpty(c.Provider, o.Provider),
+				Managed:    true,
+				Drifts:     drifts,
+			}
+
 			// If there are any drift details, send a report
 			if len(drifts) > 0 {
-				sendReport(DriftReport{InstanceID: o.InstanceID, Name: n, Drifts: drifts})
+				sendReport(report)
+			} else if options.noDrift {
+				report.Status = StatusOK
+				sendReport(report)
 			}
 		}(oldInst, currInst, name)
 	}
@@ -193,7 +379,7 @@ func Detect(
 				default:
 				}
 
-				sendReport(DriftReport{InstanceID: c.InstanceID, Name: n, Drifts: []DriftDetail{{
+				sendReport(DriftReport{InstanceID: c.InstanceID, Name: n, Provider: c.Provider, Drifts: []DriftDetail{{
 					Attribute:     "instance_added",
 					ExpectedValue: nil,
 					ActualValue:   c,
@@ -202,18 +388,21 @@ func Detect(
 		}
 	}
 
-	// Wait for all goroutines to complete
-	wg.Wait()
 	// Close the channel after all reports are sent
-	close(reportChan)
+	go func() {
+		wg.Wait()
+		close(reportChan)
+	}()
 
-	// Aggregate results from the report channel into a single list
-	driftReports := make([]DriftReport, 0, len(oldState)+len(currentState))
-	for rep := range reportChan {
-		driftReports = append(driftReports, rep)
-	}
+	return reportChan
+}
 
-	return driftReports
+// firstNonEmpty returns a unless it is empty, in which case b is returned.
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
 }
 
 // equalStringSlices compares two string slices irrespective of order.
@@ -228,3 +417,118 @@ func equalStringSlices(a, b []string) bool {
 	sort.Strings(bCopy)
 	return reflect.DeepEqual(aCopy, bCopy)
 }
+
+// listDrift reports drift of a list attribute with the full lists and the
+// entries added to and removed from the expected one
+func listDrift(attr string, expected, actual []string) DriftDetail {
+	return DriftDetail{
+		Attribute:     attr,
+		ExpectedValue: expected,
+		ActualValue:   actual,
+		Added:         missingFrom(actual, expected),
+		Removed:       missingFrom(expected, actual),
+	}
+}
+
+// missingFrom returns the distinct entries of values that other lacks, in
+// the order of values
+func missingFrom(values, other []string) []string {
+	var missing []string
+	for _, v := range values {
+		if !slices.Contains(other, v) && !slices.Contains(missing, v) {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// terminationProtectionDrift compares whether termination protection is
+// enabled. A desired config that does not set it is not compared.
+func terminationProtectionDrift(o, c cloud.Instance) (DriftDetail, bool) {
+	if o.DisableApiTermination == nil {
+		return DriftDetail{}, false
+	}
+	expected := *o.DisableApiTermination
+	actual := c.DisableApiTermination != nil && *c.DisableApiTermination
+	if expected == actual {
+		return DriftDetail{}, false
+	}
+	return DriftDetail{Attribute: "disable_api_termination", ExpectedValue: expected, ActualValue: actual}, true
+}
+
+// publicIPAssociationDrift compares whether a public IP was associated at
+// launch. Like encryption, a desired config that does not set it is not
+// compared and a live instance without a value counts as false.
+func publicIPAssociationDrift(o, c cloud.Instance) (DriftDetail, bool) {
+	if o.AssociatePublicIP == nil {
+		return DriftDetail{}, false
+	}
+	expected := *o.AssociatePublicIP
+	actual := c.AssociatePublicIP != nil && *c.AssociatePublicIP
+	if expected == actual {
+		return DriftDetail{}, false
+	}
+	return DriftDetail{Attribute: "associate_public_ip_address", ExpectedValue: expected, ActualValue: actual}, true
+}
+
+// rootBlockDeviceFields are the root_block_device sub-attributes that are
+// compared, in report order. Selecting root_block_device compares them all,
+// so a new block device field is added here and to rootBlockDeviceDrift.
+var rootBlockDeviceFields = []string{"volume_size", "volume_type", "encrypted", "delete_on_termination"}
+
+// RootBlockDeviceFields returns the root_block_device sub-attributes that a
+// bare root_block_device selector compares
+func RootBlockDeviceFields() []string {
+	return append([]string(nil), rootBlockDeviceFields...)
+}
+
+// rootBlockDeviceDrift compares one root_block_device sub-attribute. Unknown
+// fields never drift.
+func rootBlockDeviceDrift(cmp ComparatorOptions, field string, o, c cloud.Instance) (DriftDetail, bool) {
+	attr := "root_block_device." + field
+	switch field {
+	case "volume_size":
+		if o.RootBlockDevice.VolumeSize != c.RootBlockDevice.VolumeSize {
+			return DriftDetail{Attribute: attr, ExpectedValue: o.RootBlockDevice.VolumeSize, ActualValue: c.RootBlockDevice.VolumeSize}, true
+		}
+	case "volume_type":
+		if !cmp.equalValues(attr, o.RootBlockDevice.VolumeType, c.RootBlockDevice.VolumeType) {
+			return DriftDetail{Attribute: attr, ExpectedValue: o.RootBlockDevice.VolumeType, ActualValue: c.RootBlockDevice.VolumeType}, true
+		}
+	case "encrypted":
+		return encryptionDrift(o, c)
+	case "delete_on_termination":
+		return deleteOnTerminationDrift(o, c)
+	}
+	return DriftDetail{}, false
+}
+
+// encryptionDrift compares root volume encryption. A desired config that does
+// not set encrypted is not compared, and a live volume without a value counts
+// as unencrypted.
+func encryptionDrift(o, c cloud.Instance) (DriftDetail, bool) {
+	if o.RootBlockDevice.Encrypted == nil {
+		return DriftDetail{}, false
+	}
+	expected := *o.RootBlockDevice.Encrypted
+	actual := c.RootBlockDevice.Encrypted != nil && *c.RootBlockDevice.Encrypted
+	if expected == actual {
+		return DriftDetail{}, false
+	}
+	return DriftDetail{Attribute: "root_block_device.encrypted", ExpectedValue: expected, ActualValue: actual}, true
+}
+
+// deleteOnTerminationDrift compares whether the root volume is deleted with
+// the instance. A desired config that does not set it is not compared, and a
+// volume without a value counts as deleted, the default of root volumes.
+func deleteOnTerminationDrift(o, c cloud.Instance) (DriftDetail, bool) {
+	if o.RootBlockDevice.DeleteOnTermination == nil {
+		return DriftDetail{}, false
+	}
+	expected := *o.RootBlockDevice.DeleteOnTermination
+	actual := c.RootBlockDevice.DeleteOnTermination == nil || *c.RootBlockDevice.DeleteOnTermination
+	if expected == actual {
+		return DriftDetail{}, false
+	}
+	return DriftDetail{Attribute: "root_block_device.delete_on_termination", ExpectedValue: expected, ActualValue: actual}, true
+}
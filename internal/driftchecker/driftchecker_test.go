@@ -2,11 +2,16 @@ package driftchecker_test
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/oldmonad/ec2Drift/internal/driftchecker"
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // There is just too much code here to comment due to time contraints, so we'll just skip the comments for brevity.
@@ -42,6 +47,7 @@ func TestDetectBasicDrift(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{Attribute: "ami", ExpectedValue: "ami-111", ActualValue: "ami-222"},
 				{Attribute: "instance_type", ExpectedValue: "t2.micro", ActualValue: "t2.large"},
@@ -100,6 +106,7 @@ func TestDetectInstanceRemoved(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{Attribute: "instance_removed", ExpectedValue: oldInstances[0], ActualValue: nil},
 			},
@@ -137,11 +144,14 @@ func TestDetectSecurityGroupsDrift(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{
 					Attribute:     "security_groups",
 					ExpectedValue: []string{"sg-1", "sg-2"},
 					ActualValue:   []string{"sg-3", "sg-4"},
+					Added:         []string{"sg-3", "sg-4"},
+					Removed:       []string{"sg-1", "sg-2"},
 				},
 			},
 		},
@@ -167,6 +177,7 @@ func TestDetectTagsDrift(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{
 					Attribute:     "tags.Env",
@@ -195,6 +206,7 @@ func TestDetectRootBlockDeviceVolumeSizeDrift(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{
 					Attribute:     "root_block_device.volume_size",
@@ -225,6 +237,7 @@ func TestDetectConcurrentProcessing(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{Attribute: "ami", ExpectedValue: "ami-111", ActualValue: "ami-333"},
 				{Attribute: "instance_type", ExpectedValue: "t2.micro", ActualValue: "t2.large"},
@@ -251,6 +264,28 @@ func TestDetectContextCancellation(t *testing.T) {
 	assert.Empty(t, reports)
 }
 
+func TestDetectStreamCancelWhileBlocked(t *testing.T) {
+	// More drifted instances than the stream buffers, so comparisons block
+	// on the receiver until the context is cancelled
+	var desired, live []cloud.Instance
+	for i := 0; i < 500; i++ {
+		name := fmt.Sprintf("app%d", i)
+		desired = append(desired, createInstance(name, name, "ami-111", "t2.micro", nil, nil, 100, "gp2"))
+		live = append(live, createInstance(name, fmt.Sprintf("i-%d", i), "ami-222", "t2.micro", nil, nil, 100, "gp2"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := driftchecker.DetectStream(ctx, desired, live, []string{"ami"})
+	<-stream
+	cancel()
+
+	received := 1
+	for range stream {
+		received++
+	}
+	assert.Less(t, received, len(desired))
+}
+
 func TestDetectEmptyAttributes(t *testing.T) {
 	oldInstances := []cloud.Instance{
 		createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
@@ -292,6 +327,7 @@ func TestDetectNameTagChange(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1-old",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{Attribute: "instance_removed", ExpectedValue: oldInstances[0], ActualValue: nil},
 			},
@@ -343,6 +379,7 @@ func TestDetectEmptyCurrentState(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{Attribute: "instance_removed", ExpectedValue: oldInstances[0], ActualValue: nil},
 			},
@@ -369,6 +406,7 @@ func TestDetectTagsDriftAllTags(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{
 					Attribute:     "tags.Owner",
@@ -402,6 +440,60 @@ func TestDetectRootBlockDeviceDriftBothAttributes(t *testing.T) {
 	assert.ElementsMatch(t, expectedDrifts, reports[0].Drifts, "Drifts for volume size and type should be detected")
 }
 
+// TestDetectRootBlockDeviceBareSelectorComparesAllFields tests that selecting
+// root_block_device compares every field of cloud.Instance.RootBlockDevice
+func TestDetectRootBlockDeviceBareSelectorComparesAllFields(t *testing.T) {
+	var tagged []string
+	rbd := reflect.TypeOf(cloud.Instance{}.RootBlockDevice)
+	for i := 0; i < rbd.NumField(); i++ {
+		name, _, _ := strings.Cut(rbd.Field(i).Tag.Get("json"), ",")
+		tagged = append(tagged, name)
+	}
+	require.ElementsMatch(t, tagged, driftchecker.RootBlockDeviceFields(),
+		"every root_block_device field is compared under the bare selector")
+
+	encrypted, unencrypted := true, false
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.RootBlockDevice.Encrypted = &encrypted
+	desired.RootBlockDevice.DeleteOnTermination = &encrypted
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 200, "gp3")
+	live.RootBlockDevice.Encrypted = &unencrypted
+	live.RootBlockDevice.DeleteOnTermination = &unencrypted
+
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"root_block_device"})
+	require.Len(t, reports, 1)
+
+	var attributes []string
+	for _, d := range reports[0].Drifts {
+		attributes = append(attributes, d.Attribute)
+	}
+	var expected []string
+	for _, field := range driftchecker.RootBlockDeviceFields() {
+		expected = append(expected, "root_block_device."+field)
+	}
+	assert.Equal(t, expected, attributes)
+}
+
+func TestDetectSkipsUnknownAttributes(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 8, "gp3")
+	encrypted := true
+	desired.RootBlockDevice.Encrypted = &encrypted
+	// The root volume could not be described, so its fields are zero
+	live := createInstance("app1", "i-123", "ami-222", "t2.micro", nil, nil, 0, "")
+	live.Unknown = []string{"root_block_device"}
+
+	attrs := []string{"ami", "root_block_device"}
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs)
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "ami", ExpectedValue: "ami-111", ActualValue: "ami-222"},
+	}, reports[0].Drifts, "only attributes that were fetched are compared")
+
+	attrs = []string{"root_block_device.volume_size", "root_block_device.encrypted"}
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs))
+}
+
 func TestDetectRootBlockDeviceVolumeTypeDrift(t *testing.T) {
 	oldInstances := []cloud.Instance{
 		createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
@@ -417,6 +509,7 @@ func TestDetectRootBlockDeviceVolumeTypeDrift(t *testing.T) {
 		{
 			InstanceID: "i-123",
 			Name:       "app1",
+			Managed:    true,
 			Drifts: []driftchecker.DriftDetail{
 				{
 					Attribute:     "root_block_device.volume_type",
@@ -430,6 +523,90 @@ func TestDetectRootBlockDeviceVolumeTypeDrift(t *testing.T) {
 	assert.ElementsMatch(t, expected, reports)
 }
 
+func TestDetectRootBlockDeviceEncryptionDrift(t *testing.T) {
+	encrypted := true
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.RootBlockDevice.Encrypted = &encrypted
+	unencrypted := false
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.RootBlockDevice.Encrypted = &unencrypted
+
+	for _, attributes := range [][]string{{"root_block_device.encrypted"}, {"root_block_device"}} {
+		reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attributes)
+
+		require.Len(t, reports, 1)
+		assert.Equal(t, "i-123", reports[0].InstanceID)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "root_block_device.encrypted", ExpectedValue: true, ActualValue: false},
+		}, reports[0].Drifts)
+	}
+
+	// Leaving encrypted unset in the desired config skips the comparison
+	desired.RootBlockDevice.Encrypted = nil
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"root_block_device.encrypted"})
+	assert.Empty(t, reports)
+}
+
+func TestDetectRootBlockDeviceDeleteOnTerminationDrift(t *testing.T) {
+	deleted, kept := true, false
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.RootBlockDevice.DeleteOnTermination = &deleted
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.RootBlockDevice.DeleteOnTermination = &kept
+
+	for _, attributes := range [][]string{{"root_block_device.delete_on_termination"}, {"root_block_device"}} {
+		reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attributes)
+
+		require.Len(t, reports, 1)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "root_block_device.delete_on_termination", ExpectedValue: true, ActualValue: false},
+		}, reports[0].Drifts)
+	}
+
+	// A matching live volume does not drift
+	live.RootBlockDevice.DeleteOnTermination = &deleted
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"root_block_device.delete_on_termination"})
+	assert.Empty(t, reports)
+
+	// Leaving it unset in the desired config skips the comparison
+	desired.RootBlockDevice.DeleteOnTermination = nil
+	live.RootBlockDevice.DeleteOnTermination = &kept
+	reports = driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"root_block_device.delete_on_termination"})
+	assert.Empty(t, reports)
+}
+
+func TestDetectAssociatePublicIPDrift(t *testing.T) {
+	attrs := []string{"associate_public_ip_address"}
+	detect := func(expected, actual *bool) []driftchecker.DriftReport {
+		desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+		desired.AssociatePublicIP = expected
+		live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+		live.AssociatePublicIP = actual
+		return driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs)
+	}
+	yes, no := true, false
+
+	// Gained a public IP
+	reports := detect(&no, &yes)
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "associate_public_ip_address", ExpectedValue: false, ActualValue: true},
+	}, reports[0].Drifts)
+
+	// Lost it, including live data without a value
+	for _, actual := range []*bool{&no, nil} {
+		reports = detect(&yes, actual)
+		require.Len(t, reports, 1)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "associate_public_ip_address", ExpectedValue: true, ActualValue: false},
+		}, reports[0].Drifts)
+	}
+
+	assert.Empty(t, detect(&yes, &yes))
+	assert.Empty(t, detect(&no, nil), "missing live data counts as no association")
+	assert.Empty(t, detect(nil, &yes), "unset in the desired config is not compared")
+}
+
 func TestDetectSecurityGroupsDriftDifferentLength(t *testing.T) {
 	oldInstances := []cloud.Instance{
 		createInstance("app1", "i-123", "ami-111", "t2.micro", []string{"sg-1", "sg-2"}, nil, 100, "gp2"),
@@ -445,8 +622,966 @@ func TestDetectSecurityGroupsDriftDifferentLength(t *testing.T) {
 		Attribute:     "security_groups",
 		ExpectedValue: []string{"sg-1", "sg-2"},
 		ActualValue:   []string{"sg-1"},
+		Removed:       []string{"sg-2"},
 	}
 
 	assert.Len(t, reports, 1, "Expected one drift report")
 	assert.Contains(t, reports[0].Drifts, expectedDrift, "Security groups with different lengths should be reported as drifted")
 }
+
+func TestDetectSecurityGroupsDriftPartialOverlap(t *testing.T) {
+	oldInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-111", "t2.micro", []string{"sg-web", "sg-ssh", "sg-db"}, nil, 100, "gp2"),
+	}
+	currentInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-111", "t2.micro", []string{"sg-db", "sg-admin", "sg-web", "sg-admin"}, nil, 100, "gp2"),
+	}
+
+	reports := driftchecker.Detect(context.Background(), oldInstances, currentInstances, []string{"security_groups"})
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{{
+		Attribute:     "security_groups",
+		ExpectedValue: []string{"sg-web", "sg-ssh", "sg-db"},
+		ActualValue:   []string{"sg-db", "sg-admin", "sg-web", "sg-admin"},
+		Added:         []string{"sg-admin"},
+		Removed:       []string{"sg-ssh"},
+	}}, reports[0].Drifts, "full lists are kept next to the deltas")
+}
+
+func TestDetectIPDrift(t *testing.T) {
+	old := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	old.PrivateIP = "10.0.0.5"
+	old.PublicIP = "54.1.1.1"
+	current := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	current.PrivateIP = "10.0.0.9"
+	current.PublicIP = "54.2.2.2"
+
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{old}, []cloud.Instance{current},
+		[]string{"private_ip", "public_ip"})
+
+	require.Len(t, reports, 1)
+	assert.ElementsMatch(t, []driftchecker.DriftDetail{
+		{Attribute: "private_ip", ExpectedValue: "10.0.0.5", ActualValue: "10.0.0.9"},
+		{Attribute: "public_ip", ExpectedValue: "54.1.1.1", ActualValue: "54.2.2.2"},
+	}, reports[0].Drifts)
+}
+
+func TestDetectIPNoPublicIP(t *testing.T) {
+	old := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	old.PrivateIP = "10.0.0.5"
+	current := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	current.PrivateIP = "10.0.0.5"
+
+	// Neither side has a public IP, so there is nothing to report
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{old}, []cloud.Instance{current},
+		[]string{"private_ip", "public_ip"})
+	assert.Empty(t, reports)
+
+	// A public IP that was released shows up as drift
+	old.PublicIP = "54.1.1.1"
+	reports = driftchecker.Detect(context.Background(), []cloud.Instance{old}, []cloud.Instance{current},
+		[]string{"public_ip"})
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "public_ip", ExpectedValue: "54.1.1.1", ActualValue: ""},
+	}, reports[0].Drifts)
+}
+
+func TestDetectIPUnset(t *testing.T) {
+	old := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	current := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	current.PrivateIP = "10.0.0.9"
+	current.PublicIP = "54.2.2.2"
+
+	// The desired config leaves both IPs to AWS
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{old}, []cloud.Instance{current},
+		[]string{"private_ip", "public_ip"})
+	assert.Empty(t, reports)
+}
+
+func TestDetectCarriesProvider(t *testing.T) {
+	awsInstance := createInstance("web", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	awsInstance.Provider = "aws"
+	gcpInstance := createInstance("api", "gcp-1", "img-1", "n1-standard-1", nil, nil, 10, "pd-ssd")
+	gcpInstance.Provider = "gcp"
+
+	oldInstances := []cloud.Instance{awsInstance, gcpInstance}
+	currentInstances := []cloud.Instance{
+		createInstance("web", "i-123", "ami-222", "t2.micro", nil, nil, 100, "gp2"),
+	}
+
+	reports := driftchecker.Detect(context.Background(), oldInstances, currentInstances, []string{"ami"})
+	require.Len(t, reports, 2)
+
+	providers := make(map[string]string)
+	for _, report := range reports {
+		providers[report.InstanceID] = report.Provider
+	}
+	assert.Equal(t, "aws", providers["i-123"])
+	assert.Equal(t, "gcp", providers["gcp-1"])
+}
+
+func TestFilterByCategory(t *testing.T) {
+	oldInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app2", "i-456", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	currentInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-222", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app3", "i-789", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	attributes := []string{"ami"}
+
+	reports := driftchecker.Detect(context.Background(), oldInstances, currentInstances, attributes)
+	require.Len(t, reports, 3)
+
+	added := driftchecker.Filter(reports, []string{driftchecker.CategoryAdded})
+	require.Len(t, added, 1)
+	assert.Equal(t, "app3", added[0].Name)
+
+	removed := driftchecker.Filter(reports, []string{driftchecker.CategoryRemoved})
+	require.Len(t, removed, 1)
+	assert.Equal(t, "app2", removed[0].Name)
+
+	changed := driftchecker.Filter(reports, []string{driftchecker.CategoryChanged})
+	require.Len(t, changed, 1)
+	assert.Equal(t, "app1", changed[0].Name)
+
+	both := driftchecker.Filter(reports, []string{driftchecker.CategoryAdded, driftchecker.CategoryRemoved})
+	assert.Len(t, both, 2)
+}
+
+func TestFilterByAttribute(t *testing.T) {
+	oldInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-111", "t2.micro", nil, map[string]string{"Env": "prod"}, 100, "gp2"),
+	}
+	currentInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-222", "t2.large", nil, map[string]string{"Env": "dev"}, 200, "gp2"),
+	}
+	attributes := []string{"ami", "instance_type", "tags", "root_block_device"}
+
+	reports := driftchecker.Detect(context.Background(), oldInstances, currentInstances, attributes)
+
+	filtered := driftchecker.Filter(reports, []string{"tags"})
+	expected := []driftchecker.DriftReport{
+		{
+			InstanceID: "i-123",
+			Name:       "app1",
+			Managed:    true,
+			Drifts: []driftchecker.DriftDetail{
+				{Attribute: "tags.Env", ExpectedValue: "prod", ActualValue: "dev"},
+			},
+		},
+	}
+	assert.Equal(t, expected, filtered)
+
+	filtered = driftchecker.Filter(reports, []string{"ami", "root_block_device.volume_size"})
+	require.Len(t, filtered, 1)
+	assert.ElementsMatch(t, []driftchecker.DriftDetail{
+		{Attribute: "ami", ExpectedValue: "ami-111", ActualValue: "ami-222"},
+		{Attribute: "root_block_device.volume_size", ExpectedValue: 100, ActualValue: 200},
+	}, filtered[0].Drifts)
+}
+
+func TestFilterNoMatchesAndNoSelectors(t *testing.T) {
+	oldInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	currentInstances := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-222", "t2.micro", nil, nil, 100, "gp2"),
+	}
+
+	reports := driftchecker.Detect(context.Background(), oldInstances, currentInstances, []string{"ami"})
+
+	assert.Equal(t, reports, driftchecker.Filter(reports, nil))
+	assert.Empty(t, driftchecker.Filter(reports, []string{driftchecker.CategoryAdded}))
+}
+
+func TestMarkUnmanaged(t *testing.T) {
+	live := createInstance("scratch", "i-999", "ami-111", "t2.micro", nil, nil, 8, "gp3")
+	reports := []driftchecker.DriftReport{
+		{
+			InstanceID: "i-999",
+			Name:       "scratch",
+			Drifts: []driftchecker.DriftDetail{
+				{Attribute: "instance_added", ExpectedValue: nil, ActualValue: live},
+			},
+		},
+		{
+			InstanceID: "i-123",
+			Name:       "app1",
+			Drifts: []driftchecker.DriftDetail{
+				{Attribute: "ami", ExpectedValue: "ami-111", ActualValue: "ami-222"},
+			},
+		},
+	}
+
+	marked := driftchecker.MarkUnmanaged(reports)
+
+	require.Len(t, marked, 2)
+	assert.Equal(t, "instance_unmanaged", marked[0].Drifts[0].Attribute)
+	assert.Equal(t, "ami", marked[1].Drifts[0].Attribute)
+	assert.Equal(t, "instance_added", reports[0].Drifts[0].Attribute, "input reports must not be modified")
+
+	assert.True(t, driftchecker.HasDrift(marked))
+	assert.False(t, driftchecker.HasDrift(marked[:1]))
+	assert.Len(t, driftchecker.Filter(marked, []string{driftchecker.CategoryUnmanaged}), 1)
+	assert.Empty(t, driftchecker.Filter(marked[:1], []string{driftchecker.CategoryAdded, driftchecker.CategoryChanged}))
+}
+
+func TestExplain(t *testing.T) {
+	desired := []cloud.Instance{
+		createInstance("web", "web", "ami-111", "t2.micro", nil, nil, 8, "gp3"),
+	}
+
+	matched := createInstance("web", "i-123", "ami-111", "t2.micro", nil, nil, 8, "gp3")
+	matched.Provider = "aws"
+	renamed := createInstance("web-2", "i-456", "ami-111", "t2.micro", nil, nil, 8, "gp3")
+	untagged := cloud.Instance{InstanceID: "i-789", Tags: map[string]string{"Env": "prod"}}
+
+	explanations := driftchecker.Explain(desired, []cloud.Instance{matched, renamed, untagged})
+
+	assert.Equal(t, []driftchecker.MatchExplanation{
+		{InstanceID: "i-123", Provider: "aws", Name: "web", MatchKey: "tags.Name", Matched: true, DesiredID: "web", Reason: driftchecker.ReasonMatched},
+		{InstanceID: "i-456", Name: "web-2", MatchKey: "tags.Name", Reason: driftchecker.ReasonNoMatchingName},
+		{InstanceID: "i-789", MatchKey: "tags.Name", Reason: driftchecker.ReasonMissingNameTag},
+	}, explanations)
+}
+
+func TestDetectMetadataHttpTokensDrift(t *testing.T) {
+	desired := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.MetadataHttpTokens = "required"
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.MetadataHttpTokens = "optional"
+
+	for _, attr := range []string{"metadata_options.http_tokens", "metadata_options"} {
+		reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{attr})
+
+		require.Len(t, reports, 1, attr)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "metadata_options.http_tokens", ExpectedValue: "required", ActualValue: "optional"},
+		}, reports[0].Drifts, attr)
+	}
+
+	live.MetadataHttpTokens = "required"
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live},
+		[]string{"metadata_options.http_tokens"})
+	assert.Empty(t, reports)
+}
+
+func TestDetectMetadataHttpTokensUnset(t *testing.T) {
+	desired := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.MetadataHttpTokens = "optional"
+
+	// The desired config does not set http_tokens, so it is not compared
+	for _, attr := range []string{"metadata_options.http_tokens", "metadata_options"} {
+		assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{attr}), attr)
+	}
+}
+
+func TestDetectMatchesHyphenatedNamesExactly(t *testing.T) {
+	desired := []cloud.Instance{
+		createInstance("payment-service", "payment_service", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("payment-service-abc123", "payment_service_abc123", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	live := []cloud.Instance{
+		createInstance("payment-service", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("payment-service-abc123", "i-456", "ami-222", "t2.micro", nil, nil, 100, "gp2"),
+	}
+
+	reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"})
+
+	// Only the suffixed instance drifted; neither name is cut at a hyphen
+	require.Len(t, reports, 1)
+	assert.Equal(t, "i-456", reports[0].InstanceID)
+	assert.Equal(t, "payment-service-abc123", reports[0].Name)
+}
+
+func TestDetectStream(t *testing.T) {
+	desired := []cloud.Instance{
+		createInstance("app1", "app1", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app2", "app2", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	live := []cloud.Instance{
+		createInstance("app1", "i-123", "ami-222", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app3", "i-789", "ami-111", "t2.micro", nil, nil, 100, "gp2"),
+	}
+
+	var streamed []driftchecker.DriftReport
+	for report := range driftchecker.DetectStream(context.Background(), desired, live, []string{"ami"}) {
+		streamed = append(streamed, report)
+	}
+
+	// The channel is closed after every instance is compared
+	assert.ElementsMatch(t, driftchecker.Detect(context.Background(), desired, live, []string{"ami"}), streamed)
+	assert.Len(t, streamed, 3)
+}
+
+func TestDetectPopulatedAttributes(t *testing.T) {
+	// The desired config only pins the AMI and the instance type
+	desired := cloud.Instance{InstanceID: "web", AMI: "ami-111", InstanceType: "t2.micro", Tags: map[string]string{"Name": "web"}}
+	live := createInstance("web", "i-123", "ami-222", "t3.large", []string{"sg-1"}, map[string]string{"Env": "prod"}, 100, "gp3")
+	all := []string{"ami", "instance_type", "security_groups", "tags", "root_block_device.volume_size",
+		"root_block_device.volume_type", "root_block_device.encrypted", "private_ip", "public_ip", "metadata_options.http_tokens"}
+
+	var reports []driftchecker.DriftReport
+	for report := range driftchecker.DetectStreamWith(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live},
+		driftchecker.PopulatedAttributes(all)) {
+		reports = append(reports, report)
+	}
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "ami", ExpectedValue: "ami-111", ActualValue: "ami-222"},
+		{Attribute: "instance_type", ExpectedValue: "t2.micro", ActualValue: "t3.large"},
+	}, reports[0].Drifts)
+}
+
+func TestPopulatedAttributes(t *testing.T) {
+	inst := cloud.Instance{
+		AMI:  "ami-111",
+		Tags: map[string]string{"Name": "web", "Env": "prod"},
+	}
+	inst.RootBlockDevice.VolumeSize = 20
+
+	selected := driftchecker.PopulatedAttributes([]string{
+		"ami", "instance_type", "tags", "tags.Env", "tags.Team",
+		"root_block_device.volume_size", "root_block_device.volume_type", "root_block_device.encrypted",
+	})(inst)
+	assert.Equal(t, []string{"ami", "tags", "tags.Env", "root_block_device.volume_size"}, selected)
+
+	// The Name tag alone does not make tags populated
+	inst.Tags = map[string]string{"Name": "web"}
+	assert.Empty(t, driftchecker.PopulatedAttributes([]string{"tags"})(inst))
+}
+
+func TestParseSeverityMap(t *testing.T) {
+	severities, err := driftchecker.ParseSeverityMap("ami=critical, tags=INFO,,tags.Env=warning")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]driftchecker.Severity{
+		"ami":      driftchecker.SeverityCritical,
+		"tags":     driftchecker.SeverityInfo,
+		"tags.Env": driftchecker.SeverityWarning,
+	}, severities)
+
+	_, err = driftchecker.ParseSeverityMap("ami=urgent")
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedSeverity{})
+
+	_, err = driftchecker.ParseSeverityMap("ami")
+	assert.ErrorAs(t, err, &errors.ErrInvalidSeverityMapping{})
+}
+
+func TestParseComparators(t *testing.T) {
+	comparators, err := driftchecker.ParseComparators("ami=Case-Insensitive, tags=prefix-ignore:aws:,,security_groups=exact")
+	require.NoError(t, err)
+	assert.Equal(t, driftchecker.ComparatorOptions{
+		"ami":             {Strategy: driftchecker.StrategyCaseInsensitive},
+		"tags":            {Strategy: driftchecker.StrategyPrefixIgnore, Prefix: "aws:"},
+		"security_groups": {Strategy: driftchecker.StrategyExact},
+	}, comparators)
+
+	for _, raw := range []string{"ami", "ami=fuzzy", "tags=prefix-ignore", "ami=exact:foo"} {
+		_, err = driftchecker.ParseComparators(raw)
+		assert.ErrorAs(t, err, &errors.ErrInvalidComparator{}, raw)
+	}
+}
+
+func TestDetectWithComparators(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-ABC", "t2.micro", []string{"sg-1", "sg-2"},
+		map[string]string{"Env": "prod", "aws:cloudformation:stack-name": "infra"}, 100, "gp2")
+	live := createInstance("app1", "i-123", "ami-abc", "t2.micro", []string{"sg-2", "sg-1"},
+		map[string]string{"Env": "prod", "aws:cloudformation:stack-name": "other"}, 100, "gp2")
+	attrs := []string{"ami", "tags", "security_groups"}
+
+	// Exact stays the default, and aws: tags are skipped by default
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs)
+	require.Len(t, reports, 1)
+	assert.ElementsMatch(t, []driftchecker.DriftDetail{
+		{Attribute: "ami", ExpectedValue: "ami-ABC", ActualValue: "ami-abc"},
+	}, reports[0].Drifts)
+
+	comparators := driftchecker.ComparatorOptions{
+		"ami":  {Strategy: driftchecker.StrategyCaseInsensitive},
+		"tags": {Strategy: driftchecker.StrategyPrefixIgnore, Prefix: "aws:"},
+	}
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs,
+		driftchecker.WithComparators(comparators)))
+
+	// Order matters once security groups are compared exactly
+	comparators["security_groups"] = driftchecker.Comparator{Strategy: driftchecker.StrategyExact}
+	reports = driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs,
+		driftchecker.WithComparators(comparators))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "security_groups", reports[0].Drifts[0].Attribute)
+}
+
+func TestDetectIgnoreCaseValues(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-ABC", "T3.Micro", []string{"SG-1", "sg-2"},
+		map[string]string{"Env": "Prod"}, 100, "GP2")
+	live := createInstance("app1", "i-123", "ami-abc", "t3.micro", []string{"sg-2", "sg-1"},
+		map[string]string{"Env": "prod"}, 100, "gp2")
+	attrs := []string{"ami", "instance_type", "security_groups", "tags", "root_block_device"}
+	detect := func(opts ...driftchecker.DetectOption) []driftchecker.DriftReport {
+		return driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs, opts...)
+	}
+
+	t.Run("case-sensitive by default", func(t *testing.T) {
+		reports := detect()
+		require.Len(t, reports, 1)
+		assert.Len(t, reports[0].Drifts, 5)
+	})
+
+	t.Run("case variants do not drift", func(t *testing.T) {
+		assert.Empty(t, detect(driftchecker.WithIgnoreCaseValues(true)))
+	})
+
+	t.Run("other differences still drift", func(t *testing.T) {
+		changed := live
+		changed.InstanceType = "t3.large"
+		reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{changed}, attrs,
+			driftchecker.WithIgnoreCaseValues(true))
+		require.Len(t, reports, 1)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "instance_type", ExpectedValue: "T3.Micro", ActualValue: "t3.large"},
+		}, reports[0].Drifts)
+	})
+
+	t.Run("comparators win", func(t *testing.T) {
+		reports := detect(driftchecker.WithIgnoreCaseValues(true), driftchecker.WithComparators(driftchecker.ComparatorOptions{
+			"ami": {Strategy: driftchecker.StrategyExact},
+		}))
+		require.Len(t, reports, 1)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "ami", ExpectedValue: "ami-ABC", ActualValue: "ami-abc"},
+		}, reports[0].Drifts)
+	})
+}
+
+func TestDetectAWSManagedTags(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-1", "t2.micro", nil,
+		map[string]string{"Env": "prod", "aws:cloudformation:stack-name": "infra", "aws:autoscaling:groupName": "web-asg"}, 100, "gp2")
+	live := createInstance("app1", "i-123", "ami-1", "t2.micro", nil,
+		map[string]string{"Env": "prod", "aws:cloudformation:stack-name": "other", "aws:autoscaling:groupName": "api-asg"}, 100, "gp2")
+	detect := func(attrs []string, comparators driftchecker.ComparatorOptions) []driftchecker.DriftDetail {
+		reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs,
+			driftchecker.WithComparators(comparators))
+		if len(reports) == 0 {
+			return nil
+		}
+		return reports[0].Drifts
+	}
+
+	t.Run("ignored by default", func(t *testing.T) {
+		assert.Empty(t, detect([]string{"tags"}, nil))
+		assert.Empty(t, detect([]string{"tags.aws:cloudformation:stack-name"}, nil))
+	})
+
+	t.Run("compared when opted in for all tags", func(t *testing.T) {
+		drifts := detect([]string{"tags"}, driftchecker.ComparatorOptions{"tags": {Strategy: driftchecker.StrategyExact}})
+		assert.ElementsMatch(t, []driftchecker.DriftDetail{
+			{Attribute: "tags.aws:cloudformation:stack-name", ExpectedValue: "infra", ActualValue: "other"},
+			{Attribute: "tags.aws:autoscaling:groupName", ExpectedValue: "web-asg", ActualValue: "api-asg"},
+		}, drifts)
+	})
+
+	t.Run("compared when opted in for one tag", func(t *testing.T) {
+		drifts := detect([]string{"tags"}, driftchecker.ComparatorOptions{
+			"tags.aws:autoscaling:groupName": {Strategy: driftchecker.StrategyExact},
+		})
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "tags.aws:autoscaling:groupName", ExpectedValue: "web-asg", ActualValue: "api-asg"},
+		}, drifts)
+	})
+
+	t.Run("replaced by another prefix", func(t *testing.T) {
+		drifts := detect([]string{"tags"}, driftchecker.ComparatorOptions{
+			"tags": {Strategy: driftchecker.StrategyPrefixIgnore, Prefix: "aws:cloudformation:"},
+		})
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "tags.aws:autoscaling:groupName", ExpectedValue: "web-asg", ActualValue: "api-asg"},
+		}, drifts)
+	})
+}
+
+func TestDetectWithInstances(t *testing.T) {
+	desired := []cloud.Instance{
+		createInstance("app1", "web", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app2", "api", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app3", "db", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app4", "queue", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	live := []cloud.Instance{
+		createInstance("app1", "i-1", "ami-2", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app2", "i-2", "ami-2", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app3", "i-3", "ami-2", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app5", "i-5", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	reportIDs := func(reports []driftchecker.DriftReport) []string {
+		ids := make([]string, 0, len(reports))
+		for _, r := range reports {
+			ids = append(ids, r.InstanceID)
+		}
+		return ids
+	}
+
+	t.Run("IncludeOnly", func(t *testing.T) {
+		// Neither app4, missing live, nor the unmatched i-5 is considered
+		reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithInstances([]string{"i-1", "i-2"}, nil))
+		assert.ElementsMatch(t, []string{"i-1", "i-2"}, reportIDs(reports))
+	})
+
+	t.Run("IncludeThenExclude", func(t *testing.T) {
+		reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithInstances([]string{"i-1", "i-2"}, []string{"i-2"}))
+		assert.ElementsMatch(t, []string{"i-1"}, reportIDs(reports))
+	})
+
+	t.Run("ExcludeOnly", func(t *testing.T) {
+		// app2 goes with i-2, the removed app4 and added i-5 are still reported
+		reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithInstances(nil, []string{"i-2"}))
+		assert.ElementsMatch(t, []string{"i-1", "i-3", "queue", "i-5"}, reportIDs(reports))
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		assert.Empty(t, driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithInstances([]string{"i-9"}, nil)))
+	})
+}
+
+func TestDetectWithNetwork(t *testing.T) {
+	inNetwork := func(inst cloud.Instance, vpcID, subnetID string) cloud.Instance {
+		inst.VpcID = vpcID
+		inst.SubnetID = subnetID
+		return inst
+	}
+	desired := []cloud.Instance{
+		createInstance("app1", "web", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("app2", "api", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		inNetwork(createInstance("app3", "db", "ami-1", "t2.micro", nil, nil, 100, "gp2"), "", "subnet-a"),
+		inNetwork(createInstance("app4", "queue", "ami-1", "t2.micro", nil, nil, 100, "gp2"), "", "subnet-b"),
+	}
+	live := []cloud.Instance{
+		inNetwork(createInstance("app1", "i-1", "ami-2", "t2.micro", nil, nil, 100, "gp2"), "vpc-1", "subnet-a"),
+		inNetwork(createInstance("app2", "i-2", "ami-2", "t2.micro", nil, nil, 100, "gp2"), "vpc-1", "subnet-b"),
+		inNetwork(createInstance("app5", "i-5", "ami-1", "t2.micro", nil, nil, 100, "gp2"), "vpc-2", "subnet-c"),
+	}
+	reportIDs := func(reports []driftchecker.DriftReport) []string {
+		ids := make([]string, 0, len(reports))
+		for _, r := range reports {
+			ids = append(ids, r.InstanceID)
+		}
+		return ids
+	}
+
+	t.Run("VPC", func(t *testing.T) {
+		// i-5 is in another VPC; the unmatched db and queue declare no VPC
+		reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithNetwork("vpc-1", ""))
+		assert.ElementsMatch(t, []string{"i-1", "i-2"}, reportIDs(reports))
+	})
+
+	t.Run("Subnet", func(t *testing.T) {
+		// app2 goes with i-2, db declares subnet-a and is reported removed
+		reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithNetwork("", "subnet-a"))
+		assert.ElementsMatch(t, []string{"i-1", "db"}, reportIDs(reports))
+	})
+
+	t.Run("WithInstances", func(t *testing.T) {
+		reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"},
+			driftchecker.WithNetwork("vpc-1", ""), driftchecker.WithInstances(nil, []string{"i-1"}))
+		assert.ElementsMatch(t, []string{"i-2"}, reportIDs(reports))
+	})
+
+	t.Run("SelectNetwork", func(t *testing.T) {
+		assert.Len(t, driftchecker.SelectNetwork(live, "", ""), 3)
+		assert.Len(t, driftchecker.SelectNetwork(live, "vpc-1", "subnet-b"), 1)
+		assert.Empty(t, driftchecker.SelectNetwork(desired, "vpc-1", ""), "instances without a VPC never match")
+	})
+}
+
+func TestDetectManaged(t *testing.T) {
+	desired := []cloud.Instance{
+		createInstance("web", "web", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("db", "db", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	live := []cloud.Instance{
+		createInstance("web", "i-1", "ami-2", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("batch", "i-2", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+	}
+
+	managed := func(reports []driftchecker.DriftReport) map[string]bool {
+		byName := make(map[string]bool, len(reports))
+		for _, r := range reports {
+			byName[r.Name] = r.Managed
+		}
+		return byName
+	}
+
+	reports := driftchecker.Detect(context.Background(), desired, live, []string{"ami"})
+	assert.Equal(t, map[string]bool{"web": true, "db": true, "batch": false}, managed(reports))
+
+	// Unmanaged instances keep the flag once reclassified
+	assert.Equal(t, map[string]bool{"web": true, "db": true, "batch": false}, managed(driftchecker.MarkUnmanaged(reports)))
+
+	unnamed := cloud.Instance{InstanceID: "i-3"}
+	assert.True(t, driftchecker.Unmatched([]cloud.Instance{unnamed})[0].Managed)
+}
+
+func TestDetectNoDriftReports(t *testing.T) {
+	desired := []cloud.Instance{
+		createInstance("web", "web", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("api", "api", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("db", "db", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+	}
+	live := []cloud.Instance{
+		createInstance("web", "i-1", "ami-2", "t2.micro", nil, nil, 100, "gp2"),
+		createInstance("api", "i-2", "ami-1", "t2.micro", nil, nil, 100, "gp2"),
+	}
+
+	byName := func(reports []driftchecker.DriftReport) map[string]driftchecker.DriftReport {
+		m := make(map[string]driftchecker.DriftReport, len(reports))
+		for _, r := range reports {
+			m[r.Name] = r
+		}
+		return m
+	}
+
+	reports := byName(driftchecker.Detect(context.Background(), desired, live, []string{"ami", "instance_type"}))
+	assert.Len(t, reports, 2, "clean instances are not reported by default")
+	assert.NotContains(t, reports, "api")
+
+	all := driftchecker.Detect(context.Background(), desired, live, []string{"ami", "instance_type"},
+		driftchecker.WithNoDriftReports(true))
+	reports = byName(all)
+	require.Len(t, reports, 3)
+
+	clean := reports["api"]
+	assert.True(t, clean.Clean())
+	assert.Equal(t, driftchecker.StatusOK, clean.Status)
+	assert.Equal(t, "i-2", clean.InstanceID)
+	assert.True(t, clean.Managed)
+	assert.Empty(t, clean.Drifts)
+
+	assert.False(t, reports["web"].Clean(), "drifted instances keep an empty status")
+	assert.False(t, reports["db"].Clean(), "removed instances keep an empty status")
+
+	// Clean reports never count as drift
+	assert.True(t, driftchecker.HasDrift(all))
+	assert.False(t, driftchecker.HasDrift([]driftchecker.DriftReport{clean}))
+	// Filtering narrows down drift, clean reports have none and are kept
+	assert.Equal(t, []driftchecker.DriftReport{clean}, driftchecker.Filter([]driftchecker.DriftReport{clean}, []string{"changed"}))
+}
+
+func TestAssignSeverity(t *testing.T) {
+	reports := []driftchecker.DriftReport{
+		{InstanceID: "i-1", Drifts: []driftchecker.DriftDetail{
+			{Attribute: "ami"},
+			{Attribute: "tags.Env"},
+			{Attribute: "tags.Team"},
+			{Attribute: "instance_type"},
+		}},
+		{InstanceID: "i-2", Drifts: []driftchecker.DriftDetail{{Attribute: "instance_removed"}}},
+	}
+	severities := map[string]driftchecker.Severity{
+		"ami":      driftchecker.SeverityCritical,
+		"tags":     driftchecker.SeverityInfo,
+		"tags.Env": driftchecker.SeverityWarning,
+		"removed":  driftchecker.SeverityCritical,
+	}
+
+	assigned := driftchecker.AssignSeverity(reports, severities)
+
+	var got []driftchecker.Severity
+	for _, drift := range assigned[0].Drifts {
+		got = append(got, drift.Severity)
+	}
+	assert.Equal(t, []driftchecker.Severity{
+		driftchecker.SeverityCritical, // exact attribute
+		driftchecker.SeverityWarning,  // nested attribute beats its parent
+		driftchecker.SeverityInfo,     // falls back to the parent attribute
+		"",                            // unmapped
+	}, got)
+	assert.Equal(t, driftchecker.SeverityCritical, assigned[1].Drifts[0].Severity)
+
+	// The input reports are left untouched
+	assert.Empty(t, reports[0].Drifts[0].Severity)
+}
+
+func TestHasDriftAtLeast(t *testing.T) {
+	reports := []driftchecker.DriftReport{{Drifts: []driftchecker.DriftDetail{
+		{Attribute: "tags.Env", Severity: driftchecker.SeverityInfo},
+		{Attribute: "instance_unmanaged", Severity: driftchecker.SeverityCritical},
+	}}}
+
+	assert.True(t, driftchecker.HasDriftAtLeast(reports, driftchecker.SeverityInfo))
+	assert.False(t, driftchecker.HasDriftAtLeast(reports, driftchecker.SeverityWarning))
+
+	// Drift without a severity counts as warning
+	reports[0].Drifts = append(reports[0].Drifts, driftchecker.DriftDetail{Attribute: "instance_type"})
+	assert.True(t, driftchecker.HasDriftAtLeast(reports, driftchecker.SeverityWarning))
+	assert.False(t, driftchecker.HasDriftAtLeast(reports, driftchecker.SeverityCritical))
+}
+
+func TestDetectUserDataDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.UserDataHash = cloud.HashUserData([]byte("#!/bin/bash\necho v2"))
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.UserDataHash = cloud.HashUserData([]byte("#!/bin/bash\necho v1"))
+
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"user_data"})
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "user_data", ExpectedValue: desired.UserDataHash, ActualValue: live.UserDataHash},
+	}, reports[0].Drifts)
+
+	live.UserDataHash = desired.UserDataHash
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"user_data"}))
+
+	// The desired config does not set user data
+	desired.UserDataHash = ""
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"user_data"}))
+}
+
+func TestDetectInstanceLifecycleDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.InstanceLifecycle = "spot"
+
+	// A desired config without market options means on-demand
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"instance_lifecycle"})
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "instance_lifecycle", ExpectedValue: "normal", ActualValue: "spot"},
+	}, reports[0].Drifts)
+
+	live.InstanceLifecycle = cloud.LifecycleNormal
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"instance_lifecycle"}))
+}
+
+func TestDetectMonitoringDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.MonitoringState = "enabled"
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.MonitoringState = "disabled"
+
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"monitoring"})
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "monitoring", ExpectedValue: "enabled", ActualValue: "disabled"},
+	}, reports[0].Drifts)
+
+	// Monitoring that is being enabled is not drift
+	live.MonitoringState = "pending"
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"monitoring"}))
+
+	// The desired config does not set monitoring
+	desired.MonitoringState = ""
+	live.MonitoringState = "disabled"
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"monitoring"}))
+}
+
+func TestDetectHostIDDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.HostID = "h-0aaa1111bbbb2222c"
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.HostID = "h-0ddd3333eeee4444f"
+
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"host_id"})
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "host_id", ExpectedValue: "h-0aaa1111bbbb2222c", ActualValue: "h-0ddd3333eeee4444f"},
+	}, reports[0].Drifts)
+
+	// An instance moved off its dedicated host onto shared tenancy
+	live.HostID = ""
+	reports = driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"host_id"})
+	require.Len(t, reports, 1)
+	assert.Equal(t, "", reports[0].Drifts[0].ActualValue)
+
+	live.HostID = desired.HostID
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"host_id"}))
+}
+
+func TestDetectCapacityReservationDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.CapacityReservationID = "cr-0123456789abcdef0"
+	desired.Affinity = "host"
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.CapacityReservationID = "cr-0fedcba9876543210"
+	live.Affinity = "default"
+
+	attrs := []string{"capacity_reservation_id", "affinity"}
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs)
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "capacity_reservation_id", ExpectedValue: "cr-0123456789abcdef0", ActualValue: "cr-0fedcba9876543210"},
+		{Attribute: "affinity", ExpectedValue: "host", ActualValue: "default"},
+	}, reports[0].Drifts)
+
+	// The instance fell out of its reservation
+	live.CapacityReservationID = ""
+	live.Affinity = "host"
+	reports = driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs)
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "capacity_reservation_id", ExpectedValue: "cr-0123456789abcdef0", ActualValue: ""},
+	}, reports[0].Drifts)
+
+	// Neither side in a reservation nor on a dedicated host
+	desired.CapacityReservationID, desired.Affinity = "", ""
+	live.CapacityReservationID, live.Affinity = "", ""
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs))
+}
+
+func TestDetectRootDeviceTypeDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.RootDeviceType = "ebs"
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.RootDeviceType = "instance-store"
+
+	attrs := []string{"root_device_type"}
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs)
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "root_device_type", ExpectedValue: "ebs", ActualValue: "instance-store"},
+	}, reports[0].Drifts)
+
+	live.RootDeviceType = "ebs"
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs))
+
+	// The desired config does not set the root device type
+	desired.RootDeviceType = ""
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs))
+}
+
+func TestDetectInstanceStateDrift(t *testing.T) {
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	attrs := []string{"ami", "instance_state"}
+	detect := func(opts ...driftchecker.DetectOption) []driftchecker.DriftReport {
+		return driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, attrs, opts...)
+	}
+
+	t.Run("stopped instance drifts from the implied running state", func(t *testing.T) {
+		live.InstanceState = "stopped"
+		reports := detect()
+		require.Len(t, reports, 1)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "instance_state", ExpectedValue: "running", ActualValue: "stopped"},
+		}, reports[0].Drifts)
+	})
+
+	t.Run("running instance does not drift", func(t *testing.T) {
+		live.InstanceState = "running"
+		assert.Empty(t, detect())
+	})
+
+	t.Run("expected state is configurable", func(t *testing.T) {
+		live.InstanceState = "stopped"
+		assert.Empty(t, detect(driftchecker.WithExpectedState("stopped")))
+
+		live.InstanceState = "running"
+		reports := detect(driftchecker.WithExpectedState("stopped"))
+		require.Len(t, reports, 1)
+		assert.Equal(t, "running", reports[0].Drifts[0].ActualValue)
+	})
+
+	t.Run("desired instance state wins", func(t *testing.T) {
+		desired.InstanceState = "stopped"
+		defer func() { desired.InstanceState = "" }()
+		live.InstanceState = "terminated"
+		reports := detect(driftchecker.WithExpectedState("running"))
+		require.Len(t, reports, 1)
+		assert.Equal(t, []driftchecker.DriftDetail{
+			{Attribute: "instance_state", ExpectedValue: "stopped", ActualValue: "terminated"},
+		}, reports[0].Drifts)
+	})
+
+	t.Run("unknown live state is not compared", func(t *testing.T) {
+		live.InstanceState = ""
+		assert.Empty(t, detect())
+	})
+}
+
+func TestParseInstanceState(t *testing.T) {
+	state, err := driftchecker.ParseInstanceState(" Stopped ")
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", state)
+
+	_, err = driftchecker.ParseInstanceState("hibernated")
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedInstanceState{})
+	assert.EqualError(t, err,
+		`unsupported instance state "hibernated" (supported: pending, running, stopping, stopped, shutting-down, terminated)`)
+}
+
+func TestDetectDisableApiTerminationDrift(t *testing.T) {
+	yes, no := true, false
+	desired := createInstance("app1", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	desired.DisableApiTermination = &yes
+	live := createInstance("app1", "i-123", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	live.DisableApiTermination = &no
+
+	reports := driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"disable_api_termination"})
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "disable_api_termination", ExpectedValue: true, ActualValue: false},
+	}, reports[0].Drifts)
+
+	live.DisableApiTermination = &yes
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"disable_api_termination"}))
+
+	// The desired config does not set termination protection
+	desired.DisableApiTermination = nil
+	live.DisableApiTermination = &no
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{desired}, []cloud.Instance{live}, []string{"disable_api_termination"}))
+}
+
+func TestUnmatched(t *testing.T) {
+	named := createInstance("web", "web", "ami-111", "t2.micro", nil, nil, 100, "gp2")
+	unnamed := cloud.Instance{InstanceID: "worker", AMI: "ami-222", Tags: map[string]string{"Env": "prod"}}
+
+	// Detect cannot match a desired instance without a Name tag and skips it
+	assert.Empty(t, driftchecker.Detect(context.Background(), []cloud.Instance{unnamed}, nil, []string{"ami"}))
+
+	reports := driftchecker.Unmatched([]cloud.Instance{named, unnamed})
+	require.Len(t, reports, 1)
+	assert.Equal(t, "worker", reports[0].InstanceID)
+	assert.Equal(t, []driftchecker.DriftDetail{
+		{Attribute: "instance_missing", ExpectedValue: unnamed, ActualValue: nil},
+	}, reports[0].Drifts)
+	assert.True(t, driftchecker.HasDrift(reports))
+	assert.Equal(t, reports, driftchecker.Filter(reports, []string{driftchecker.CategoryMissing}))
+	assert.Empty(t, driftchecker.Filter(reports, []string{driftchecker.CategoryChanged}))
+}
+
+func BenchmarkDetectNoDriftFleet(b *testing.B) {
+	const fleet = 10000
+	desired := make([]cloud.Instance, fleet)
+	live := make([]cloud.Instance, fleet)
+	for i := range desired {
+		name := fmt.Sprintf("app%d", i)
+		desired[i] = createInstance(name, name, "ami-111", "t2.micro", []string{"sg-1"}, nil, 100, "gp2")
+		live[i] = createInstance(name, fmt.Sprintf("i-%d", i), "ami-111", "t2.micro", []string{"sg-1"}, nil, 100, "gp2")
+	}
+	attributes := []string{"ami", "instance_type", "security_groups"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if reports := driftchecker.Detect(context.Background(), desired, live, attributes); len(reports) != 0 {
+			b.Fatalf("expected no drift, got %d reports", len(reports))
+		}
+	}
+}
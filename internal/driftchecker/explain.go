@@ -0,0 +1,59 @@
+package driftchecker
+
+import "github.com/oldmonad/ec2Drift/pkg/cloud"
+
+// Reasons reported by Explain for each live instance.
+const (
+	ReasonMatched        = "matched"          // A desired instance has the same Name tag
+	ReasonNoMatchingName = "no_matching_name" // No desired instance has the same Name tag
+	ReasonMissingNameTag = "missing_name_tag" // The live instance has no Name tag and is never compared
+)
+
+// MatchExplanation describes how Detect pairs a live instance with the
+// desired configuration.
+type MatchExplanation struct {
+	InstanceID string `json:"instance_id"`
+	Provider   string `json:"provider,omitempty"`
+	Name       string `json:"name"`      // Value of the Name tag used as the match key
+	MatchKey   string `json:"match_key"` // Key instances are matched by
+	Matched    bool   `json:"matched"`
+	DesiredID  string `json:"desired_id,omitempty"` // Instance ID of the matched desired instance
+	Reason     string `json:"reason"`
+}
+
+// Explain reports, for every live instance, whether Detect would pair it
+// with a desired instance. Instances are matched on the exact value of their
+// Name tag, so instances without one are silently left out of Detect.
+func Explain(desired, live []cloud.Instance) []MatchExplanation {
+	desiredByName := make(map[string]cloud.Instance, len(desired))
+	for _, inst := range desired {
+		if name, ok := inst.Tags["Name"]; ok {
+			desiredByName[name] = inst
+		}
+	}
+
+	explanations := make([]MatchExplanation, 0, len(live))
+	for _, inst := range live {
+		e := MatchExplanation{
+			InstanceID: inst.InstanceID,
+			Provider:   inst.Provider,
+			MatchKey:   "tags.Name",
+		}
+
+		name, ok := inst.Tags["Name"]
+		if !ok {
+			e.Reason = ReasonMissingNameTag
+		} else if d, found := desiredByName[name]; found {
+			e.Name = name
+			e.Matched = true
+			e.DesiredID = d.InstanceID
+			e.Reason = ReasonMatched
+		} else {
+			e.Name = name
+			e.Reason = ReasonNoMatchingName
+		}
+
+		explanations = append(explanations, e)
+	}
+	return explanations
+}
@@ -0,0 +1,95 @@
+package driftchecker
+
+import "strings"
+
+// Drift categories that can be used to narrow down reports with Filter.
+const (
+	CategoryAdded     = "added"     // Instances reported with instance_added
+	CategoryRemoved   = "removed"   // Instances reported with instance_removed
+	CategoryChanged   = "changed"   // Attribute-level drift on matched instances
+	CategoryUnmanaged = "unmanaged" // Instances reported with instance_unmanaged
+	CategoryMissing   = "missing"   // Desired instances reported with instance_missing
+)
+
+// Categories returns the drift categories accepted by Filter.
+func Categories() []string {
+	return []string{CategoryAdded, CategoryRemoved, CategoryChanged, CategoryUnmanaged, CategoryMissing}
+}
+
+// Filter prunes drift reports so that only drift details matching at least one
+// of the provided selectors remain. A selector is either a drift category
+// (added, removed, changed, unmanaged, missing) or an attribute name such as "ami" or "tags".
+// Attribute selectors also match nested attributes, so "tags" keeps "tags.Env".
+// Reports left without any drift details are dropped, except clean reports
+// with StatusOK, which have none to select. An empty selector list returns
+// the reports unchanged.
+func Filter(reports []DriftReport, only []string) []DriftReport {
+	if len(only) == 0 {
+		return reports
+	}
+
+	filtered := make([]DriftReport, 0, len(reports))
+	for _, report := range reports {
+		if report.Clean() {
+			filtered = append(filtered, report)
+			continue
+		}
+
+		drifts := make([]DriftDetail, 0, len(report.Drifts))
+		for _, drift := range report.Drifts {
+			if matchesAny(drift, only) {
+				drifts = append(drifts, drift)
+			}
+		}
+
+		if len(drifts) > 0 {
+			report.Drifts = drifts
+			filtered = append(filtered, report)
+		}
+	}
+
+	return filtered
+}
+
+// matchesAny reports whether a drift detail matches any of the selectors.
+func matchesAny(drift DriftDetail, selectors []string) bool {
+	for _, selector := range selectors {
+		switch selector {
+		case CategoryAdded:
+			if drift.Attribute == "instance_added" {
+				return true
+			}
+		case CategoryRemoved:
+			if drift.Attribute == "instance_removed" {
+				return true
+			}
+		case CategoryChanged:
+			if !isInstanceLevel(drift.Attribute) {
+				return true
+			}
+		case CategoryUnmanaged:
+			if drift.Attribute == "instance_unmanaged" {
+				return true
+			}
+		case CategoryMissing:
+			if drift.Attribute == "instance_missing" {
+				return true
+			}
+		default:
+			if drift.Attribute == selector || strings.HasPrefix(drift.Attribute, selector+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isInstanceLevel reports whether an attribute describes a whole instance
+// rather than a single attribute of a matched instance.
+func isInstanceLevel(attribute string) bool {
+	switch attribute {
+	case "instance_added", "instance_removed", "instance_unmanaged", "instance_missing":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package driftchecker
+
+import "github.com/oldmonad/ec2Drift/pkg/cloud"
+
+// SelectInstances returns the live instances whose ID is in include, or all
+// of them when include is empty, minus those whose ID is in exclude.
+// Include is applied first, so an ID in both lists is excluded.
+func SelectInstances(instances []cloud.Instance, include, exclude []string) []cloud.Instance {
+	included := toSet(include)
+	excluded := toSet(exclude)
+
+	selected := make([]cloud.Instance, 0, len(instances))
+	for _, inst := range instances {
+		if len(included) > 0 && !included[inst.InstanceID] {
+			continue
+		}
+		if excluded[inst.InstanceID] {
+			continue
+		}
+		selected = append(selected, inst)
+	}
+	return selected
+}
+
+// WithInstances limits detection to the live instances SelectInstances
+// keeps and the desired instances matched to them
+func WithInstances(include, exclude []string) DetectOption {
+	return func(o *detectOptions) {
+		o.include = include
+		o.exclude = exclude
+	}
+}
+
+// SelectNetwork returns the instances in the given VPC and subnet. An empty
+// ID matches every instance, a set one excludes instances that do not
+// record their VPC or subnet.
+func SelectNetwork(instances []cloud.Instance, vpcID, subnetID string) []cloud.Instance {
+	if vpcID == "" && subnetID == "" {
+		return instances
+	}
+
+	selected := make([]cloud.Instance, 0, len(instances))
+	for _, inst := range instances {
+		if inNetwork(inst, vpcID, subnetID) {
+			selected = append(selected, inst)
+		}
+	}
+	return selected
+}
+
+// WithNetwork limits detection to the live instances in the given VPC and
+// subnet, the desired instances matched to them and the unmatched desired
+// instances that declare that VPC and subnet
+func WithNetwork(vpcID, subnetID string) DetectOption {
+	return func(o *detectOptions) {
+		o.vpcID = vpcID
+		o.subnetID = subnetID
+	}
+}
+
+func inNetwork(inst cloud.Instance, vpcID, subnetID string) bool {
+	return (vpcID == "" || inst.VpcID == vpcID) && (subnetID == "" || inst.SubnetID == subnetID)
+}
+
+// restrictInstances applies the instance and network selection to both
+// states. Desired instances matched to a dropped live instance are dropped
+// with it, and with an include list so are those matching no selected live
+// instance, otherwise every other desired instance would be reported as
+// removed. With a network selection unmatched desired instances are only
+// kept when they declare the selected VPC and subnet.
+func restrictInstances(desired, live []cloud.Instance, options detectOptions) ([]cloud.Instance, []cloud.Instance) {
+	network := options.vpcID != "" || options.subnetID != ""
+	if len(options.include) == 0 && len(options.exclude) == 0 && !network {
+		return desired, live
+	}
+
+	selected := SelectInstances(live, options.include, options.exclude)
+	selected = SelectNetwork(selected, options.vpcID, options.subnetID)
+	selectedNames := make(map[string]bool, len(selected))
+	for _, inst := range selected {
+		if name, ok := inst.Tags["Name"]; ok {
+			selectedNames[name] = true
+		}
+	}
+	liveNames := make(map[string]bool, len(live))
+	for _, inst := range live {
+		if name, ok := inst.Tags["Name"]; ok {
+			liveNames[name] = true
+		}
+	}
+
+	kept := make([]cloud.Instance, 0, len(desired))
+	for _, inst := range desired {
+		name, ok := inst.Tags["Name"]
+		switch {
+		case ok && selectedNames[name]:
+			kept = append(kept, inst)
+		case len(options.include) > 0:
+			// Only the matches of the included instances are considered
+		case ok && liveNames[name]:
+			// Matched to an excluded live instance or one outside the network
+		case network && !inNetwork(inst, options.vpcID, options.subnetID):
+			// Unmatched and not declared in the selected network
+		default:
+			kept = append(kept, inst)
+		}
+	}
+	return kept, selected
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
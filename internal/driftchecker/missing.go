@@ -0,0 +1,26 @@
+package driftchecker
+
+import "github.com/oldmonad/ec2Drift/pkg/cloud"
+
+// Unmatched reports the desired instances that can never be matched to a
+// live instance because they have no Name tag, each as instance_missing.
+// Detect skips them silently; strict matching turns them into drift.
+func Unmatched(desired []cloud.Instance) []DriftReport {
+	var reports []DriftReport
+	for _, inst := range desired {
+		if _, ok := inst.Tags["Name"]; ok {
+			continue
+		}
+		reports = append(reports, DriftReport{
+			InstanceID: inst.InstanceID,
+			Provider:   inst.Provider,
+			Managed:    true,
+			Drifts: []DriftDetail{{
+				Attribute:     "instance_missing",
+				ExpectedValue: inst,
+				ActualValue:   nil,
+			}},
+		})
+	}
+	return reports
+}
@@ -0,0 +1,99 @@
+package driftchecker
+
+import (
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+)
+
+// AttributeSelector returns the attributes to compare for an instance of
+// the desired state
+type AttributeSelector func(desired cloud.Instance) []string
+
+// PopulatedAttributes selects, for each desired instance, only those of the
+// given attributes that the instance actually sets. A sparse config that
+// only pins the AMI is then never reported for its unset fields.
+func PopulatedAttributes(attributes []string) AttributeSelector {
+	return func(desired cloud.Instance) []string {
+		selected := make([]string, 0, len(attributes))
+		for _, attr := range attributes {
+			if isPopulated(desired, attr) {
+				selected = append(selected, attr)
+			}
+		}
+		return selected
+	}
+}
+
+// isPopulated reports whether the instance sets a value for the attribute
+func isPopulated(inst cloud.Instance, attr string) bool {
+	parts := strings.Split(attr, ".")
+	switch parts[0] {
+	case "ami":
+		return inst.AMI != ""
+	case "instance_type":
+		return inst.InstanceType != ""
+	case "private_ip":
+		return inst.PrivateIP != ""
+	case "public_ip":
+		return inst.PublicIP != ""
+	case "metadata_options":
+		return inst.MetadataHttpTokens != ""
+	case "disable_api_termination":
+		return inst.DisableApiTermination != nil
+	case "associate_public_ip_address":
+		return inst.AssociatePublicIP != nil
+	case "user_data":
+		return inst.UserDataHash != ""
+	case "monitoring":
+		return inst.MonitoringState != ""
+	case "instance_lifecycle":
+		return inst.InstanceLifecycle != ""
+	case "host_id":
+		return inst.HostID != ""
+	case "capacity_reservation_id":
+		return inst.CapacityReservationID != ""
+	case "affinity":
+		return inst.Affinity != ""
+	case "root_device_type":
+		return inst.RootDeviceType != ""
+	case "instance_state":
+		// Every desired instance is expected in a state, running by default
+		return true
+	case "security_groups":
+		return len(inst.SecurityGroups) > 0
+	case "tags":
+		if len(parts) > 1 {
+			_, ok := inst.Tags[parts[1]]
+			return ok
+		}
+		// The Name tag only identifies the instance and is never compared
+		for k := range inst.Tags {
+			if k != "Name" {
+				return true
+			}
+		}
+		return false
+	case "root_block_device":
+		if len(parts) == 1 {
+			for _, field := range rootBlockDeviceFields {
+				if isPopulated(inst, "root_block_device."+field) {
+					return true
+				}
+			}
+			return false
+		}
+		rbd := inst.RootBlockDevice
+		switch parts[1] {
+		case "volume_size":
+			return rbd.VolumeSize != 0
+		case "volume_type":
+			return rbd.VolumeType != ""
+		case "encrypted":
+			return rbd.Encrypted != nil
+		case "delete_on_termination":
+			return rbd.DeleteOnTermination != nil
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package driftchecker
+
+import (
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Severity ranks how important drift of an attribute is
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// DefaultSeverity applies to drift of attributes without a configured
+// severity when gating on a severity threshold
+const DefaultSeverity = SeverityWarning
+
+// severityRanks orders the severities from least to most important
+var severityRanks = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// Severities returns the accepted severity names, least important first
+func Severities() []string {
+	return []string{string(SeverityInfo), string(SeverityWarning), string(SeverityCritical)}
+}
+
+// ParseSeverity validates a severity name, ignoring case and whitespace
+func ParseSeverity(raw string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(raw)))
+	if _, ok := severityRanks[severity]; !ok {
+		return "", errors.NewUnsupportedSeverity(raw, Severities())
+	}
+	return severity, nil
+}
+
+// ParseSeverityMap parses comma separated attribute=severity entries such
+// as "ami=critical,tags=info". Keys are attribute names, nested attributes
+// such as tags.Env, or drift categories such as removed.
+func ParseSeverityMap(raw string) (map[string]Severity, error) {
+	severities := make(map[string]Severity)
+	for _, entry := range strings.Split(raw, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		attr, level, ok := strings.Cut(entry, "=")
+		attr = strings.TrimSpace(attr)
+		if !ok || attr == "" {
+			return nil, errors.NewErrInvalidSeverityMapping(strings.TrimSpace(entry))
+		}
+		severity, err := ParseSeverity(level)
+		if err != nil {
+			return nil, err
+		}
+		severities[attr] = severity
+	}
+	return severities, nil
+}
+
+// AssignSeverity sets the severity of every drift detail from the map. The
+// most specific key wins: the attribute itself (tags.Env), then its parents
+// (tags), then its drift category (changed). Unmatched details are left
+// without a severity.
+func AssignSeverity(reports []DriftReport, severities map[string]Severity) []DriftReport {
+	if len(severities) == 0 {
+		return reports
+	}
+
+	assigned := make([]DriftReport, 0, len(reports))
+	for _, report := range reports {
+		drifts := make([]DriftDetail, len(report.Drifts))
+		for i, drift := range report.Drifts {
+			drift.Severity = lookupSeverity(drift.Attribute, severities)
+			drifts[i] = drift
+		}
+		report.Drifts = drifts
+		assigned = append(assigned, report)
+	}
+	return assigned
+}
+
+// lookupSeverity finds the severity configured for an attribute
+func lookupSeverity(attribute string, severities map[string]Severity) Severity {
+	for key := attribute; key != ""; {
+		if severity, ok := severities[key]; ok {
+			return severity
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return severities[category(attribute)]
+}
+
+// category returns the drift category an attribute belongs to
+func category(attribute string) string {
+	switch attribute {
+	case "instance_added":
+		return CategoryAdded
+	case "instance_removed":
+		return CategoryRemoved
+	case "instance_unmanaged":
+		return CategoryUnmanaged
+	case "instance_missing":
+		return CategoryMissing
+	}
+	return CategoryChanged
+}
+
+// HasDriftAtLeast works like HasDrift but only counts drift whose severity
+// is at least min. Drift without a severity counts as DefaultSeverity.
+func HasDriftAtLeast(reports []DriftReport, min Severity) bool {
+	for _, report := range reports {
+		for _, drift := range report.Drifts {
+			if drift.Attribute == "instance_unmanaged" {
+				continue
+			}
+			severity := drift.Severity
+			if severity == "" {
+				severity = DefaultSeverity
+			}
+			if severityRanks[severity] >= severityRanks[min] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package driftchecker
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// DefaultInstanceState is the state a desired config implies: instances it
+// declares should exist and run
+const DefaultInstanceState = "running"
+
+// InstanceStates returns the EC2 instance states an instance can be
+// expected in
+func InstanceStates() []string {
+	return []string{"pending", "running", "stopping", "stopped", "shutting-down", "terminated"}
+}
+
+// ParseInstanceState validates an instance state name, ignoring case and
+// whitespace
+func ParseInstanceState(raw string) (string, error) {
+	state := strings.ToLower(strings.TrimSpace(raw))
+	if !slices.Contains(InstanceStates(), state) {
+		return "", errors.NewUnsupportedInstanceState(raw, InstanceStates())
+	}
+	return state, nil
+}
+
+// instanceStateDrift compares the live state with the state of the desired
+// instance, or expected when it sets none. Providers that do not report a
+// state never drift.
+func instanceStateDrift(cmp ComparatorOptions, expected string, o, c cloud.Instance) (DriftDetail, bool) {
+	if c.InstanceState == "" {
+		return DriftDetail{}, false
+	}
+	if o.InstanceState != "" {
+		expected = o.InstanceState
+	}
+	if cmp.equalValues("instance_state", expected, c.InstanceState) {
+		return DriftDetail{}, false
+	}
+	return DriftDetail{Attribute: "instance_state", ExpectedValue: expected, ActualValue: c.InstanceState}, true
+}
@@ -0,0 +1,34 @@
+package driftchecker
+
+// MarkUnmanaged reclassifies instances reported as instance_added, i.e. live
+// instances that are missing from the desired configuration, as
+// instance_unmanaged. Unmanaged instances are reported but do not count as
+// drift, see HasDrift.
+func MarkUnmanaged(reports []DriftReport) []DriftReport {
+	marked := make([]DriftReport, 0, len(reports))
+	for _, report := range reports {
+		drifts := make([]DriftDetail, len(report.Drifts))
+		for i, drift := range report.Drifts {
+			if drift.Attribute == "instance_added" {
+				drift.Attribute = "instance_unmanaged"
+			}
+			drifts[i] = drift
+		}
+		report.Drifts = drifts
+		marked = append(marked, report)
+	}
+	return marked
+}
+
+// HasDrift reports whether any of the reports contains drift that should
+// affect the drift verdict. Unmanaged instances are informational only.
+func HasDrift(reports []DriftReport) bool {
+	for _, report := range reports {
+		for _, drift := range report.Drifts {
+			if drift.Attribute != "instance_unmanaged" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// Package exitcode maps the outcome of a command to the process exit code,
+// so scripts and CI jobs can tell drift apart from failures.
+package exitcode
+
+import (
+	"context"
+	"errors"
+
+	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Code is a process exit code
+type Code int
+
+const (
+	OK      Code = 0 // No drift, or only drift below --fail-on-severity
+	Error   Code = 1 // Any failure without a code of its own, e.g. a cloud API error
+	Drift   Code = 2 // Drift was detected
+	NoMatch Code = 3 // The instance selection matched no live instance
+	Timeout Code = 4 // The run exceeded --timeout
+	Config  Code = 5 // Invalid settings, flags or desired config
+)
+
+// configErrors are the errors of invalid settings, flags or desired config
+// files. Each entry is a pointer to a zero value for errors.As.
+var configErrors = []any{
+	// Settings read from .env and the environment
+	&cerrors.ErrEnvLoad{},
+	&cerrors.ErrConfigSetup{},
+	&cerrors.ErrLoadGeneralConfig{},
+	&cerrors.ErrLoadCloudConfig{},
+	&cerrors.ErrInvalidConfigurations{},
+	&cerrors.ErrMissingPaths{},
+	&cerrors.ErrConflictingConfigSources{},
+	&cerrors.ErrMissingCloudProvider{},
+	&cerrors.ErrUnsupportedProvider{},
+	&cerrors.ErrProviderConfigMismatch{},
+	&cerrors.ErrCloudConfigNotInit{},
+	&cerrors.ErrMissingCredentials{},
+	&cerrors.ErrSecretFile{},
+	&cerrors.ErrMissingGCPConfig{},
+	&cerrors.ErrAWSConfigValidation{},
+	&cerrors.ErrGCPConfigValidation{},
+	&cerrors.InvalidConfigCredential{},
+	&cerrors.ErrInvalidAccessKeyFormat{},
+	&cerrors.ErrDebugParse{},
+	&cerrors.ErrPortParse{},
+	&cerrors.ErrPortOutOfRange{},
+	&cerrors.ErrTimeoutParse{},
+	&cerrors.ErrMaxReportsParse{},
+	&cerrors.ErrInvalidSchedule{},
+	&cerrors.ErrInvalidSeverityMapping{},
+	&cerrors.ErrInvalidComparator{},
+	&cerrors.ErrCredentialsExpiringSoon{},
+
+	// Command line flags
+	&cerrors.ErrFormatValidation{},
+	&cerrors.ErrAttributeValidation{},
+	new(*cerrors.InvalidAttributesError), // Returned as a pointer
+	&cerrors.ErrNoAttributesSelected{},
+	&cerrors.ErrUnknownPreset{},
+	&cerrors.ErrUnsupportedOutputFormat{},
+	&cerrors.ErrUnsupportedColorMode{},
+	&cerrors.ErrUnsupportedGroupBy{},
+	&cerrors.ErrOutputDirNeedsGroupBy{},
+	&cerrors.ErrUnsupportedSeverity{},
+	&cerrors.ErrUnsupportedInstanceState{},
+	&cerrors.ErrPageSizeOutOfRange{},
+	&cerrors.ErrInvalidTemplate{},
+	&cerrors.ErrTemplateFile{},
+
+	// Desired config files
+	&cerrors.ErrReadFile{},
+	&cerrors.ErrGitSource{},
+	&cerrors.ErrEmptyStateFile{},
+	&cerrors.ErrNoConfigFiles{},
+	&cerrors.ErrDuplicateInstanceName{},
+	&cerrors.ErrConfigParse{},
+	&cerrors.ErrUndefinedEnvVars{},
+	&cerrors.ErrUnknownParser{},
+}
+
+// FromError returns the exit code of a command that returned err
+func FromError(err error) Code {
+	switch {
+	case err == nil:
+		return OK
+	case errors.As(err, &cerrors.ErrDriftDetected{}):
+		return Drift
+	case errors.As(err, &cerrors.ErrNoMatchingInstances{}):
+		return NoMatch
+	case errors.As(err, &cerrors.ErrRunTimeout{}), errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	}
+	for _, target := range configErrors {
+		if errors.As(err, target) {
+			return Config
+		}
+	}
+	return Error
+}
@@ -0,0 +1,43 @@
+package exitcode_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/exitcode"
+	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected exitcode.Code
+	}{
+		{name: "no error", err: nil, expected: exitcode.OK},
+		{name: "drift", err: cerrors.NewDriftDetected(), expected: exitcode.Drift},
+		{name: "wrapped drift", err: fmt.Errorf("compare: %w", cerrors.ErrDriftDetected{}), expected: exitcode.Drift},
+		{name: "no matching instances", err: cerrors.NewErrNoMatchingInstances(), expected: exitcode.NoMatch},
+		{name: "run timeout", err: cerrors.NewErrRunTimeout(time.Minute, context.DeadlineExceeded), expected: exitcode.Timeout},
+		{name: "deadline exceeded", err: fmt.Errorf("fetch: %w", context.DeadlineExceeded), expected: exitcode.Timeout},
+		{name: "config setup", err: cerrors.NewErrConfigSetup(cerrors.NewErrMissingPaths()), expected: exitcode.Config},
+		{name: "env load", err: cerrors.NewErrEnvLoad(".env", errors.New("bad line")), expected: exitcode.Config},
+		{name: "invalid port", err: cerrors.NewErrPortOutOfRange("--port", 0), expected: exitcode.Config},
+		{name: "invalid attributes", err: &cerrors.InvalidAttributesError{InvalidAttrs: []string{"colour"}}, expected: exitcode.Config},
+		{name: "unsupported output format", err: cerrors.NewUnsupportedOutputFormat("xml", []string{"json"}), expected: exitcode.Config},
+		{name: "unreadable state file", err: cerrors.NewReadFileError(errors.New("permission denied")), expected: exitcode.Config},
+		{name: "unparseable config", err: cerrors.NewErrConfigParse("json", errors.New("unexpected EOF")), expected: exitcode.Config},
+		{name: "cloud API error", err: cerrors.NewDescribeInstances(errors.New("ExpiredToken")), expected: exitcode.Error},
+		{name: "unknown error", err: errors.New("boom"), expected: exitcode.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, exitcode.FromError(tt.err))
+		})
+	}
+}
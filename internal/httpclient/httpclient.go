@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Transport tuning shared by every outbound HTTP call. Idle connections are
+// kept per host so concurrent cloud calls reuse them instead of dialing anew.
+const (
+	MaxIdleConns          = 100
+	MaxIdleConnsPerHost   = 20
+	IdleConnTimeout       = 90 * time.Second
+	DialTimeout           = 10 * time.Second
+	TLSHandshakeTimeout   = 10 * time.Second
+	ResponseHeaderTimeout = 30 * time.Second
+)
+
+// New builds an HTTP client with the tuned transport. Request deadlines are
+// left to the caller's context so long paginated calls are not cut short.
+func New() *http.Client {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		ExpectContinueTimeout: time.Second,
+	}
+	ConfigureTransport(transport)
+	return &http.Client{Transport: transport}
+}
+
+// ConfigureTransport applies the pool and timeout tuning to a transport.
+// It is exposed for SDKs that build their own transport, such as the AWS
+// SDK when a custom CA bundle is configured.
+func ConfigureTransport(transport *http.Transport) {
+	transport.DialContext = (&net.Dialer{
+		Timeout:   DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.MaxIdleConns = MaxIdleConns
+	transport.MaxIdleConnsPerHost = MaxIdleConnsPerHost
+	transport.IdleConnTimeout = IdleConnTimeout
+	transport.TLSHandshakeTimeout = TLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = ResponseHeaderTimeout
+}
@@ -0,0 +1,23 @@
+package httpclient_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	client := httpclient.New()
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, httpclient.MaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, httpclient.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, httpclient.IdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, httpclient.TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	assert.Equal(t, httpclient.ResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	assert.NotSame(t, client, httpclient.New())
+}
@@ -0,0 +1,90 @@
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Metadata describes where and when a baseline was taken
+type Metadata struct {
+	CreatedAt     time.Time `json:"created_at"`
+	Provider      string    `json:"provider"`
+	Region        string    `json:"region,omitempty"`
+	Account       string    `json:"account,omitempty"` // AWS account or GCP project, empty when unknown
+	InstanceCount int       `json:"instance_count"`
+	Checksum      string    `json:"checksum"` // "sha256:" and the hex digest of the JSON encoded instances
+}
+
+// Document is a baseline file: the metadata header and the instances. The
+// JSON parser reads it like a plain list of instances.
+type Document struct {
+	Metadata  Metadata         `json:"metadata"`
+	Instances []cloud.Instance `json:"instances"`
+}
+
+// New builds the document of the instances, filling in the instance count
+// and checksum of meta
+func New(instances []cloud.Instance, meta Metadata) (Document, error) {
+	if instances == nil {
+		instances = []cloud.Instance{}
+	}
+	sum, err := Checksum(instances)
+	if err != nil {
+		return Document{}, err
+	}
+	meta.InstanceCount = len(instances)
+	meta.Checksum = sum
+	return Document{Metadata: meta, Instances: instances}, nil
+}
+
+// Checksum returns the SHA-256 of the JSON encoded instances, so tools can
+// tell a baseline was edited after it was written
+func Checksum(instances []cloud.Instance) (string, error) {
+	data, err := json.Marshal(instances)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// WriteFile replaces the file at path with the document. It writes a
+// temporary file next to it and renames it into place, so readers see
+// either the old baseline or the new one, never a partial file.
+func WriteFile(path string, doc Document) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+	// A no-op once the rename succeeded
+	defer os.Remove(tmp.Name())
+
+	encoder := json.NewEncoder(tmp)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		tmp.Close()
+		return errors.NewWriteOutputError(path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.NewWriteOutputError(path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+	// CreateTemp makes the file readable by its owner only
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+	return nil
+}
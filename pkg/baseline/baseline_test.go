@@ -0,0 +1,103 @@
+package baseline_test
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/baseline"
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	instances := []cloud.Instance{{InstanceID: "i-123", AMI: "ami-123"}}
+	created := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	doc, err := baseline.New(instances, baseline.Metadata{CreatedAt: created, Provider: "aws", Region: "eu-west-1", Account: "123456789012"})
+	require.NoError(t, err)
+
+	sum, err := baseline.Checksum(instances)
+	require.NoError(t, err)
+	assert.Equal(t, baseline.Metadata{
+		CreatedAt:     created,
+		Provider:      "aws",
+		Region:        "eu-west-1",
+		Account:       "123456789012",
+		InstanceCount: 1,
+		Checksum:      sum,
+	}, doc.Metadata)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, sum)
+
+	// Any change to the instances changes the checksum
+	instances[0].AMI = "ami-456"
+	changed, err := baseline.Checksum(instances)
+	require.NoError(t, err)
+	assert.NotEqual(t, sum, changed)
+}
+
+func TestWriteFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+
+	old, err := baseline.New([]cloud.Instance{{InstanceID: "i-old"}}, baseline.Metadata{Provider: "aws"})
+	require.NoError(t, err)
+	require.NoError(t, baseline.WriteFile(path, old))
+	oldData, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// A reader that opened the baseline before the refresh keeps the whole
+	// old file, as the new one is renamed into place rather than rewritten
+	reader, err := os.Open(path)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	refreshed, err := baseline.New([]cloud.Instance{{InstanceID: "i-new-1"}, {InstanceID: "i-new-2"}},
+		baseline.Metadata{CreatedAt: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), Provider: "aws", Region: "us-east-1"})
+	require.NoError(t, err)
+	require.NoError(t, baseline.WriteFile(path, refreshed))
+
+	seen, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, oldData, seen)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc baseline.Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, refreshed, doc)
+	assert.Equal(t, 2, doc.Metadata.InstanceCount)
+	assert.Equal(t, "us-east-1", doc.Metadata.Region)
+	assert.NotEmpty(t, doc.Metadata.Checksum)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	// No temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "baseline.json", entries[0].Name())
+}
+
+func TestWriteFileCleansUpOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	// A directory in the way makes the final rename fail
+	path := filepath.Join(dir, "baseline.json")
+	require.NoError(t, os.Mkdir(path, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "keep"), []byte("[]"), 0o644))
+
+	err := baseline.WriteFile(path, baseline.Document{})
+	assert.ErrorAs(t, err, &errors.ErrWriteOutput{})
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the temporary file is removed")
+	assert.FileExists(t, filepath.Join(path, "keep"))
+}
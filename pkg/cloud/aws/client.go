@@ -2,25 +2,42 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
+	stderrors "errors"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsPkgConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/oldmonad/ec2Drift/internal/httpclient"
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
 	config "github.com/oldmonad/ec2Drift/pkg/config/cloud"
 	awsConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
 	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type EC2Client interface {
 	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
 	DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+	DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error)
 }
 
+// ClientFactory builds an EC2 client for the given region
+type ClientFactory func(ctx context.Context, cfg *awsConfig.Config, region string) (EC2Client, error)
+
+// AWSProvider fetches EC2 instances, keeping one client per region so that
+// configs for different regions never share a client
 type AWSProvider struct {
-	EC2Client EC2Client
+	newClient ClientFactory
+	clients   map[string]EC2Client
+	mu        sync.Mutex
 }
 
 func NewAWSProvider() *AWSProvider {
@@ -28,12 +45,32 @@ func NewAWSProvider() *AWSProvider {
 }
 
 type EC2Instance struct {
-	InstanceID      string
-	AMI             string
-	InstanceType    string
-	SecurityGroups  []string
-	Tags            map[string]string
-	RootBlockDevice *BlockDevice
+	InstanceID         string
+	AMI                string
+	InstanceType       string
+	SecurityGroups     []string
+	Tags               map[string]string
+	PrivateIP          string
+	PublicIP           string
+	VpcID              string
+	SubnetID           string
+	MetadataHttpTokens string // HttpTokens metadata option: optional or required
+	RootBlockDevice    *BlockDevice
+
+	DisableApiTermination *bool  // Termination protection, nil unless looked up
+	UserDataHash          string // SHA-256 of the decoded user data, only looked up on request
+	InstanceLifecycle     string // spot, scheduled or normal
+	MonitoringState       string // Detailed monitoring: enabled, disabled, pending or disabling
+	HostID                string // Dedicated host, empty on shared tenancy
+	CapacityReservationID string // Capacity reservation, empty outside one
+	Affinity              string // Dedicated host affinity: default or host
+	RootDeviceType        string // ebs or instance-store
+	AssociatePublicIP     bool   // Auto-assigned public IP on the primary interface
+	InstanceState         string // pending, running, stopping, stopped, shutting-down or terminated
+	AccountID             string // Owner of the reservation the instance was launched in
+
+	// Attributes whose lookup failed, see cloud.Instance
+	Unknown []string
 }
 
 type BlockDevice struct {
@@ -41,6 +78,9 @@ type BlockDevice struct {
 	DeviceName string
 	SizeGB     int64
 	VolumeType string
+	Encrypted  bool
+	// Whether the volume is deleted with the instance, from the block device mapping
+	DeleteOnTermination bool
 }
 
 func (p *AWSProvider) FetchInstances(ctx context.Context, providerCfg config.ProviderConfig) ([]cloud.Instance, error) {
@@ -51,57 +91,123 @@ func (p *AWSProvider) FetchInstances(ctx context.Context, providerCfg config.Pro
 		return nil, errors.NewWrongConfigType(providerCfg)
 	}
 
-	if p.EC2Client == nil {
-		awsCfg, err := awsPkgConfig.LoadDefaultConfig(ctx,
-			awsPkgConfig.WithRegion(awsCfgStruct.GetRegion()),
-			awsPkgConfig.WithCredentialsProvider(
-				credentials.NewStaticCredentialsProvider(
-					awsCfgStruct.AccessKey,
-					awsCfgStruct.SecretKey,
-					awsCfgStruct.SessionToken,
-				),
-			),
-		)
-		if err != nil {
-			return nil, errors.NewAWSConfigLoad(err)
-		}
-		p.EC2Client = ec2.NewFromConfig(awsCfg)
+	client, err := p.clientForRegion(ctx, awsCfgStruct)
+	if err != nil {
+		return nil, err
 	}
 
-	paginator := ec2.NewDescribeInstancesPaginator(p.EC2Client, &ec2.DescribeInstancesInput{})
+	input := &ec2.DescribeInstancesInput{Filters: networkFilters(awsCfgStruct)}
+	if awsCfgStruct.PageSize > 0 {
+		input.MaxResults = aws.Int32(awsCfgStruct.PageSize)
+	}
+
+	paginator := ec2.NewDescribeInstancesPaginator(client, input)
 	instances := make([]cloud.Instance, 0)
+	var nextToken *string // Resumes the listing after a credential refresh
+	refreshed := false
 
 	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(ctx)
+		callCtx, cancel := callContext(ctx, awsCfgStruct.CallTimeout)
+		cloud.CountCall(ctx, "DescribeInstances")
+		page, err := paginator.NextPage(callCtx)
+		cancel()
+		if err != nil && !refreshed && awsCfgStruct.RefreshableCredentials() && credentialsExpired(err) {
+			// Long runs can outlive temporary credentials. Their source
+			// hands out new ones once; static keys fail right away.
+			logger.Log.Warn("AWS credentials expired, refreshing them and retrying once",
+				zap.String("profile", awsCfgStruct.Profile), zap.Error(err))
+			refreshed = true
+			if client, err = p.refreshClient(ctx, awsCfgStruct); err != nil {
+				return nil, err
+			}
+			resumed := *input
+			resumed.NextToken = nextToken
+			paginator = ec2.NewDescribeInstancesPaginator(client, &resumed)
+			continue
+		}
 		if err != nil {
 			return nil, errors.NewDescribeInstances(err)
 		}
+		nextToken = page.NextToken
+
+		// Checked before the per-instance lookups of the page are made
+		if limit := awsCfgStruct.MaxInstances; limit > 0 {
+			if count := len(instances) + pageInstanceCount(page); count > limit {
+				return nil, errors.NewErrTooManyInstances(limit, count)
+			}
+		}
 
 		for _, reservation := range page.Reservations {
 			for _, instance := range reservation.Instances {
-				e := mapToEC2Instance(ctx, instance, p.EC2Client)
+				e := mapToEC2Instance(ctx, instance, client, awsCfgStruct.CallTimeout)
+				e.AccountID = aws.ToString(reservation.OwnerId)
+				if awsCfgStruct.FetchTerminationProtection {
+					protected, err := getTerminationProtection(ctx, client, e.InstanceID, awsCfgStruct.CallTimeout)
+					if err != nil {
+						// Unknown rather than read as disabled, which would drift
+						// on every protected instance
+						logger.Log.Warn("Failed to describe termination protection", zap.Error(err))
+						cloud.AddWarning(ctx, e.InstanceID, "disable_api_termination unknown: "+err.Error())
+						e.Unknown = append(e.Unknown, "disable_api_termination")
+					} else {
+						e.DisableApiTermination = aws.Bool(protected)
+					}
+				}
+				if awsCfgStruct.FetchUserData {
+					hash, err := getUserDataHash(ctx, client, e.InstanceID, awsCfgStruct.CallTimeout)
+					if err != nil {
+						logger.Log.Warn("Failed to describe user data", zap.Error(err))
+						cloud.AddWarning(ctx, e.InstanceID, "user_data unknown: "+err.Error())
+						e.Unknown = append(e.Unknown, "user_data")
+					}
+					e.UserDataHash = hash
+				}
 
 				var rbd struct {
-					VolumeSize int    `json:"volume_size"`
-					VolumeType string `json:"volume_type"`
+					VolumeSize          int    `json:"volume_size"`
+					VolumeType          string `json:"volume_type"`
+					Encrypted           *bool  `json:"encrypted,omitempty"`
+					DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 				}
 				if e.RootBlockDevice != nil {
 					rbd = struct {
-						VolumeSize int    `json:"volume_size"`
-						VolumeType string `json:"volume_type"`
+						VolumeSize          int    `json:"volume_size"`
+						VolumeType          string `json:"volume_type"`
+						Encrypted           *bool  `json:"encrypted,omitempty"`
+						DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 					}{
-						VolumeSize: int(e.RootBlockDevice.SizeGB),
-						VolumeType: e.RootBlockDevice.VolumeType,
+						VolumeSize:          int(e.RootBlockDevice.SizeGB),
+						VolumeType:          e.RootBlockDevice.VolumeType,
+						Encrypted:           aws.Bool(e.RootBlockDevice.Encrypted),
+						DeleteOnTermination: aws.Bool(e.RootBlockDevice.DeleteOnTermination),
 					}
 				}
 
 				instances = append(instances, cloud.Instance{
-					InstanceID:      e.InstanceID,
-					AMI:             e.AMI,
-					InstanceType:    e.InstanceType,
-					SecurityGroups:  e.SecurityGroups,
-					Tags:            e.Tags,
-					RootBlockDevice: rbd,
+					InstanceID:         e.InstanceID,
+					AMI:                e.AMI,
+					InstanceType:       e.InstanceType,
+					SecurityGroups:     e.SecurityGroups,
+					Tags:               e.Tags,
+					PrivateIP:          e.PrivateIP,
+					PublicIP:           e.PublicIP,
+					VpcID:              e.VpcID,
+					SubnetID:           e.SubnetID,
+					MetadataHttpTokens: e.MetadataHttpTokens,
+					RootBlockDevice:    rbd,
+
+					DisableApiTermination: e.DisableApiTermination,
+					UserDataHash:          e.UserDataHash,
+					InstanceLifecycle:     e.InstanceLifecycle,
+					MonitoringState:       e.MonitoringState,
+					HostID:                e.HostID,
+					CapacityReservationID: e.CapacityReservationID,
+					Affinity:              e.Affinity,
+					RootDeviceType:        e.RootDeviceType,
+					AssociatePublicIP:     aws.Bool(e.AssociatePublicIP),
+					InstanceState:         e.InstanceState,
+					Unknown:               e.Unknown,
+					AccountID:             e.AccountID,
 				})
 			}
 		}
@@ -110,19 +216,55 @@ func (p *AWSProvider) FetchInstances(ctx context.Context, providerCfg config.Pro
 	return instances, nil
 }
 
-func getVolumeDetails(ctx context.Context, client EC2Client, volumeID string) BlockDevice {
+// pageInstanceCount returns the number of instances on a DescribeInstances page
+func pageInstanceCount(page *ec2.DescribeInstancesOutput) int {
+	count := 0
+	for _, reservation := range page.Reservations {
+		count += len(reservation.Instances)
+	}
+	return count
+}
+
+// networkFilters has DescribeInstances only return the instances in the
+// configured VPC and subnet, nil when neither is set
+func networkFilters(cfg *awsConfig.Config) []types.Filter {
+	var filters []types.Filter
+	if cfg.VpcID != "" {
+		filters = append(filters, types.Filter{Name: aws.String("vpc-id"), Values: []string{cfg.VpcID}})
+	}
+	if cfg.SubnetID != "" {
+		filters = append(filters, types.Filter{Name: aws.String("subnet-id"), Values: []string{cfg.SubnetID}})
+	}
+	return filters
+}
+
+// callContext bounds a single EC2 API call by the given timeout, leaving
+// the context untouched when it is zero
+func callContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// getVolumeDetails looks up the root volume. Failures, including a call
+// timing out, are returned so the caller can flag the instance instead of
+// failing the fetch.
+func getVolumeDetails(ctx context.Context, client EC2Client, volumeID string, callTimeout time.Duration) (BlockDevice, error) {
 	volInput := &ec2.DescribeVolumesInput{
 		VolumeIds: []string{volumeID},
 	}
-	volResult, err := client.DescribeVolumes(ctx, volInput)
+	callCtx, cancel := callContext(ctx, callTimeout)
+	defer cancel()
+
+	cloud.CountCall(ctx, "DescribeVolumes")
+	volResult, err := client.DescribeVolumes(callCtx, volInput)
 	if err != nil {
-		_ = errors.NewDescribeVolumes(volumeID, err)
-		return BlockDevice{VolumeID: volumeID}
+		return BlockDevice{}, errors.NewDescribeVolumes(volumeID, err)
 	}
 
 	if len(volResult.Volumes) == 0 {
-		_ = errors.NewDescribeVolumes(volumeID, nil)
-		return BlockDevice{VolumeID: volumeID}
+		return BlockDevice{}, errors.NewDescribeVolumes(volumeID, nil)
 	}
 
 	var sizeGB int64
@@ -134,16 +276,88 @@ func getVolumeDetails(ctx context.Context, client EC2Client, volumeID string) Bl
 		VolumeID:   volumeID,
 		SizeGB:     sizeGB,
 		VolumeType: string(volResult.Volumes[0].VolumeType),
+		Encrypted:  aws.ToBool(volResult.Volumes[0].Encrypted),
+	}, nil
+}
+
+// getTerminationProtection looks up whether termination protection is
+// enabled
+func getTerminationProtection(ctx context.Context, client EC2Client, instanceID string, callTimeout time.Duration) (bool, error) {
+	callCtx, cancel := callContext(ctx, callTimeout)
+	defer cancel()
+
+	cloud.CountCall(ctx, "DescribeInstanceAttribute")
+	result, err := client.DescribeInstanceAttribute(callCtx, &ec2.DescribeInstanceAttributeInput{
+		InstanceId: aws.String(instanceID),
+		Attribute:  types.InstanceAttributeNameDisableApiTermination,
+	})
+	if err != nil {
+		return false, errors.NewDescribeInstanceAttribute(instanceID, "disableApiTermination", err)
+	}
+	if result.DisableApiTermination == nil {
+		return false, nil
 	}
+	return aws.ToBool(result.DisableApiTermination.Value), nil
 }
 
-func mapToEC2Instance(ctx context.Context, instance types.Instance, client EC2Client) *EC2Instance {
+// getUserDataHash looks up the instance user data and hashes it. AWS
+// returns it base64 encoded.
+func getUserDataHash(ctx context.Context, client EC2Client, instanceID string, callTimeout time.Duration) (string, error) {
+	callCtx, cancel := callContext(ctx, callTimeout)
+	defer cancel()
+
+	cloud.CountCall(ctx, "DescribeInstanceAttribute")
+	result, err := client.DescribeInstanceAttribute(callCtx, &ec2.DescribeInstanceAttributeInput{
+		InstanceId: aws.String(instanceID),
+		Attribute:  types.InstanceAttributeNameUserData,
+	})
+	if err != nil {
+		return "", errors.NewDescribeInstanceAttribute(instanceID, "userData", err)
+	}
+	if result.UserData == nil || result.UserData.Value == nil {
+		return "", nil
+	}
+
+	userData, err := base64.StdEncoding.DecodeString(aws.ToString(result.UserData.Value))
+	if err != nil {
+		return "", errors.NewDescribeInstanceAttribute(instanceID, "userData", err)
+	}
+	return cloud.HashUserData(userData), nil
+}
+
+func mapToEC2Instance(ctx context.Context, instance types.Instance, client EC2Client, callTimeout time.Duration) *EC2Instance {
 	e := &EC2Instance{
 		InstanceID:     aws.ToString(instance.InstanceId),
 		AMI:            aws.ToString(instance.ImageId),
 		InstanceType:   string(instance.InstanceType),
 		SecurityGroups: make([]string, 0),
 		Tags:           make(map[string]string),
+		PrivateIP:      aws.ToString(instance.PrivateIpAddress),
+		PublicIP:       aws.ToString(instance.PublicIpAddress), // Empty when no public IP is assigned
+		VpcID:          aws.ToString(instance.VpcId),
+		SubnetID:       aws.ToString(instance.SubnetId),
+
+		InstanceLifecycle:     cloud.NormalizeLifecycle(string(instance.InstanceLifecycle)),
+		CapacityReservationID: aws.ToString(instance.CapacityReservationId),
+		RootDeviceType:        string(instance.RootDeviceType),
+		AssociatePublicIP:     associatesPublicIP(instance),
+	}
+
+	if instance.MetadataOptions != nil {
+		e.MetadataHttpTokens = string(instance.MetadataOptions.HttpTokens)
+	}
+
+	if instance.Monitoring != nil {
+		e.MonitoringState = string(instance.Monitoring.State)
+	}
+
+	if instance.State != nil {
+		e.InstanceState = string(instance.State.Name)
+	}
+
+	if instance.Placement != nil {
+		e.HostID = aws.ToString(instance.Placement.HostId)
+		e.Affinity = aws.ToString(instance.Placement.Affinity)
 	}
 
 	for _, tag := range instance.Tags {
@@ -163,14 +377,25 @@ func mapToEC2Instance(ctx context.Context, instance types.Instance, client EC2Cl
 	found := false
 	for _, bd := range instance.BlockDeviceMappings {
 		if bd.Ebs != nil && aws.ToString(bd.DeviceName) == aws.ToString(instance.RootDeviceName) {
-			v := getVolumeDetails(ctx, client, aws.ToString(bd.Ebs.VolumeId))
+			found = true
+			v, err := getVolumeDetails(ctx, client, aws.ToString(bd.Ebs.VolumeId), callTimeout)
+			if err != nil {
+				// Leave the root block device unset rather than filling it
+				// with zero values that read like real data
+				logger.Log.Warn("Failed to describe root volume",
+					zap.String("instance_id", e.InstanceID), zap.Error(err))
+				cloud.AddWarning(ctx, e.InstanceID, "root block device unknown: "+err.Error())
+				e.Unknown = append(e.Unknown, "root_block_device")
+				break
+			}
 			e.RootBlockDevice = &BlockDevice{
-				VolumeID:   aws.ToString(bd.Ebs.VolumeId),
-				DeviceName: aws.ToString(bd.DeviceName),
-				SizeGB:     v.SizeGB,
-				VolumeType: v.VolumeType,
+				VolumeID:            aws.ToString(bd.Ebs.VolumeId),
+				DeviceName:          aws.ToString(bd.DeviceName),
+				SizeGB:              v.SizeGB,
+				VolumeType:          v.VolumeType,
+				Encrypted:           v.Encrypted,
+				DeleteOnTermination: aws.ToBool(bd.Ebs.DeleteOnTermination),
 			}
-			found = true
 			break
 		}
 	}
@@ -185,6 +410,150 @@ func mapToEC2Instance(ctx context.Context, instance types.Instance, client EC2Cl
 	return e
 }
 
+// clientForRegion returns the cached client for the config's region and
+// endpoint, building one on first use
+func (p *AWSProvider) clientForRegion(ctx context.Context, cfg *awsConfig.Config) (EC2Client, error) {
+	region := cfg.GetRegion()
+	key := clientKey(cfg)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[key]; ok {
+		return client, nil
+	}
+
+	newClient := p.newClient
+	if newClient == nil {
+		newClient = NewEC2Client
+	}
+
+	client, err := newClient(ctx, cfg, region)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.clients == nil {
+		p.clients = make(map[string]EC2Client)
+	}
+	p.clients[key] = client
+	return client, nil
+}
+
+// refreshClient drops the cached client of the config's region and builds
+// a new one, which loads the profile and resolves its credentials anew.
+// The old client's cached credentials are invalidated as well, so runs
+// still holding it retrieve new ones on their next call.
+func (p *AWSProvider) refreshClient(ctx context.Context, cfg *awsConfig.Config) (EC2Client, error) {
+	p.mu.Lock()
+	if client, ok := p.clients[clientKey(cfg)].(*ec2.Client); ok {
+		if cache, ok := client.Options().Credentials.(*aws.CredentialsCache); ok {
+			cache.Invalidate()
+		}
+	}
+	delete(p.clients, clientKey(cfg))
+	p.mu.Unlock()
+	return p.clientForRegion(ctx, cfg)
+}
+
+// clientKey identifies the cached client of a region and endpoint
+func clientKey(cfg *awsConfig.Config) string {
+	key := cfg.GetRegion()
+	if cfg.EndpointURL != "" {
+		key += "@" + cfg.EndpointURL
+	}
+	return key
+}
+
+// expiredCredentialCodes are the error codes AWS answers requests signed
+// with expired temporary credentials with
+var expiredCredentialCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+}
+
+// credentialsExpired reports whether a call failed on expired credentials
+func credentialsExpired(err error) bool {
+	var apiErr smithy.APIError
+	return stderrors.As(err, &apiErr) && expiredCredentialCodes[apiErr.ErrorCode()]
+}
+
+// sdkHTTPClient is shared by every regional client so they draw from one
+// connection pool. It stays buildable so the SDK can still add a custom CA
+// bundle from AWS_CA_BUNDLE.
+var sdkHTTPClient = awshttp.NewBuildableClient().WithTransportOptions(httpclient.ConfigureTransport)
+
+// NewEC2Client is the default ClientFactory backed by the AWS SDK. Every
+// client it builds shares sdkHTTPClient.
+func NewEC2Client(ctx context.Context, cfg *awsConfig.Config, region string) (EC2Client, error) {
+	awsCfg, err := SDKConfig(ctx, cfg, region, sdkHTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	return ec2.NewFromConfig(awsCfg), nil
+}
+
+// SDKConfig loads the AWS SDK config for a region using the static
+// credentials from cfg, or its profile when no access key is set, and the
+// given HTTP client. A custom endpoint URL replaces the AWS endpoints, e.g.
+// to test against LocalStack.
+func SDKConfig(ctx context.Context, cfg *awsConfig.Config, region string, httpClient aws.HTTPClient) (aws.Config, error) {
+	loadOpts := []func(*awsPkgConfig.LoadOptions) error{
+		awsPkgConfig.WithRegion(region),
+		awsPkgConfig.WithHTTPClient(httpClient),
+	}
+	if cfg.RefreshableCredentials() {
+		// Each load resolves the profile's credentials anew
+		loadOpts = append(loadOpts, awsPkgConfig.WithSharedConfigProfile(cfg.Profile))
+	} else {
+		loadOpts = append(loadOpts, awsPkgConfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(
+				cfg.AccessKey,
+				cfg.SecretKey,
+				cfg.SessionToken,
+			),
+		))
+	}
+	if cfg.EndpointURL != "" {
+		loadOpts = append(loadOpts, awsPkgConfig.WithBaseEndpoint(cfg.EndpointURL))
+	}
+
+	awsCfg, err := awsPkgConfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, errors.NewAWSConfigLoad(err)
+	}
+	return awsCfg, nil
+}
+
+// SetEC2Client makes every region use the given client, mainly for tests
 func (p *AWSProvider) SetEC2Client(c EC2Client) {
-	p.EC2Client = c
+	p.SetClientFactory(func(context.Context, *awsConfig.Config, string) (EC2Client, error) {
+		return c, nil
+	})
+}
+
+// SetClientFactory overrides how per-region clients are built and drops
+// any clients cached so far
+func (p *AWSProvider) SetClientFactory(f ClientFactory) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.newClient = f
+	p.clients = nil
+}
+
+// associatesPublicIP reports whether the primary network interface holds a
+// public IP assigned at launch. Amazon owns those; Elastic IPs are owned by
+// the account and were associated later. Without interface data any public
+// IP counts.
+func associatesPublicIP(instance types.Instance) bool {
+	if len(instance.NetworkInterfaces) == 0 {
+		return aws.ToString(instance.PublicIpAddress) != ""
+	}
+	for _, ni := range instance.NetworkInterfaces {
+		if ni.Attachment == nil || aws.ToInt32(ni.Attachment.DeviceIndex) != 0 {
+			continue
+		}
+		return ni.Association != nil && aws.ToString(ni.Association.IpOwnerId) == "amazon"
+	}
+	return false
 }
@@ -0,0 +1,60 @@
+package cloud
+
+import (
+	"context"
+	"sync"
+)
+
+// CallCounter counts the cloud API calls made during a run, per operation.
+// It is safe for concurrent use.
+type CallCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// Add records one call of the operation
+func (c *CallCounter) Add(operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[operation]++
+}
+
+// Counts returns a copy of the number of calls per operation
+func (c *CallCounter) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int, len(c.counts))
+	for operation, n := range c.counts {
+		counts[operation] = n
+	}
+	return counts
+}
+
+// Total returns the number of calls across all operations
+func (c *CallCounter) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0
+	for _, n := range c.counts {
+		total += n
+	}
+	return total
+}
+
+type callCounterKey struct{}
+
+// WithCallCounter returns a context whose cloud API calls are counted by c
+func WithCallCounter(ctx context.Context, c *CallCounter) context.Context {
+	return context.WithValue(ctx, callCounterKey{}, c)
+}
+
+// CountCall records a call of the operation on the context's counter, if any.
+// Providers call it once per API request, whether or not the request succeeds.
+func CountCall(ctx context.Context, operation string) {
+	if c, ok := ctx.Value(callCounterKey{}).(*CallCounter); ok {
+		c.Add(operation)
+	}
+}
@@ -20,8 +20,10 @@ func (p *GCPProvider) FetchInstances(ctx context.Context, providerCfg config.Pro
 				"Name": "GCP-WebServer",
 			},
 			RootBlockDevice: struct {
-				VolumeSize int    `json:"volume_size"`
-				VolumeType string `json:"volume_type"`
+				VolumeSize          int    `json:"volume_size"`
+				VolumeType          string `json:"volume_type"`
+				Encrypted           *bool  `json:"encrypted,omitempty"`
+				DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 			}{
 				VolumeSize: 10,
 				VolumeType: "pd-standard",
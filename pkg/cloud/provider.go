@@ -2,20 +2,113 @@ package cloud
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 
 	"github.com/oldmonad/ec2Drift/pkg/config/cloud"
 )
 
 type Instance struct {
-	InstanceID      string            `json:"instance_id"`
-	AMI             string            `json:"ami"`
-	InstanceType    string            `json:"instance_type"`
-	SecurityGroups  []string          `json:"security_groups"`
-	Tags            map[string]string `json:"tags"`
-	RootBlockDevice struct {
-		VolumeSize int    `json:"volume_size"`
-		VolumeType string `json:"volume_type"`
+	InstanceID         string            `json:"instance_id"`
+	Provider           string            `json:"provider,omitempty"`
+	AMI                string            `json:"ami"`
+	InstanceType       string            `json:"instance_type"`
+	SecurityGroups     []string          `json:"security_groups"`
+	Tags               map[string]string `json:"tags"`
+	PrivateIP          string            `json:"private_ip,omitempty"`
+	PublicIP           string            `json:"public_ip,omitempty"`
+	VpcID              string            `json:"vpc_id,omitempty"`
+	SubnetID           string            `json:"subnet_id,omitempty"`
+	MetadataHttpTokens string            `json:"metadata_http_tokens,omitempty"` // "required" enforces IMDSv2
+	RootBlockDevice    struct {
+		VolumeSize          int    `json:"volume_size"`
+		VolumeType          string `json:"volume_type"`
+		Encrypted           *bool  `json:"encrypted,omitempty"`             // nil when the desired config leaves it unset
+		DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"` // nil when the desired config leaves it unset
 	} `json:"root_block_device"`
+	// Termination protection, only fetched from AWS when the attribute is
+	// selected. nil when the desired config leaves it unset.
+	DisableApiTermination *bool `json:"disable_api_termination,omitempty"`
+	// SHA-256 of the decoded user data, empty without user data. Like
+	// termination protection it is only fetched when selected.
+	UserDataHash string `json:"user_data_hash,omitempty"`
+	// spot, scheduled or normal (on-demand). Empty in a desired config that
+	// does not set it, which compares as on-demand.
+	InstanceLifecycle string `json:"instance_lifecycle,omitempty"`
+	// Detailed CloudWatch monitoring: enabled, disabled or a transition
+	// state such as pending. Empty when the desired config leaves it unset.
+	MonitoringState string `json:"monitoring_state,omitempty"`
+	// Dedicated host the instance is placed on, empty on shared tenancy
+	HostID string `json:"host_id,omitempty"`
+	// On-demand capacity reservation the instance runs in, empty when it
+	// runs outside one
+	CapacityReservationID string `json:"capacity_reservation_id,omitempty"`
+	// Dedicated host affinity, default or host, empty on shared tenancy
+	Affinity string `json:"affinity,omitempty"`
+	// Root device type, ebs or instance-store. Replacements may change it.
+	RootDeviceType string `json:"root_device_type,omitempty"`
+	// Whether the primary network interface was given a public IP at
+	// launch. Elastic IPs do not count. nil when the desired config leaves
+	// it unset.
+	AssociatePublicIP *bool `json:"associate_public_ip_address,omitempty"`
+	// Lifecycle state, e.g. running or stopped. Empty in a desired config
+	// that does not set it, which expects the instance to run.
+	InstanceState string `json:"instance_state,omitempty"`
+	// AWS account owning the instance's reservation. Recorded in baselines,
+	// never compared.
+	AccountID string `json:"account_id,omitempty"`
+	// Attributes the provider failed to fetch, such as root_block_device
+	// when its volume could not be described. They hold zero values and are
+	// never compared.
+	Unknown []string `json:"unknown_attributes,omitempty"`
+}
+
+// IsUnknown reports whether the attribute, or the attribute it belongs
+// to, could not be fetched, so root_block_device covers
+// root_block_device.volume_size
+func (i Instance) IsUnknown(attribute string) bool {
+	for _, unknown := range i.Unknown {
+		if attribute == unknown || strings.HasPrefix(attribute, unknown+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleNormal is the lifecycle of on-demand instances
+const LifecycleNormal = "normal"
+
+// NormalizeLifecycle maps the empty lifecycle AWS reports for on-demand
+// instances to "normal"
+func NormalizeLifecycle(lifecycle string) string {
+	if lifecycle == "" {
+		return LifecycleNormal
+	}
+	return lifecycle
+}
+
+// HashUserData returns the hex SHA-256 of user data, or an empty string when
+// there is none, so scripts are compared without reporting their content
+func HashUserData(userData []byte) string {
+	if len(userData) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(userData)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeMonitoringState folds the transition states AWS reports while
+// monitoring is switched into the state being switched to
+func NormalizeMonitoringState(state string) string {
+	switch state {
+	case "pending":
+		return "enabled"
+	case "disabling":
+		return "disabled"
+	default:
+		return state
+	}
 }
 
 type CloudProvider interface {
@@ -0,0 +1,56 @@
+package cloud
+
+import (
+	"context"
+	"sync"
+)
+
+// Warning records data a provider could not fetch, or a config parser could
+// not decode, for an instance. The run still succeeds, the instance just
+// carries partial data.
+type Warning struct {
+	InstanceID string `json:"instance_id"`
+	Message    string `json:"message"`
+}
+
+// WarningCollector gathers the warnings raised during a run. It is safe for
+// concurrent use.
+type WarningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+// Add records a warning for the instance
+func (c *WarningCollector) Add(instanceID, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, Warning{InstanceID: instanceID, Message: message})
+}
+
+// Warnings returns a copy of the warnings in the order they were raised
+func (c *WarningCollector) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Warning(nil), c.warnings...)
+}
+
+type warningCollectorKey struct{}
+
+// WithWarningCollector returns a context whose partial data warnings are
+// gathered by c
+func WithWarningCollector(ctx context.Context, c *WarningCollector) context.Context {
+	return context.WithValue(ctx, warningCollectorKey{}, c)
+}
+
+// WarningCollectorFrom returns the context's collector, nil when it has none
+func WarningCollectorFrom(ctx context.Context) *WarningCollector {
+	c, _ := ctx.Value(warningCollectorKey{}).(*WarningCollector)
+	return c
+}
+
+// AddWarning records a warning on the context's collector, if any
+func AddWarning(ctx context.Context, instanceID, message string) {
+	if c, ok := ctx.Value(warningCollectorKey{}).(*WarningCollector); ok {
+		c.Add(instanceID, message)
+	}
+}
@@ -1,6 +1,8 @@
 package cloud
 
 import (
+	"strings"
+
 	"github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
 	"github.com/oldmonad/ec2Drift/pkg/config/cloud/gcp"
 
@@ -22,27 +24,52 @@ const (
 	GCP ProviderType = "gcp"
 )
 
+// ProviderTypeOf returns the provider a config belongs to. Configs of other
+// types, such as test doubles, report false.
+func ProviderTypeOf(cfg ProviderConfig) (ProviderType, bool) {
+	switch cfg.(type) {
+	case *aws.Config:
+		return AWS, true
+	case *gcp.Config:
+		return GCP, true
+	default:
+		return "", false
+	}
+}
+
+// MaskSecret keeps the first four characters of a key, enough to tell keys
+// apart, and masks the rest
+func MaskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + "****"
+}
+
 func NewProviderConfig(provider ProviderType) (ProviderConfig, error) {
 	switch provider {
 	case AWS:
-		cfg := aws.LoadConfig()
-		// Check for access key validity,
-		// this is not a proper validation for an access key
-		// It's just to make sure the logger functions properly.
-		if len(cfg.AccessKey) < 10 {
-			logger.Log.Info("Ensure the that correct AWS_ACCESS_KEY_ID is set or not empty")
-			return nil, errors.NewInvalidConfigCredential("Invalid AWS_ACCESS_KEY_ID")
+		cfg, err := aws.LoadConfig()
+		if err != nil {
+			logger.Log.Error("Failed to load AWS configuration", zap.Error(err))
+			return nil, err
 		}
-
 		logger.Log.Debug("Loaded AWS configuration",
-			zap.String("access_key", cfg.AccessKey[:4]+"****"),
+			zap.String("access_key", MaskSecret(cfg.AccessKey)),
 			zap.String("region", cfg.Region))
 
+		// Missing settings are listed together before the key is checked,
+		// so the error names every variable that needs fixing
 		if err := cfg.Validate(); err != nil {
 			logger.Log.Error("AWS configuration validation failed",
 				zap.Error(err))
 			return nil, err
 		}
+		if err := cfg.ValidateAccessKeyFormat(); err != nil {
+			logger.Log.Error("AWS configuration validation failed",
+				zap.Error(err))
+			return nil, err
+		}
 		return cfg, nil
 
 	// The GCP config here is just here to demonstrate the
@@ -4,19 +4,25 @@ import (
 	"os"
 
 	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type Config struct {
 	ProjectID       string
 	Region          string
-	CredentialsFile string
+	Zone            string
+	CredentialsFile string // Path to a service account JSON key
+	CredentialsJSON string // Inline service account JSON key
 }
 
 func LoadConfig() *Config {
 	return &Config{
 		ProjectID:       os.Getenv("GCP_PROJECT"),
 		Region:          os.Getenv("GCP_REGION"),
+		Zone:            os.Getenv("GCP_ZONE"),
 		CredentialsFile: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+		CredentialsJSON: os.Getenv("GOOGLE_CREDENTIALS_JSON"),
 	}
 }
 
@@ -28,19 +34,33 @@ func (c *Config) Validate() error {
 	if c.Region == "" {
 		missing = append(missing, "GCP_REGION")
 	}
-	if c.CredentialsFile == "" {
+
+	// Either a key file path or the inline key contents is enough
+	if c.CredentialsFile == "" && c.CredentialsJSON == "" {
 		missing = append(missing, "GOOGLE_APPLICATION_CREDENTIALS")
 	}
+
 	if len(missing) > 0 {
+		logger.Log.Error("GCP config validation failed", zap.Strings("missing", missing))
 		return errors.NewErrMissingGCPConfig(missing)
 	}
 	return nil
 }
 
+// GetCredentials returns the inline service account key when set,
+// falling back to the path of the key file.
 func (c *Config) GetCredentials() interface{} {
+	if c.CredentialsJSON != "" {
+		return c.CredentialsJSON
+	}
 	return c.CredentialsFile
 }
 
 func (c *Config) GetRegion() string {
 	return c.Region
 }
+
+// GetZone returns the configured zone, which is optional for GCP.
+func (c *Config) GetZone() string {
+	return c.Zone
+}
@@ -0,0 +1,168 @@
+package gcp_test
+
+import (
+	"testing"
+
+	gcpConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/gcp"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMain(m *testing.M) {
+	logger.SetLogger(zap.NewNop())
+	m.Run()
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("all fields set", func(t *testing.T) {
+		t.Setenv("GCP_PROJECT", "test-project")
+		t.Setenv("GCP_REGION", "europe-west1")
+		t.Setenv("GCP_ZONE", "europe-west1-b")
+		t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/secrets/key.json")
+		t.Setenv("GOOGLE_CREDENTIALS_JSON", `{"type":"service_account"}`)
+
+		cfg := gcpConfig.LoadConfig()
+
+		assert.Equal(t, "test-project", cfg.ProjectID)
+		assert.Equal(t, "europe-west1", cfg.Region)
+		assert.Equal(t, "europe-west1-b", cfg.Zone)
+		assert.Equal(t, "/secrets/key.json", cfg.CredentialsFile)
+		assert.Equal(t, `{"type":"service_account"}`, cfg.CredentialsJSON)
+	})
+
+	t.Run("optional fields missing", func(t *testing.T) {
+		t.Setenv("GCP_PROJECT", "test-project")
+		t.Setenv("GCP_REGION", "europe-west1")
+		t.Setenv("GCP_ZONE", "")
+		t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/secrets/key.json")
+		t.Setenv("GOOGLE_CREDENTIALS_JSON", "")
+
+		cfg := gcpConfig.LoadConfig()
+
+		assert.Equal(t, "test-project", cfg.ProjectID)
+		assert.Equal(t, "europe-west1", cfg.Region)
+		assert.Empty(t, cfg.Zone)
+		assert.Empty(t, cfg.CredentialsJSON)
+	})
+}
+
+func TestGetCredentials(t *testing.T) {
+	t.Run("inline credentials take precedence", func(t *testing.T) {
+		cfg := &gcpConfig.Config{
+			CredentialsFile: "/secrets/key.json",
+			CredentialsJSON: `{"type":"service_account"}`,
+		}
+
+		assert.Equal(t, `{"type":"service_account"}`, cfg.GetCredentials())
+	})
+
+	t.Run("credentials file path", func(t *testing.T) {
+		cfg := &gcpConfig.Config{
+			CredentialsFile: "/secrets/key.json",
+		}
+
+		assert.Equal(t, "/secrets/key.json", cfg.GetCredentials())
+	})
+}
+
+func TestGetRegionAndZone(t *testing.T) {
+	cfg := &gcpConfig.Config{
+		Region: "us-central1",
+		Zone:   "us-central1-a",
+	}
+
+	assert.Equal(t, "us-central1", cfg.GetRegion())
+	assert.Equal(t, "us-central1-a", cfg.GetZone())
+	assert.Empty(t, (&gcpConfig.Config{}).GetRegion())
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *gcpConfig.Config
+		wantErr bool
+		missing []string
+	}{
+		{
+			name: "all required fields present with credentials file",
+			config: &gcpConfig.Config{
+				ProjectID:       "project",
+				Region:          "region",
+				CredentialsFile: "/secrets/key.json",
+			},
+			wantErr: false,
+		},
+		{
+			name: "all required fields present with inline credentials",
+			config: &gcpConfig.Config{
+				ProjectID:       "project",
+				Region:          "region",
+				CredentialsJSON: `{"type":"service_account"}`,
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing project",
+			config: &gcpConfig.Config{
+				Region:          "region",
+				CredentialsFile: "/secrets/key.json",
+			},
+			wantErr: true,
+			missing: []string{"GCP_PROJECT"},
+		},
+		{
+			name: "missing region",
+			config: &gcpConfig.Config{
+				ProjectID:       "project",
+				CredentialsFile: "/secrets/key.json",
+			},
+			wantErr: true,
+			missing: []string{"GCP_REGION"},
+		},
+		{
+			name: "missing credentials",
+			config: &gcpConfig.Config{
+				ProjectID: "project",
+				Region:    "region",
+			},
+			wantErr: true,
+			missing: []string{"GOOGLE_APPLICATION_CREDENTIALS"},
+		},
+		{
+			name:    "all required fields missing",
+			config:  &gcpConfig.Config{},
+			wantErr: true,
+			missing: []string{"GCP_PROJECT", "GCP_REGION", "GOOGLE_APPLICATION_CREDENTIALS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, recordedLogs := observer.New(zap.ErrorLevel)
+			observedLogger := zap.New(core)
+
+			originalLogger := logger.Log
+			logger.Log = observedLogger
+			defer func() { logger.Log = originalLogger }()
+
+			err := tt.config.Validate()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				var gcpErr errors.ErrMissingGCPConfig
+				require.ErrorAs(t, err, &gcpErr)
+				assert.ElementsMatch(t, tt.missing, gcpErr.Missing)
+
+				require.Equal(t, 1, recordedLogs.Len())
+				assert.Equal(t, "GCP config validation failed", recordedLogs.All()[0].Message)
+			} else {
+				assert.NoError(t, err)
+				assert.Zero(t, recordedLogs.Len())
+			}
+		})
+	}
+}
@@ -1,18 +1,25 @@
 package env
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/joho/godotenv"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
 	"github.com/oldmonad/ec2Drift/pkg/config/cloud"
 	"github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/schedule"
 	"go.uber.org/zap"
 )
 
 type Config interface {
 	PortToString() string
 	InitiateLogger()
+	ValidateServe() error
 }
 
 type Configurations struct {
@@ -21,10 +28,29 @@ type Configurations struct {
 	ConfigPath        string
 	StatePath         string
 	OutputPath        string
+	Schedule          string                           // Interval or cron expression for scheduled checks in serve mode
+	Severities        map[string]driftchecker.Severity // Severity per attribute or drift category, from SEVERITY
+	Comparators       driftchecker.ComparatorOptions   // Comparison strategy per attribute, from COMPARATORS
 	CloudProviderType cloud.ProviderType
 	HttpPort          int
+	HttpTimeouts      HttpTimeouts // Zero durations keep the server defaults
+	MaxReports        int          // Reports per REST response, zero serves them all
+	ReportTitle       string       // Label included in JSON and HTML reports and REST responses
 	CloudConfig       cloud.ProviderConfig
 	CloudProvider     CloudConfigProvider
+
+	// Set when CLOUD_PROVIDER lists more than one provider (e.g. aws,gcp).
+	// CloudProviderType and CloudConfig always hold the first entry.
+	CloudProviderTypes []cloud.ProviderType
+	CloudConfigs       map[cloud.ProviderType]cloud.ProviderConfig
+}
+
+// HttpTimeouts holds the server timeouts set through HTTP_READ_TIMEOUT,
+// HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT
+type HttpTimeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Idle  time.Duration
 }
 
 type CloudConfigProvider interface {
@@ -61,6 +87,30 @@ func (c *Configurations) LoadGeneralConfig() error {
 	c.ConfigPath = os.Getenv("CONFIG_PATH")
 	c.StatePath = os.Getenv("STATE_PATH")
 	c.OutputPath = os.Getenv("OUTPUT_PATH")
+	c.ReportTitle = strings.TrimSpace(os.Getenv("REPORT_TITLE"))
+
+	c.Schedule = strings.TrimSpace(os.Getenv("SCHEDULE"))
+	if c.Schedule != "" {
+		if _, err := schedule.Parse(c.Schedule); err != nil {
+			logger.Log.Error("Invalid schedule configuration", zap.Error(err))
+			logger.Log.Info("Ensure that SCHEDULE is a duration such as 15m or a cron expression such as */15 * * * *")
+			return err
+		}
+	}
+
+	c.Severities, err = driftchecker.ParseSeverityMap(os.Getenv("SEVERITY"))
+	if err != nil {
+		logger.Log.Error("Invalid severity configuration", zap.Error(err))
+		logger.Log.Info("Ensure that SEVERITY lists attribute=severity pairs such as ami=critical,tags=info")
+		return err
+	}
+
+	c.Comparators, err = driftchecker.ParseComparators(os.Getenv("COMPARATORS"))
+	if err != nil {
+		logger.Log.Error("Invalid comparator configuration", zap.Error(err))
+		logger.Log.Info("Ensure that COMPARATORS lists attribute=strategy pairs such as ami=case-insensitive,tags=prefix-ignore:aws:")
+		return err
+	}
 
 	if err := c.ValidateAndSetPort(); err != nil {
 		logger.Log.Error("Invalid port configuration", zap.Error(err))
@@ -68,18 +118,56 @@ func (c *Configurations) LoadGeneralConfig() error {
 		return err
 	}
 
-	provider := os.Getenv("CLOUD_PROVIDER")
-	if provider == "" {
+	if err := c.ValidateAndSetTimeouts(); err != nil {
+		logger.Log.Error("Invalid HTTP timeout configuration", zap.Error(err))
+		logger.Log.Info("Ensure that HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT are durations such as 30s or 5m")
+		return err
+	}
+
+	if err := c.ValidateAndSetMaxReports(); err != nil {
+		logger.Log.Error("Invalid maximum report count", zap.Error(err))
+		logger.Log.Info("Ensure that MAX_REPORTS is a non-negative integer, 0 disables truncation")
+		return err
+	}
+
+	providers := parseProviderList(os.Getenv("CLOUD_PROVIDER"))
+	if len(providers) == 0 {
 		logger.Log.Error("failed to set up configuration", zap.Error(err))
 		logger.Log.Info("Ensure the that CLOUD_PROVIDER is set e.g aws, azure, gcp")
 		return errors.NewErrMissingCloudProvider()
 	}
 
-	c.CloudProviderType = cloud.ProviderType(provider)
+	c.CloudProviderType = providers[0]
+	c.CloudProviderTypes = providers
 
 	return nil
 }
 
+// parseProviderList splits a comma separated CLOUD_PROVIDER value,
+// dropping empty and duplicate entries while keeping their order
+func parseProviderList(raw string) []cloud.ProviderType {
+	var providers []cloud.ProviderType
+	seen := make(map[cloud.ProviderType]bool)
+	for _, p := range strings.Split(raw, ",") {
+		provider := cloud.ProviderType(strings.TrimSpace(p))
+		if provider == "" || seen[provider] {
+			continue
+		}
+		seen[provider] = true
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// ProviderTypes returns every configured cloud provider, falling back
+// to the single CloudProviderType when no list was loaded
+func (c *Configurations) ProviderTypes() []cloud.ProviderType {
+	if len(c.CloudProviderTypes) > 0 {
+		return c.CloudProviderTypes
+	}
+	return []cloud.ProviderType{c.CloudProviderType}
+}
+
 func (c *Configurations) LoadCloudConfig() error {
 	// Delegate to cloud package to create provider-specific config
 	cloudCfg, err := c.CloudProvider.NewProviderConfig(c.CloudProviderType)
@@ -87,21 +175,64 @@ func (c *Configurations) LoadCloudConfig() error {
 		return err
 	}
 	c.CloudConfig = cloudCfg
+
+	providers := c.ProviderTypes()
+	if len(providers) < 2 {
+		return nil
+	}
+
+	// Each provider reads its own credentials from the environment
+	c.CloudConfigs = map[cloud.ProviderType]cloud.ProviderConfig{c.CloudProviderType: cloudCfg}
+	for _, provider := range providers[1:] {
+		providerCfg, err := c.CloudProvider.NewProviderConfig(provider)
+		if err != nil {
+			return err
+		}
+		c.CloudConfigs[provider] = providerCfg
+	}
 	return nil
 }
 
+// ValidateGeneralConfig checks the cloud configuration. STATE_PATH is only
+// required by the checks that read it, not by compare, --config-file,
+// --baseline or requests sending their config inline.
 func (c *Configurations) ValidateGeneralConfig() error {
-	// Validate core configuration
-	if c.StatePath == "" {
-		return errors.NewErrMissingPaths()
-	}
-
 	// Validate cloud configuration
 	if c.CloudConfig == nil {
 		return errors.NewErrCloudConfigNotInit()
 	}
 
-	return c.CloudConfig.Validate()
+	if err := checkProviderConfig(c.CloudProviderType, c.CloudConfig); err != nil {
+		return err
+	}
+
+	if err := c.CloudConfig.Validate(); err != nil {
+		return err
+	}
+
+	for _, provider := range c.ProviderTypes() {
+		providerCfg, ok := c.CloudConfigs[provider]
+		if !ok || providerCfg == c.CloudConfig {
+			continue
+		}
+		if err := checkProviderConfig(provider, providerCfg); err != nil {
+			return err
+		}
+		if err := providerCfg.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkProviderConfig ensures a config loaded for a provider is of that
+// provider's type, so fetching does not fail on a type assertion later
+func checkProviderConfig(provider cloud.ProviderType, cfg cloud.ProviderConfig) error {
+	configType, ok := cloud.ProviderTypeOf(cfg)
+	if ok && configType != provider {
+		return errors.NewErrProviderConfigMismatch(string(provider), string(configType))
+	}
+	return nil
 }
 
 func (c *Configurations) ValidateAndSetPort() error {
@@ -110,16 +241,84 @@ func (c *Configurations) ValidateAndSetPort() error {
 		return nil // Use default port (already set in constructor)
 	}
 
-	port, err := strconv.Atoi(portStr)
+	port, err := ParsePort("HTTP_PORT", portStr)
+	if err != nil {
+		return err
+	}
+
+	c.HttpPort = port
+	return nil
+}
+
+// ParsePort parses a port number set through source, HTTP_PORT or the
+// --port flag, and checks that it is within 1–65535
+func ParsePort(source, raw string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(raw))
 	if err != nil {
-		return errors.NewErrPortParse(portStr, err)
+		return 0, errors.NewErrPortParse(source, raw, err)
 	}
 
 	if port < 1 || port > 65535 {
-		return errors.NewErrPortOutOfRange(port)
+		return 0, errors.NewErrPortOutOfRange(source, port)
 	}
+	return port, nil
+}
 
-	c.HttpPort = port
+// ValidateAndSetTimeouts reads the optional HTTP server timeouts. Unset
+// variables leave the timeout at zero so the server default applies.
+func (c *Configurations) ValidateAndSetTimeouts() error {
+	timeouts := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", &c.HttpTimeouts.Read},
+		{"HTTP_WRITE_TIMEOUT", &c.HttpTimeouts.Write},
+		{"HTTP_IDLE_TIMEOUT", &c.HttpTimeouts.Idle},
+	}
+
+	for _, timeout := range timeouts {
+		raw := strings.TrimSpace(os.Getenv(timeout.name))
+		if raw == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.NewErrTimeoutParse(timeout.name, raw, err)
+		}
+		if d <= 0 {
+			return errors.NewErrTimeoutParse(timeout.name, raw, fmt.Errorf("must be positive"))
+		}
+		*timeout.value = d
+	}
+	return nil
+}
+
+// ValidateAndSetMaxReports reads the optional MAX_REPORTS limit on the
+// reports served in one REST response
+func (c *Configurations) ValidateAndSetMaxReports() error {
+	raw := strings.TrimSpace(os.Getenv("MAX_REPORTS"))
+	if raw == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return errors.NewErrMaxReportsParse(raw, err)
+	}
+	if n < 0 {
+		return errors.NewErrMaxReportsParse(raw, fmt.Errorf("must not be negative"))
+	}
+	c.MaxReports = n
+	return nil
+}
+
+// ValidateServe checks the settings only serve mode needs, so other
+// commands still run without them
+func (c *Configurations) ValidateServe() error {
+	if c.Schedule != "" && c.StatePath == "" {
+		return errors.NewErrInvalidSchedule(c.Schedule, "scheduled checks read STATE_PATH, which is unset")
+	}
 	return nil
 }
 
@@ -131,6 +330,31 @@ func (c *Configurations) InitiateLogger() {
 	logger.Init(c.DebugMode)
 }
 
+// LoadDotEnv loads the variables of the .env file at path into the
+// environment. A missing file is not an error since the variables may be
+// set directly; a file that cannot be read or parsed is.
+func LoadDotEnv(path string) error {
+	return loadDotEnv(path, godotenv.Load)
+}
+
+// ReloadDotEnv works like LoadDotEnv but the file's variables replace
+// those already set, so edits made since startup take effect
+func ReloadDotEnv(path string) error {
+	return loadDotEnv(path, godotenv.Overload)
+}
+
+func loadDotEnv(path string, load func(filenames ...string) error) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		logger.Log.Info("No .env file found, using the environment", zap.String("path", path))
+		return nil
+	}
+
+	if err := load(path); err != nil {
+		return errors.NewErrEnvLoad(path, err)
+	}
+	return nil
+}
+
 func SetupConfigurations() (*Configurations, error) {
 	configurations := NewConfiguration()
 
@@ -2,14 +2,21 @@ package env_test
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
 	"github.com/oldmonad/ec2Drift/pkg/config/cloud"
+	awsConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
+	gcpConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/gcp"
 	"github.com/oldmonad/ec2Drift/pkg/config/env"
 	err "github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -86,6 +93,7 @@ func TestLoadGeneralConfig(t *testing.T) {
 				"CONFIG_PATH":    "/config",
 				"STATE_PATH":     "/state",
 				"OUTPUT_PATH":    "/output",
+				"REPORT_TITLE":   " prod us-east-1 ",
 				"HTTP_PORT":      "8081",
 				"CLOUD_PROVIDER": "aws",
 			},
@@ -95,6 +103,7 @@ func TestLoadGeneralConfig(t *testing.T) {
 				ConfigPath:        "/config",
 				StatePath:         "/state",
 				OutputPath:        "/output",
+				ReportTitle:       "prod us-east-1",
 				HttpPort:          8081,
 				CloudProviderType: "aws",
 			},
@@ -160,6 +169,79 @@ func TestLoadGeneralConfig(t *testing.T) {
 			expectErr: true,
 			errType:   &err.ErrMissingCloudProvider{},
 		},
+		{
+			name: "multiple cloud providers",
+			env: map[string]string{
+				"DEBUG":          "true",
+				"CLOUD_PROVIDER": "aws, gcp,aws",
+			},
+			expectedConfig: &env.Configurations{
+				DebugMode:          true,
+				HttpPort:           8080,
+				CloudProviderType:  "aws",
+				CloudProviderTypes: []cloud.ProviderType{"aws", "gcp"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "valid SCHEDULE",
+			env: map[string]string{
+				"DEBUG":          "true",
+				"CLOUD_PROVIDER": "aws",
+				"SCHEDULE":       "*/15 * * * *",
+			},
+			expectedConfig: &env.Configurations{
+				DebugMode:         true,
+				HttpPort:          8080,
+				CloudProviderType: "aws",
+				Schedule:          "*/15 * * * *",
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid SCHEDULE",
+			env: map[string]string{
+				"DEBUG":          "true",
+				"CLOUD_PROVIDER": "aws",
+				"SCHEDULE":       "every now and then",
+			},
+			expectedConfig: &env.Configurations{
+				DebugMode: true,
+				HttpPort:  8080,
+				Schedule:  "every now and then",
+			},
+			expectErr: true,
+			errType:   &err.ErrInvalidSchedule{},
+		},
+		{
+			name: "valid SEVERITY",
+			env: map[string]string{
+				"DEBUG":          "true",
+				"CLOUD_PROVIDER": "aws",
+				"SEVERITY":       "ami=critical, tags = info",
+			},
+			expectedConfig: &env.Configurations{
+				DebugMode:         true,
+				HttpPort:          8080,
+				CloudProviderType: "aws",
+				Severities:        map[string]driftchecker.Severity{"ami": driftchecker.SeverityCritical, "tags": driftchecker.SeverityInfo},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid SEVERITY",
+			env: map[string]string{
+				"DEBUG":          "true",
+				"CLOUD_PROVIDER": "aws",
+				"SEVERITY":       "ami=urgent",
+			},
+			expectedConfig: &env.Configurations{
+				DebugMode: true,
+				HttpPort:  8080,
+			},
+			expectErr: true,
+			errType:   &err.ErrUnsupportedSeverity{},
+		},
 		{
 			name: "HTTP_PORT default",
 			env: map[string]string{
@@ -205,6 +287,13 @@ func TestLoadGeneralConfig(t *testing.T) {
 			assert.Equal(t, tt.expectedConfig.OutputPath, cfg.OutputPath)
 			assert.Equal(t, tt.expectedConfig.HttpPort, cfg.HttpPort)
 			assert.Equal(t, tt.expectedConfig.CloudProviderType, cfg.CloudProviderType)
+			assert.Equal(t, tt.expectedConfig.Schedule, cfg.Schedule)
+			if tt.expectedConfig.Severities != nil {
+				assert.Equal(t, tt.expectedConfig.Severities, cfg.Severities)
+			}
+			if tt.expectedConfig.CloudProviderTypes != nil {
+				assert.Equal(t, tt.expectedConfig.CloudProviderTypes, cfg.CloudProviderTypes)
+			}
 		})
 	}
 }
@@ -266,6 +355,118 @@ func TestValidateAndSetPort(t *testing.T) {
 	}
 }
 
+func TestLoadDotEnv(t *testing.T) {
+	t.Run("missing file is skipped", func(t *testing.T) {
+		assert.NoError(t, env.LoadDotEnv(filepath.Join(t.TempDir(), ".env")))
+	})
+
+	t.Run("variables are loaded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, os.WriteFile(path, []byte("EC2DRIFT_TEST_VALUE=loaded\n"), 0o600))
+		t.Setenv("EC2DRIFT_TEST_VALUE", "")
+		os.Unsetenv("EC2DRIFT_TEST_VALUE")
+
+		require.NoError(t, env.LoadDotEnv(path))
+		assert.Equal(t, "loaded", os.Getenv("EC2DRIFT_TEST_VALUE"))
+	})
+
+	for name, content := range map[string]string{
+		"unterminated quote": "DEBUG=true\nSTATE_PATH=\"./main.tf\n",
+		"missing separator":  "DEBUG=true\nSTATE_PATH ./main.tf\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			loadErr := env.LoadDotEnv(path)
+			var envErr err.ErrEnvLoad
+			require.ErrorAs(t, loadErr, &envErr)
+			assert.Equal(t, path, envErr.Path)
+			assert.Contains(t, loadErr.Error(), path)
+		})
+	}
+}
+
+func TestReloadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("EC2DRIFT_TEST_VALUE=edited\n"), 0o600))
+	t.Setenv("EC2DRIFT_TEST_VALUE", "loaded")
+
+	// LoadDotEnv keeps the variables already set, ReloadDotEnv replaces them
+	require.NoError(t, env.LoadDotEnv(path))
+	assert.Equal(t, "loaded", os.Getenv("EC2DRIFT_TEST_VALUE"))
+
+	require.NoError(t, env.ReloadDotEnv(path))
+	assert.Equal(t, "edited", os.Getenv("EC2DRIFT_TEST_VALUE"))
+}
+
+func TestValidateAndSetTimeouts(t *testing.T) {
+	t.Run("unset keeps the server defaults", func(t *testing.T) {
+		cfg := env.NewConfiguration()
+		require.NoError(t, cfg.ValidateAndSetTimeouts())
+		assert.Equal(t, env.HttpTimeouts{}, cfg.HttpTimeouts)
+	})
+
+	t.Run("durations are parsed", func(t *testing.T) {
+		t.Setenv("HTTP_READ_TIMEOUT", "30s")
+		t.Setenv("HTTP_WRITE_TIMEOUT", "15m")
+		t.Setenv("HTTP_IDLE_TIMEOUT", "2m")
+
+		cfg := env.NewConfiguration()
+		require.NoError(t, cfg.ValidateAndSetTimeouts())
+		assert.Equal(t, env.HttpTimeouts{Read: 30 * time.Second, Write: 15 * time.Minute, Idle: 2 * time.Minute}, cfg.HttpTimeouts)
+	})
+
+	for _, raw := range []string{"soon", "0s", "-5s"} {
+		t.Run("invalid "+raw, func(t *testing.T) {
+			t.Setenv("HTTP_WRITE_TIMEOUT", raw)
+
+			var parseErr err.ErrTimeoutParse
+			require.ErrorAs(t, env.NewConfiguration().ValidateAndSetTimeouts(), &parseErr)
+			assert.Equal(t, "HTTP_WRITE_TIMEOUT", parseErr.Name)
+		})
+	}
+}
+
+func TestValidateAndSetMaxReports(t *testing.T) {
+	t.Run("unset serves every report", func(t *testing.T) {
+		cfg := env.NewConfiguration()
+		require.NoError(t, cfg.ValidateAndSetMaxReports())
+		assert.Zero(t, cfg.MaxReports)
+	})
+
+	t.Run("count is parsed", func(t *testing.T) {
+		t.Setenv("MAX_REPORTS", "500")
+
+		cfg := env.NewConfiguration()
+		require.NoError(t, cfg.ValidateAndSetMaxReports())
+		assert.Equal(t, 500, cfg.MaxReports)
+	})
+
+	for _, raw := range []string{"many", "-1"} {
+		t.Run("invalid "+raw, func(t *testing.T) {
+			t.Setenv("MAX_REPORTS", raw)
+
+			var parseErr err.ErrMaxReportsParse
+			require.ErrorAs(t, env.NewConfiguration().ValidateAndSetMaxReports(), &parseErr)
+			assert.Equal(t, raw, parseErr.RawValue)
+		})
+	}
+}
+
+func TestValidateServe(t *testing.T) {
+	cfg := env.NewConfiguration()
+	require.NoError(t, cfg.ValidateServe(), "nothing is required without a schedule")
+
+	cfg.Schedule = "15m"
+	var scheduleErr err.ErrInvalidSchedule
+	require.ErrorAs(t, cfg.ValidateServe(), &scheduleErr, "scheduled checks need STATE_PATH")
+	assert.Equal(t, "15m", scheduleErr.Spec)
+
+	cfg.StatePath = "/state"
+	assert.NoError(t, cfg.ValidateServe())
+}
+
 func TestLoadCloudConfig(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -332,6 +533,36 @@ func TestLoadCloudConfig(t *testing.T) {
 	}
 }
 
+func TestLoadCloudConfigMultipleProviders(t *testing.T) {
+	awsCfg := new(MockAWSConfig)
+	gcpCfg := new(MockGCPConfig)
+
+	mockFactory := new(MockProviderConfigFactory)
+	mockFactory.On("NewProviderConfig", cloud.ProviderType("aws")).Return(awsCfg, nil)
+	mockFactory.On("NewProviderConfig", cloud.ProviderType("gcp")).Return(gcpCfg, nil)
+
+	cfg := env.NewConfiguration()
+	cfg.CloudProviderType = cloud.AWS
+	cfg.CloudProviderTypes = []cloud.ProviderType{cloud.AWS, cloud.GCP}
+	cfg.CloudProvider = mockFactory
+
+	assert.NoError(t, cfg.LoadCloudConfig())
+	mockFactory.AssertExpectations(t)
+
+	assert.Same(t, awsCfg, cfg.CloudConfig)
+	assert.Same(t, awsCfg, cfg.CloudConfigs[cloud.AWS])
+	assert.Same(t, gcpCfg, cfg.CloudConfigs[cloud.GCP])
+
+	// Every provider's config is validated
+	cfg.StatePath = "/state"
+	awsCfg.On("Validate").Return(nil)
+	gcpCfg.On("Validate").Return(errors.New("missing GCP_PROJECT"))
+
+	assert.EqualError(t, cfg.ValidateGeneralConfig(), "missing GCP_PROJECT")
+	awsCfg.AssertExpectations(t)
+	gcpCfg.AssertExpectations(t)
+}
+
 // Tests for general configuration validator
 func TestValidateGeneralConfig(t *testing.T) {
 	tests := []struct {
@@ -352,13 +583,13 @@ func TestValidateGeneralConfig(t *testing.T) {
 			expectErr:       false,
 		},
 		{
-			name:            "missing state path",
+			name:            "missing state path is left to the checks reading it",
 			statePath:       "",
 			outputPath:      "/output",
 			cloudConfig:     &MockAWSConfig{},
 			validateReturns: nil,
-			expectErr:       true,
-			expectedErrType: &err.ErrMissingPaths{},
+			expectErr:       false,
+			expectedErrType: nil,
 		},
 		{
 			name:            "missing output path",
@@ -396,7 +627,7 @@ func TestValidateGeneralConfig(t *testing.T) {
 			cfg.CloudConfig = tt.cloudConfig
 
 			// Only set up the mock expectation if we expect to reach that code
-			if tt.cloudConfig != nil && tt.statePath != "" {
+			if tt.cloudConfig != nil {
 				if mockConfig, ok := tt.cloudConfig.(*MockAWSConfig); ok {
 					mockConfig.On("Validate").Return(tt.validateReturns)
 				}
@@ -429,6 +660,52 @@ func TestValidateGeneralConfig(t *testing.T) {
 	}
 }
 
+func TestValidateGeneralConfigProviderMismatch(t *testing.T) {
+	awsCfg := &awsConfig.Config{AccessKey: "AKIAEXAMPLE", SecretKey: "secret", Region: "us-east-1", SessionToken: "token"}
+	gcpCfg := &gcpConfig.Config{ProjectID: "project", Region: "us-central1", CredentialsFile: "key.json"}
+
+	tests := []struct {
+		name     string
+		provider cloud.ProviderType
+		config   cloud.ProviderConfig
+		mismatch bool
+	}{
+		{name: "aws with aws config", provider: cloud.AWS, config: awsCfg},
+		{name: "gcp with gcp config", provider: cloud.GCP, config: gcpCfg},
+		{name: "gcp with aws config", provider: cloud.GCP, config: awsCfg, mismatch: true},
+		{name: "aws with gcp config", provider: cloud.AWS, config: gcpCfg, mismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := env.NewConfiguration()
+			cfg.StatePath = "/state"
+			cfg.CloudProviderType = tt.provider
+			cfg.CloudConfig = tt.config
+
+			validateErr := cfg.ValidateGeneralConfig()
+			if !tt.mismatch {
+				assert.NoError(t, validateErr)
+				return
+			}
+			var mismatch err.ErrProviderConfigMismatch
+			require.ErrorAs(t, validateErr, &mismatch)
+			assert.Equal(t, string(tt.provider), mismatch.ProviderType)
+		})
+	}
+
+	t.Run("additional provider with the wrong config", func(t *testing.T) {
+		cfg := env.NewConfiguration()
+		cfg.StatePath = "/state"
+		cfg.CloudProviderType = cloud.AWS
+		cfg.CloudProviderTypes = []cloud.ProviderType{cloud.AWS, cloud.GCP}
+		cfg.CloudConfig = awsCfg
+		cfg.CloudConfigs = map[cloud.ProviderType]cloud.ProviderConfig{cloud.AWS: awsCfg, cloud.GCP: &awsConfig.Config{}}
+
+		assert.ErrorAs(t, cfg.ValidateGeneralConfig(), &err.ErrProviderConfigMismatch{})
+	})
+}
+
 func TestPortToString(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -514,3 +791,41 @@ func TestSetupConfigurationsLoadCloudConfigError(t *testing.T) {
 	assert.ErrorAs(t, setupErr, &unsupported, "error should be ErrUnsupportedProvider")
 	assert.EqualError(t, unsupported, "unsupported provider: invalid-provider")
 }
+
+func TestSettings(t *testing.T) {
+	configurations := env.Configurations{
+		StatePath:          "./samples/main.tf",
+		HttpPort:           9090,
+		ReportTitle:        "prod",
+		Severities:         map[string]driftchecker.Severity{"tags": driftchecker.SeverityInfo, "ami": driftchecker.SeverityCritical},
+		Comparators:        driftchecker.ComparatorOptions{"tags": {Strategy: driftchecker.StrategyPrefixIgnore, Prefix: "aws:"}},
+		CloudProviderType:  cloud.AWS,
+		CloudProviderTypes: []cloud.ProviderType{cloud.AWS, cloud.GCP},
+		CloudConfigs: map[cloud.ProviderType]cloud.ProviderConfig{
+			cloud.AWS: &awsConfig.Config{AccessKey: "AKIAEXAMPLE", SecretKey: "wJalrXUtnFEMI", SessionToken: "FwoGZXIvYXdz", Region: "us-east-1"},
+			cloud.GCP: &gcpConfig.Config{ProjectID: "project", Region: "us-central1", CredentialsJSON: `{"private_key":"-----BEGIN"}`},
+		},
+	}
+
+	values := make(map[string]string)
+	for _, setting := range configurations.Settings() {
+		values[setting.Name] = setting.Value
+	}
+
+	// Secrets are masked
+	assert.Equal(t, "AKIA****", values["AWS_ACCESS_KEY_ID"])
+	assert.Equal(t, "****", values["AWS_SECRET_ACCESS_KEY"])
+	assert.Equal(t, "****", values["AWS_SESSION_TOKEN"])
+	assert.Equal(t, "****", values["GOOGLE_CREDENTIALS_JSON"])
+
+	// Everything else is shown as configured
+	assert.Equal(t, "aws,gcp", values["CLOUD_PROVIDER"])
+	assert.Equal(t, "./samples/main.tf", values["STATE_PATH"])
+	assert.Equal(t, "9090", values["HTTP_PORT"])
+	assert.Equal(t, "prod", values["REPORT_TITLE"])
+	assert.Equal(t, "ami=critical,tags=info", values["SEVERITY"])
+	assert.Equal(t, "tags=prefix-ignore:aws:", values["COMPARATORS"])
+	assert.Equal(t, "us-east-1", values["AWS_REGION"])
+	assert.Equal(t, "project", values["GCP_PROJECT"])
+	assert.Equal(t, "", values["AWS_ENDPOINT_URL"])
+}
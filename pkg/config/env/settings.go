@@ -0,0 +1,148 @@
+package env
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/config/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
+	"github.com/oldmonad/ec2Drift/pkg/config/cloud/gcp"
+)
+
+// redacted replaces secrets that should not be shown at all
+const redacted = "****"
+
+// Setting is one resolved configuration value, named after the
+// environment variable it is read from
+type Setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Settings returns the configuration in effect, in the order of
+// .env.example. The access key keeps its first characters like the debug
+// log does; other secrets are fully redacted.
+func (c *Configurations) Settings() []Setting {
+	settings := []Setting{
+		{"CLOUD_PROVIDER", joinProviders(c.ProviderTypes())},
+		{"DEBUG", strconv.FormatBool(c.DebugMode)},
+		{"LOG_LEVEL", c.LogLevel},
+		{"OUTPUT_PATH", c.OutputPath},
+		{"STATE_PATH", c.StatePath},
+		{"CONFIG_PATH", c.ConfigPath},
+		{"HTTP_PORT", c.PortToString()},
+		{"HTTP_READ_TIMEOUT", formatDuration(c.HttpTimeouts.Read)},
+		{"HTTP_WRITE_TIMEOUT", formatDuration(c.HttpTimeouts.Write)},
+		{"HTTP_IDLE_TIMEOUT", formatDuration(c.HttpTimeouts.Idle)},
+		{"MAX_REPORTS", strconv.Itoa(c.MaxReports)},
+		{"REPORT_TITLE", c.ReportTitle},
+		{"SCHEDULE", c.Schedule},
+		{"SEVERITY", formatSeverities(c.Severities)},
+		{"COMPARATORS", formatComparators(c.Comparators)},
+	}
+
+	for _, provider := range c.ProviderTypes() {
+		cfg := c.CloudConfig
+		if providerCfg, ok := c.CloudConfigs[provider]; ok {
+			cfg = providerCfg
+		}
+		settings = append(settings, providerSettings(cfg)...)
+	}
+	return settings
+}
+
+// providerSettings lists the settings of a cloud provider config. Configs
+// of other types, such as test doubles, only show their region.
+func providerSettings(cfg cloud.ProviderConfig) []Setting {
+	switch cfg := cfg.(type) {
+	case *aws.Config:
+		var expires string
+		if !cfg.Expires.IsZero() {
+			expires = cfg.Expires.Format(time.RFC3339)
+		}
+		return []Setting{
+			{"AWS_ACCESS_KEY_ID", cloud.MaskSecret(cfg.AccessKey)},
+			{"AWS_SECRET_ACCESS_KEY", redact(cfg.SecretKey)},
+			{"AWS_REGION", cfg.Region},
+			{"AWS_SESSION_TOKEN", redact(cfg.SessionToken)},
+			{aws.CredentialExpirationEnv, expires},
+			{"AWS_PROFILE", cfg.Profile},
+			{"AWS_ENDPOINT_URL", cfg.EndpointURL},
+		}
+	case *gcp.Config:
+		return []Setting{
+			{"GCP_PROJECT", cfg.ProjectID},
+			{"GCP_REGION", cfg.Region},
+			{"GCP_ZONE", cfg.Zone},
+			{"GOOGLE_APPLICATION_CREDENTIALS", cfg.CredentialsFile},
+			{"GOOGLE_CREDENTIALS_JSON", redact(cfg.CredentialsJSON)},
+		}
+	case nil:
+		return nil
+	default:
+		return []Setting{{"REGION", cfg.GetRegion()}}
+	}
+}
+
+// redact hides a secret entirely, keeping unset values visibly empty
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+func joinProviders(providers []cloud.ProviderType) string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		if p != "" {
+			names = append(names, string(p))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// formatDuration prints a duration, leaving unset ones empty
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func formatSeverities(severities map[string]driftchecker.Severity) string {
+	pairs := make(map[string]string, len(severities))
+	for attr, severity := range severities {
+		pairs[attr] = string(severity)
+	}
+	return formatPairs(pairs)
+}
+
+func formatComparators(comparators driftchecker.ComparatorOptions) string {
+	pairs := make(map[string]string, len(comparators))
+	for attr, comparator := range comparators {
+		pairs[attr] = string(comparator.Strategy)
+		if comparator.Prefix != "" {
+			pairs[attr] += ":" + comparator.Prefix
+		}
+	}
+	return formatPairs(pairs)
+}
+
+// formatPairs writes a map back as the sorted key=value list it is parsed from
+func formatPairs(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + values[key]
+	}
+	return strings.Join(pairs, ",")
+}
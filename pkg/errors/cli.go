@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"time"
 )
 
 type CommandError struct {
@@ -15,3 +16,39 @@ func (e *CommandError) Error() string {
 func (e *CommandError) Unwrap() error {
 	return e.Err
 }
+
+// ErrRunTimeout is returned when a run exceeds its --timeout deadline.
+type ErrRunTimeout struct {
+	Timeout time.Duration
+	Err     error
+}
+
+func (e ErrRunTimeout) Error() string {
+	return fmt.Sprintf("drift check timed out after %s: %v", e.Timeout, e.Err)
+}
+
+func (e ErrRunTimeout) Unwrap() error {
+	return e.Err
+}
+
+func NewErrRunTimeout(timeout time.Duration, err error) error {
+	return ErrRunTimeout{Timeout: timeout, Err: err}
+}
+
+// ErrHook wraps failures of the --exec command run after a drift check.
+type ErrHook struct {
+	Command string
+	Err     error
+}
+
+func (e ErrHook) Error() string {
+	return fmt.Sprintf("exec hook %q: %v", e.Command, e.Err)
+}
+
+func (e ErrHook) Unwrap() error {
+	return e.Err
+}
+
+func NewHookError(command string, err error) error {
+	return ErrHook{Command: command, Err: err}
+}
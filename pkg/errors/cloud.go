@@ -51,6 +51,22 @@ func NewDescribeInstances(err error) error {
 	return ErrDescribeInstances{Err: err}
 }
 
+// ErrTooManyInstances is returned when the live fetch would exceed
+// --max-instances. Count is the number of instances seen so far.
+type ErrTooManyInstances struct {
+	Limit int
+	Count int
+}
+
+func (e ErrTooManyInstances) Error() string {
+	return fmt.Sprintf("found at least %d live instances, more than --max-instances %d; "+
+		"narrow the fetch with --vpc-id or --subnet-id, or raise the limit", e.Count, e.Limit)
+}
+
+func NewErrTooManyInstances(limit, count int) error {
+	return ErrTooManyInstances{Limit: limit, Count: count}
+}
+
 // ErrDescribeVolumes wraps failures or empty results in DescribeVolumes.
 type ErrDescribeVolumes struct {
 	VolumeID string
@@ -72,6 +88,25 @@ func NewDescribeVolumes(volID string, err error) error {
 	return ErrDescribeVolumes{VolumeID: volID, Err: err}
 }
 
+// ErrDescribeInstanceAttribute wraps a failed DescribeInstanceAttribute call.
+type ErrDescribeInstanceAttribute struct {
+	InstanceID string
+	Attribute  string
+	Err        error
+}
+
+func (e ErrDescribeInstanceAttribute) Error() string {
+	return fmt.Sprintf("failed to describe %s of instance %s: %v", e.Attribute, e.InstanceID, e.Err)
+}
+
+func (e ErrDescribeInstanceAttribute) Unwrap() error {
+	return e.Err
+}
+
+func NewDescribeInstanceAttribute(instanceID, attribute string, err error) error {
+	return ErrDescribeInstanceAttribute{InstanceID: instanceID, Attribute: attribute, Err: err}
+}
+
 // ErrMapInstance covers any unexpected mapping failure.
 type ErrMapInstance struct {
 	InstanceID string
@@ -2,6 +2,8 @@ package errors
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 // ErrAWSConfigValidation is returned when AWS provider config fails Validate().
@@ -51,6 +53,21 @@ func NewUnsupportedProvider(pt string) error {
 	return ErrUnsupportedProvider{ProviderType: pt}
 }
 
+// ErrProviderConfigMismatch is returned when the loaded cloud config belongs
+// to a different provider than CLOUD_PROVIDER names.
+type ErrProviderConfigMismatch struct {
+	ProviderType string
+	ConfigType   string
+}
+
+func (e ErrProviderConfigMismatch) Error() string {
+	return fmt.Sprintf("cloud provider %q was configured with a %s config", e.ProviderType, e.ConfigType)
+}
+
+func NewErrProviderConfigMismatch(providerType, configType string) error {
+	return ErrProviderConfigMismatch{ProviderType: providerType, ConfigType: configType}
+}
+
 // ErrDebugParse wraps failures parsing the DEBUG env var.
 type ErrDebugParse struct {
 	RawValue string
@@ -80,38 +97,79 @@ func NewErrMissingCloudProvider() error {
 	return ErrMissingCloudProvider{}
 }
 
-// ErrPortParse wraps failures parsing HTTP_PORT.
+// ErrPortParse wraps failures parsing HTTP_PORT or the --port flag.
 type ErrPortParse struct {
+	Source   string // HTTP_PORT or --port
 	RawValue string
 	Err      error
 }
 
 func (e ErrPortParse) Error() string {
-	return fmt.Sprintf("invalid HTTP_PORT=%q: %v", e.RawValue, e.Err)
+	return fmt.Sprintf("invalid %s=%q: %v", e.Source, e.RawValue, e.Err)
 }
 
 func (e ErrPortParse) Unwrap() error {
 	return e.Err
 }
 
-func NewErrPortParse(raw string, err error) error {
-	return ErrPortParse{RawValue: raw, Err: err}
+func NewErrPortParse(source, raw string, err error) error {
+	return ErrPortParse{Source: source, RawValue: raw, Err: err}
+}
+
+// ErrTimeoutParse wraps failures parsing one of the HTTP_*_TIMEOUT
+// durations.
+type ErrTimeoutParse struct {
+	Name     string
+	RawValue string
+	Err      error
+}
+
+func (e ErrTimeoutParse) Error() string {
+	return fmt.Sprintf("invalid %s=%q: %v", e.Name, e.RawValue, e.Err)
+}
+
+func (e ErrTimeoutParse) Unwrap() error {
+	return e.Err
+}
+
+func NewErrTimeoutParse(name, raw string, err error) error {
+	return ErrTimeoutParse{Name: name, RawValue: raw, Err: err}
+}
+
+// ErrMaxReportsParse wraps an invalid MAX_REPORTS value
+type ErrMaxReportsParse struct {
+	RawValue string
+	Err      error
+}
+
+func (e ErrMaxReportsParse) Error() string {
+	return fmt.Sprintf("invalid MAX_REPORTS=%q: %v", e.RawValue, e.Err)
+}
+
+func (e ErrMaxReportsParse) Unwrap() error {
+	return e.Err
+}
+
+func NewErrMaxReportsParse(raw string, err error) error {
+	return ErrMaxReportsParse{RawValue: raw, Err: err}
 }
 
-// ErrPortOutOfRange indicates HTTP_PORT is outside 1–65535.
+// ErrPortOutOfRange indicates HTTP_PORT or the --port flag is outside 1–65535.
 type ErrPortOutOfRange struct {
-	Port int
+	Source string // HTTP_PORT or --port
+	Port   int
 }
 
 func (e ErrPortOutOfRange) Error() string {
-	return fmt.Sprintf("HTTP_PORT out of bounds: %d (must be 1–65535)", e.Port)
+	return fmt.Sprintf("%s out of bounds: %d (must be 1–65535)", e.Source, e.Port)
 }
 
-func NewErrPortOutOfRange(port int) error {
-	return ErrPortOutOfRange{Port: port}
+func NewErrPortOutOfRange(source string, port int) error {
+	return ErrPortOutOfRange{Source: source, Port: port}
 }
 
-// ErrMissingPaths is returned when STATE_PATH or OUTPUT_PATH are unset.
+// ErrMissingPaths is returned when a check reads the desired config from
+// STATE_PATH and it is unset.
 type ErrMissingPaths struct{}
 
 func (e ErrMissingPaths) Error() string {
@@ -122,6 +180,18 @@ func NewErrMissingPaths() error {
 	return ErrMissingPaths{}
 }
 
+// ErrConflictingConfigSources is returned when a check is given an inline
+// desired config together with STATE_PATH, config files or a baseline
+type ErrConflictingConfigSources struct{}
+
+func (e ErrConflictingConfigSources) Error() string {
+	return "only one desired config source can be used: inline config, STATE_PATH, config files or a baseline"
+}
+
+func NewErrConflictingConfigSources() error {
+	return ErrConflictingConfigSources{}
+}
+
 // ErrCloudConfigNotInit indicates loadCloudConfig wasn’t called or failed.
 type ErrCloudConfigNotInit struct{}
 
@@ -203,6 +273,26 @@ func NewErrMissingCredentials(missing []string) error {
 	return ErrMissingCredentials{Missing: missing}
 }
 
+// ErrSecretFile wraps failures reading a credential from the file named by
+// a _FILE variable, e.g. AWS_SECRET_ACCESS_KEY_FILE.
+type ErrSecretFile struct {
+	Variable string
+	Path     string
+	Err      error
+}
+
+func (e ErrSecretFile) Error() string {
+	return fmt.Sprintf("read %s %s: %v", e.Variable, e.Path, e.Err)
+}
+
+func (e ErrSecretFile) Unwrap() error {
+	return e.Err
+}
+
+func NewErrSecretFile(variable, path string, err error) error {
+	return ErrSecretFile{Variable: variable, Path: path, Err: err}
+}
+
 // ErrMissingGCPConfig indicates that one or more required GCP environment
 // variables were not set.
 type ErrMissingGCPConfig struct {
@@ -222,6 +312,20 @@ func NewErrMissingGCPConfig(missing []string) error {
 	return ErrMissingGCPConfig{Missing: missing}
 }
 
+// ErrInvalidAccessKeyFormat indicates AWS_ACCESS_KEY_ID is set but cannot
+// be an access key ID
+type ErrInvalidAccessKeyFormat struct {
+	Reason string
+}
+
+func (e ErrInvalidAccessKeyFormat) Error() string {
+	return fmt.Sprintf("invalid AWS_ACCESS_KEY_ID: %s", e.Reason)
+}
+
+func NewErrInvalidAccessKeyFormat(reason string) error {
+	return ErrInvalidAccessKeyFormat{Reason: reason}
+}
+
 type InvalidConfigCredential struct {
 	Err string
 }
@@ -233,3 +337,79 @@ func (e InvalidConfigCredential) Error() string {
 func NewInvalidConfigCredential(err string) error {
 	return InvalidConfigCredential{Err: err}
 }
+
+// ErrPageSizeOutOfRange indicates --page-size is outside what AWS accepts
+// for DescribeInstances MaxResults.
+type ErrPageSizeOutOfRange struct {
+	Size     int
+	Min, Max int
+}
+
+func (e ErrPageSizeOutOfRange) Error() string {
+	return fmt.Sprintf("page size out of bounds: %d (must be %d–%d)", e.Size, e.Min, e.Max)
+}
+
+func NewErrPageSizeOutOfRange(size, min, max int) error {
+	return ErrPageSizeOutOfRange{Size: size, Min: min, Max: max}
+}
+
+// ErrInvalidSchedule indicates SCHEDULE is neither a duration nor a valid
+// cron expression.
+type ErrInvalidSchedule struct {
+	Spec   string
+	Reason string
+}
+
+func (e ErrInvalidSchedule) Error() string {
+	return fmt.Sprintf("invalid SCHEDULE %q: %s", e.Spec, e.Reason)
+}
+
+func NewErrInvalidSchedule(spec, reason string) error {
+	return ErrInvalidSchedule{Spec: spec, Reason: reason}
+}
+
+// ErrInvalidSeverityMapping indicates an entry of SEVERITY is not of the
+// form attribute=severity.
+type ErrInvalidSeverityMapping struct {
+	Entry string
+}
+
+func (e ErrInvalidSeverityMapping) Error() string {
+	return fmt.Sprintf("invalid SEVERITY entry %q: expected attribute=severity", e.Entry)
+}
+
+func NewErrInvalidSeverityMapping(entry string) error {
+	return ErrInvalidSeverityMapping{Entry: entry}
+}
+
+// ErrCredentialsExpiringSoon indicates the temporary AWS credentials expire
+// within the buffer required before a run starts.
+type ErrCredentialsExpiringSoon struct {
+	Expires time.Time
+	Buffer  time.Duration
+}
+
+func (e ErrCredentialsExpiringSoon) Error() string {
+	return fmt.Sprintf("AWS credentials expire at %s, less than %s from now; refresh them before running",
+		e.Expires.Format(time.RFC3339), e.Buffer)
+}
+
+func NewErrCredentialsExpiringSoon(expires time.Time, buffer time.Duration) error {
+	return ErrCredentialsExpiringSoon{Expires: expires, Buffer: buffer}
+}
+
+// ErrInvalidComparator indicates an entry of COMPARATORS is not of the form
+// attribute=strategy or names an unknown strategy.
+type ErrInvalidComparator struct {
+	Entry      string
+	Strategies []string
+}
+
+func (e ErrInvalidComparator) Error() string {
+	return fmt.Sprintf("invalid COMPARATORS entry %q: expected attribute=strategy with a strategy of %s",
+		e.Entry, strings.Join(e.Strategies, ", "))
+}
+
+func NewErrInvalidComparator(entry string, strategies []string) error {
+	return ErrInvalidComparator{Entry: entry, Strategies: strategies}
+}
@@ -16,3 +16,15 @@ func NewDriftDetected() error {
 		Message: "drift detected",
 	}
 }
+
+// ErrNoMatchingInstances is returned when the instance selection of a run,
+// e.g. --instances or --vpc-id, matches no live instance.
+type ErrNoMatchingInstances struct{}
+
+func (e ErrNoMatchingInstances) Error() string {
+	return "no live instance matched the instance selection"
+}
+
+func NewErrNoMatchingInstances() error {
+	return ErrNoMatchingInstances{}
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 )
@@ -79,3 +80,50 @@ type ErrInvalidTagsType struct {
 func (e ErrInvalidTagsType) Error() string {
 	return fmt.Sprintf("resource %q: tags must be a map[string]string", e.ResourceName)
 }
+
+// ErrUndefinedEnvVars is returned when the desired config references
+// environment variables that are not set.
+type ErrUndefinedEnvVars struct {
+	Names []string
+}
+
+func (e ErrUndefinedEnvVars) Error() string {
+	return fmt.Sprintf("undefined environment variables in config: %s", strings.Join(e.Names, ", "))
+}
+
+func NewUndefinedEnvVars(names []string) error {
+	return ErrUndefinedEnvVars{Names: names}
+}
+
+// ErrUnknownParser is returned when no parser is registered for a format.
+type ErrUnknownParser struct {
+	Format     string
+	Registered []string
+}
+
+func (e ErrUnknownParser) Error() string {
+	return fmt.Sprintf("no parser registered for format %q (registered: %s)", e.Format, strings.Join(e.Registered, ", "))
+}
+
+func NewErrUnknownParser(format string, registered []string) error {
+	return ErrUnknownParser{Format: format, Registered: registered}
+}
+
+// ErrConfigParse is returned when a desired config cannot be parsed, a
+// problem with the config rather than with the application
+type ErrConfigParse struct {
+	Parser string
+	Err    error
+}
+
+func (e ErrConfigParse) Error() string {
+	return fmt.Sprintf("failed to parse %s config: %v", e.Parser, e.Err)
+}
+
+func (e ErrConfigParse) Unwrap() error {
+	return e.Err
+}
+
+func NewErrConfigParse(parser string, err error) error {
+	return ErrConfigParse{Parser: parser, Err: err}
+}
@@ -2,21 +2,23 @@ package errors
 
 import "fmt"
 
-// ErrEnvLoad wraps failures loading the .env file.
+// ErrEnvLoad wraps failures loading an existing .env file, such as a line
+// that cannot be parsed.
 type ErrEnvLoad struct {
-	Err error
+	Path string
+	Err  error
 }
 
 func (e ErrEnvLoad) Error() string {
-	return fmt.Sprintf("error loading .env: %v", e.Err)
+	return fmt.Sprintf("error loading %s: %v", e.Path, e.Err)
 }
 
 func (e ErrEnvLoad) Unwrap() error {
 	return e.Err
 }
 
-func NewErrEnvLoad(err error) error {
-	return ErrEnvLoad{Err: err}
+func NewErrEnvLoad(path string, err error) error {
+	return ErrEnvLoad{Path: path, Err: err}
 }
 
 // ErrConfigSetup wraps failures in SetupConfigurations.
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type ErrReadFile struct {
 	Err error
@@ -17,3 +20,102 @@ func (e ErrReadFile) Unwrap() error {
 func NewReadFileError(err error) error {
 	return ErrReadFile{Err: err}
 }
+
+// ErrWriteOutput wraps failures writing a drift report to a file.
+type ErrWriteOutput struct {
+	Path string
+	Err  error
+}
+
+func (e ErrWriteOutput) Error() string {
+	return fmt.Sprintf("write output %s: %v", e.Path, e.Err)
+}
+
+func (e ErrWriteOutput) Unwrap() error {
+	return e.Err
+}
+
+func NewWriteOutputError(path string, err error) error {
+	return ErrWriteOutput{Path: path, Err: err}
+}
+
+// ErrOutputFileCollision is returned when several applications would be
+// written to the same file of an output directory.
+type ErrOutputFileCollision struct {
+	Path         string
+	Applications []string
+}
+
+func (e ErrOutputFileCollision) Error() string {
+	return fmt.Sprintf("write output %s: applications %s map to the same file", e.Path, strings.Join(e.Applications, ", "))
+}
+
+func NewOutputFileCollision(path string, applications []string) error {
+	return ErrOutputFileCollision{Path: path, Applications: applications}
+}
+
+// ErrGitSource wraps failures reading the desired config from a git reference.
+type ErrGitSource struct {
+	Source string
+	Err    error
+}
+
+func (e ErrGitSource) Error() string {
+	return fmt.Sprintf("git source %s: %v", e.Source, e.Err)
+}
+
+func (e ErrGitSource) Unwrap() error {
+	return e.Err
+}
+
+func NewGitSourceError(source string, err error) error {
+	return ErrGitSource{Source: source, Err: err}
+}
+
+// ErrEmptyStateFile indicates the desired state file is empty or only holds
+// whitespace, which parsers would otherwise report as malformed.
+type ErrEmptyStateFile struct {
+	Path string
+}
+
+func (e ErrEmptyStateFile) Error() string {
+	return fmt.Sprintf("state file %s is empty", e.Path)
+}
+
+func NewErrEmptyStateFile(path string) error {
+	return ErrEmptyStateFile{Path: path}
+}
+
+// ErrNoConfigFiles indicates a config directory holds no file of the
+// selected input format.
+type ErrNoConfigFiles struct {
+	Dir       string
+	Extension string
+}
+
+func (e ErrNoConfigFiles) Error() string {
+	return fmt.Sprintf("no %s files in config directory %s", e.Extension, e.Dir)
+}
+
+func NewErrNoConfigFiles(dir, extension string) error {
+	return ErrNoConfigFiles{Dir: dir, Extension: extension}
+}
+
+// ErrDuplicateInstanceName indicates two merged config files declare an
+// instance with the same Name tag, which matching could not tell apart.
+type ErrDuplicateInstanceName struct {
+	Name   string
+	First  string
+	Second string
+}
+
+func (e ErrDuplicateInstanceName) Error() string {
+	if e.First == e.Second {
+		return fmt.Sprintf("instance name %q is declared twice in %s", e.Name, e.First)
+	}
+	return fmt.Sprintf("instance name %q is declared in both %s and %s", e.Name, e.First, e.Second)
+}
+
+func NewErrDuplicateInstanceName(name, first, second string) error {
+	return ErrDuplicateInstanceName{Name: name, First: first, Second: second}
+}
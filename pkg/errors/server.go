@@ -70,3 +70,18 @@ func (e ErrAppRun) Unwrap() error {
 func NewErrAppRun(err error) error {
 	return ErrAppRun{Err: err}
 }
+
+// ErrInvalidRequest describes a request body that is valid JSON but does
+// not match the expected schema.
+type ErrInvalidRequest struct {
+	Field  string
+	Reason string
+}
+
+func (e ErrInvalidRequest) Error() string {
+	return fmt.Sprintf("invalid request: %q %s", e.Field, e.Reason)
+}
+
+func NewErrInvalidRequest(field, reason string) error {
+	return ErrInvalidRequest{Field: field, Reason: reason}
+}
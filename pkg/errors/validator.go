@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // ErrFormatValidation wraps a format validation error
 type ErrFormatValidation struct {
@@ -39,6 +43,7 @@ func NewAttributeValidationError(err error) error {
 type InvalidAttributesError struct {
 	InvalidAttrs []string
 	ValidAttrs   []string
+	Aliases      map[string]string // Accepted synonyms, alias -> valid attribute
 }
 
 func (e *InvalidAttributesError) Error() string {
@@ -46,5 +51,165 @@ func (e *InvalidAttributesError) Error() string {
 	for _, attr := range e.ValidAttrs {
 		validFormatted += fmt.Sprintf("  - %s\n", attr)
 	}
-	return fmt.Sprintf("invalid attributes: %v\nValid options:\n%s", e.InvalidAttrs, validFormatted)
+	msg := fmt.Sprintf("invalid attributes: %v\nValid options:\n%s", e.InvalidAttrs, validFormatted)
+	if len(e.Aliases) > 0 {
+		msg += fmt.Sprintf("Aliases: %s\n", strings.Join(FormatAliases(e.Aliases), ", "))
+	}
+	return msg
+}
+
+// FormatAliases lists aliases as "alias=attribute" pairs sorted by alias
+func FormatAliases(aliases map[string]string) []string {
+	pairs := make([]string, 0, len(aliases))
+	for alias, attr := range aliases {
+		pairs = append(pairs, alias+"="+attr)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// ErrNoAttributesSelected is returned when the effective set of attributes
+// to compare is empty, which would otherwise always report "no drift".
+type ErrNoAttributesSelected struct{}
+
+func (e ErrNoAttributesSelected) Error() string {
+	return "no attributes selected for drift detection"
+}
+
+func NewErrNoAttributesSelected() error {
+	return ErrNoAttributesSelected{}
+}
+
+// ErrUnknownPreset is returned for a --preset name that names no attribute
+// preset
+type ErrUnknownPreset struct {
+	Preset string
+	Valid  []string
+}
+
+func (e ErrUnknownPreset) Error() string {
+	return fmt.Sprintf("unknown attribute preset %q, valid presets: %s", e.Preset, strings.Join(e.Valid, ", "))
+}
+
+func NewErrUnknownPreset(preset string, valid []string) error {
+	return ErrUnknownPreset{Preset: preset, Valid: valid}
+}
+
+// ErrUnsupportedOutputFormat is returned for an unknown --output value.
+type ErrUnsupportedOutputFormat struct {
+	Format    string
+	Supported []string
+}
+
+func (e ErrUnsupportedOutputFormat) Error() string {
+	return fmt.Sprintf("unsupported output format %q (supported: %s)", e.Format, strings.Join(e.Supported, ", "))
+}
+
+func NewUnsupportedOutputFormat(format string, supported []string) error {
+	return ErrUnsupportedOutputFormat{Format: format, Supported: supported}
+}
+
+// ErrUnsupportedColorMode is returned for an unknown --color value.
+type ErrUnsupportedColorMode struct {
+	Mode      string
+	Supported []string
+}
+
+func (e ErrUnsupportedColorMode) Error() string {
+	return fmt.Sprintf("unsupported color mode %q (supported: %s)", e.Mode, strings.Join(e.Supported, ", "))
+}
+
+func NewUnsupportedColorMode(mode string, supported []string) error {
+	return ErrUnsupportedColorMode{Mode: mode, Supported: supported}
+}
+
+// ErrUnsupportedGroupBy is returned for an unknown --group-by value.
+type ErrUnsupportedGroupBy struct {
+	GroupBy   string
+	Supported []string
+}
+
+func (e ErrUnsupportedGroupBy) Error() string {
+	return fmt.Sprintf("unsupported grouping %q (supported: %s)", e.GroupBy, strings.Join(e.Supported, ", "))
+}
+
+func NewUnsupportedGroupBy(groupBy string, supported []string) error {
+	return ErrUnsupportedGroupBy{GroupBy: groupBy, Supported: supported}
+}
+
+// ErrOutputDirNeedsGroupBy is returned when --output-dir is used without
+// --group-by application, which decides the file of each report.
+type ErrOutputDirNeedsGroupBy struct {
+	Dir string
+}
+
+func (e ErrOutputDirNeedsGroupBy) Error() string {
+	return fmt.Sprintf("output directory %s writes one file per application and needs --group-by application", e.Dir)
+}
+
+func NewOutputDirNeedsGroupBy(dir string) error {
+	return ErrOutputDirNeedsGroupBy{Dir: dir}
+}
+
+// ErrUnsupportedInstanceState is returned for an unknown --expected-state.
+type ErrUnsupportedInstanceState struct {
+	State     string
+	Supported []string
+}
+
+func (e ErrUnsupportedInstanceState) Error() string {
+	return fmt.Sprintf("unsupported instance state %q (supported: %s)", e.State, strings.Join(e.Supported, ", "))
+}
+
+func NewUnsupportedInstanceState(state string, supported []string) error {
+	return ErrUnsupportedInstanceState{State: state, Supported: supported}
+}
+
+// ErrUnsupportedSeverity is returned for an unknown severity level.
+type ErrUnsupportedSeverity struct {
+	Severity  string
+	Supported []string
+}
+
+func (e ErrUnsupportedSeverity) Error() string {
+	return fmt.Sprintf("unsupported severity %q (supported: %s)", e.Severity, strings.Join(e.Supported, ", "))
+}
+
+func NewUnsupportedSeverity(severity string, supported []string) error {
+	return ErrUnsupportedSeverity{Severity: severity, Supported: supported}
+}
+
+// ErrInvalidTemplate wraps failures reading or parsing a --template-file.
+type ErrInvalidTemplate struct {
+	Path string
+	Err  error
+}
+
+func (e ErrInvalidTemplate) Error() string {
+	return fmt.Sprintf("invalid report template %s: %v", e.Path, e.Err)
+}
+
+func (e ErrInvalidTemplate) Unwrap() error {
+	return e.Err
+}
+
+func NewErrInvalidTemplate(path string, err error) error {
+	return ErrInvalidTemplate{Path: path, Err: err}
+}
+
+// ErrTemplateFile is returned when --output template lacks a
+// --template-file, or a --template-file is given with another format.
+type ErrTemplateFile struct {
+	Format string
+}
+
+func (e ErrTemplateFile) Error() string {
+	if e.Format == "template" {
+		return "--output template requires --template-file"
+	}
+	return fmt.Sprintf("--template-file only applies to --output template, not %q", e.Format)
+}
+
+func NewErrTemplateFile(format string) error {
+	return ErrTemplateFile{Format: format}
 }
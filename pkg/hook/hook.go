@@ -0,0 +1,68 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// DefaultTimeout bounds a hook command when no timeout is given
+const DefaultTimeout = 30 * time.Second
+
+// Environment variables set for the hook command on top of the inherited ones
+const (
+	DriftDetectedEnv = "EC2DRIFT_DRIFT_DETECTED"
+	ReportCountEnv   = "EC2DRIFT_REPORT_COUNT"
+	ReportTitleEnv   = "EC2DRIFT_REPORT_TITLE"
+)
+
+// Result describes a finished drift check to the hook command
+type Result struct {
+	DriftDetected bool   // Whether the run counts as drift
+	ReportCount   int    // Number of reports, including clean and unmanaged ones
+	Title         string // Report title, empty when none is set
+}
+
+// Environ returns the variables describing the result, as KEY=value pairs
+func (r Result) Environ() []string {
+	return []string{
+		fmt.Sprintf("%s=%t", DriftDetectedEnv, r.DriftDetected),
+		fmt.Sprintf("%s=%d", ReportCountEnv, r.ReportCount),
+		fmt.Sprintf("%s=%s", ReportTitleEnv, r.Title),
+	}
+}
+
+// Run runs command through sh with the report on its stdin and the result
+// in its environment. The command is killed after timeout, DefaultTimeout
+// when zero. The combined stdout and stderr is returned even on failure so
+// callers can log it.
+func Run(ctx context.Context, command string, report io.Reader, result Result, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), result.Environ()...)
+	cmd.Stdin = report
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Do not wait for grandchildren holding the output pipes once killed
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s: %w", timeout, err)
+		}
+		return out.Bytes(), errors.NewHookError(command, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package hook_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	customErr "github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/hook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes an executable shell script to a temporary directory
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Run("passes the report on stdin and the result in the environment", func(t *testing.T) {
+		dir := t.TempDir()
+		script := writeScript(t, `cat > "$1/report.json"
+echo "drift=$EC2DRIFT_DRIFT_DETECTED count=$EC2DRIFT_REPORT_COUNT title=$EC2DRIFT_REPORT_TITLE"
+`)
+		result := hook.Result{DriftDetected: true, ReportCount: 2, Title: "prod"}
+
+		out, err := hook.Run(context.Background(), script+" "+dir, strings.NewReader(`[{"instance_id":"i-1"}]`), result, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "drift=true count=2 title=prod\n", string(out))
+
+		report, err := os.ReadFile(filepath.Join(dir, "report.json"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"instance_id":"i-1"}]`, string(report))
+	})
+
+	t.Run("returns the output of a failing command", func(t *testing.T) {
+		script := writeScript(t, "echo 'slack webhook rejected' >&2\nexit 3\n")
+
+		out, err := hook.Run(context.Background(), script, strings.NewReader("[]"), hook.Result{}, 0)
+		require.Error(t, err)
+		var hookErr customErr.ErrHook
+		require.ErrorAs(t, err, &hookErr)
+		assert.Equal(t, script, hookErr.Command)
+		assert.Equal(t, "slack webhook rejected\n", string(out))
+	})
+
+	t.Run("kills a command exceeding the timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := hook.Run(context.Background(), "sleep 5", strings.NewReader(""), hook.Result{}, 50*time.Millisecond)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out after 50ms")
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+}
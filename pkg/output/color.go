@@ -0,0 +1,57 @@
+package output
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// ColorMode controls whether reports are colored
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"   // Color only when writing to a terminal and NO_COLOR is unset
+	ColorAlways ColorMode = "always" // Color even when piped or NO_COLOR is set
+	ColorNever  ColorMode = "never"  // Never color
+)
+
+// ColorModes returns the accepted --color values.
+func ColorModes() []string {
+	return []string{string(ColorAuto), string(ColorAlways), string(ColorNever)}
+}
+
+// ParseColorMode resolves a --color value case-insensitively.
+func ParseColorMode(name string) (ColorMode, error) {
+	for _, m := range ColorModes() {
+		if strings.EqualFold(name, m) {
+			return ColorMode(m), nil
+		}
+	}
+	return "", errors.NewUnsupportedColorMode(name, ColorModes())
+}
+
+// ApplyColorMode sets color.NoColor for output written to w. It must run
+// before any report is printed.
+func ApplyColorMode(mode ColorMode, w io.Writer) {
+	switch mode {
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	default:
+		color.NoColor = os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isTerminal(w)
+	}
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
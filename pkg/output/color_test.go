@@ -0,0 +1,57 @@
+package output_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyColorMode(t *testing.T) {
+	original := color.NoColor
+	t.Cleanup(func() { color.NoColor = original })
+
+	r, pipe, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close(); pipe.Close() })
+
+	tests := []struct {
+		name     string
+		mode     output.ColorMode
+		noColor  string
+		expected bool // expected color.NoColor
+	}{
+		{name: "auto without a terminal", mode: output.ColorAuto, expected: true},
+		{name: "always without a terminal", mode: output.ColorAlways, expected: false},
+		{name: "always overrides NO_COLOR", mode: output.ColorAlways, noColor: "1", expected: false},
+		{name: "never", mode: output.ColorNever, expected: true},
+		{name: "auto honours NO_COLOR", mode: output.ColorAuto, noColor: "1", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			// Neither a buffer nor a pipe is a terminal
+			for _, w := range []interface{ Write([]byte) (int, error) }{&bytes.Buffer{}, pipe} {
+				color.NoColor = !tt.expected
+				output.ApplyColorMode(tt.mode, w)
+				assert.Equal(t, tt.expected, color.NoColor)
+			}
+		})
+	}
+}
+
+func TestParseColorMode(t *testing.T) {
+	mode, err := output.ParseColorMode("Always")
+	require.NoError(t, err)
+	assert.Equal(t, output.ColorAlways, mode)
+
+	_, err = output.ParseColorMode("sometimes")
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedColorMode{})
+}
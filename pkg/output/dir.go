@@ -0,0 +1,74 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// WriteDir writes the reports of each application to its own file in dir,
+// named after the application with the extension of the format, e.g.
+// web-server.json. The directory is created if missing. Every file is
+// rendered with opts, so a JSON file grouped by application holds a single
+// group. Nothing is written when two applications map to the same file.
+func WriteDir(dir string, format Format, reports []driftchecker.DriftReport, opts ...RenderOption) ([]string, error) {
+	groups := GroupByApplication(reports)
+	paths := make([]string, len(groups))
+	claimed := make(map[string][]string, len(groups))
+	for i, group := range groups {
+		paths[i] = filepath.Join(dir, applicationFileName(group.Application)+formatExtension(format))
+		// Compared case-insensitively, as on macOS and Windows file systems
+		key := strings.ToLower(paths[i])
+		claimed[key] = append(claimed[key], group.Application)
+	}
+	for i, path := range paths {
+		if applications := claimed[strings.ToLower(path)]; len(applications) > 1 {
+			sort.Strings(applications)
+			return nil, errors.NewOutputFileCollision(paths[i], applications)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.NewWriteOutputError(dir, err)
+	}
+	for i, group := range groups {
+		if err := WriteFile(paths[i], format, group.Instances, opts...); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// applicationFileName replaces the characters of an application name that
+// are unsafe in file names, such as path separators, with underscores
+func applicationFileName(application string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, application)
+}
+
+// formatExtension returns the file extension FormatFromPath reads back as
+// the format; formats without one use .txt
+func formatExtension(format Format) string {
+	switch format {
+	case JSON:
+		return ".json"
+	case CSV:
+		return ".csv"
+	case HTML:
+		return ".html"
+	case SARIF:
+		return ".sarif"
+	default:
+		return ".txt"
+	}
+}
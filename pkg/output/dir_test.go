@@ -0,0 +1,65 @@
+package output_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+
+	paths, err := output.WriteDir(dir, output.JSON, fleetReports(), output.WithGroupBy(output.GroupApplication))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "_unnamed_.json"),
+		filepath.Join(dir, "api.json"),
+		filepath.Join(dir, "web.json"),
+	}, paths)
+
+	data, err := os.ReadFile(filepath.Join(dir, "web.json"))
+	require.NoError(t, err)
+	var document output.GroupedDocument
+	require.NoError(t, json.Unmarshal(data, &document))
+	require.Len(t, document.Groups, 1)
+	assert.Equal(t, "web", document.Groups[0].Application)
+	require.Len(t, document.Groups[0].Instances, 2)
+	assert.Equal(t, "i-2", document.Groups[0].Instances[0].InstanceID)
+	assert.Equal(t, "i-3", document.Groups[0].Instances[1].InstanceID)
+
+	html, err := output.WriteDir(dir, output.HTML, fleetReports()[1:2])
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "api.html")}, html)
+}
+
+func TestWriteDirFileNames(t *testing.T) {
+	dir := t.TempDir()
+	reports := []driftchecker.DriftReport{{InstanceID: "i-1", Name: "team/web app"}}
+
+	paths, err := output.WriteDir(dir, output.JSON, reports)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "team_web_app.json")}, paths)
+}
+
+func TestWriteDirCollision(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	reports := []driftchecker.DriftReport{
+		{InstanceID: "i-1", Name: "team/web"},
+		{InstanceID: "i-2", Name: "team_web"},
+		{InstanceID: "i-3", Name: "api"},
+	}
+
+	_, err := output.WriteDir(dir, output.JSON, reports)
+	require.ErrorAs(t, err, &errors.ErrOutputFileCollision{})
+	assert.EqualError(t, err, "write output "+filepath.Join(dir, "team_web.json")+": applications team/web, team_web map to the same file")
+
+	_, statErr := os.Stat(dir)
+	assert.True(t, os.IsNotExist(statErr), "nothing is written on a collision")
+}
@@ -0,0 +1,22 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+)
+
+// PrintExplanations writes the instance matching explanations to stdout as
+// an indented JSON array.
+func PrintExplanations(explanations []driftchecker.MatchExplanation) error {
+	return WriteExplanations(os.Stdout, explanations)
+}
+
+// WriteExplanations works like PrintExplanations but writes to w
+func WriteExplanations(w io.Writer, explanations []driftchecker.MatchExplanation) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(explanations)
+}
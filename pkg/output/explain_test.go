@@ -0,0 +1,25 @@
+package output_test
+
+import (
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintExplanations(t *testing.T) {
+	explanations := []driftchecker.MatchExplanation{
+		{InstanceID: "i-123", Name: "web", MatchKey: "tags.Name", Matched: true, DesiredID: "web", Reason: driftchecker.ReasonMatched},
+		{InstanceID: "i-789", MatchKey: "tags.Name", Reason: driftchecker.ReasonMissingNameTag},
+	}
+
+	out := captureOutput(func() {
+		assert.NoError(t, output.PrintExplanations(explanations))
+	})
+
+	assert.JSONEq(t, `[
+		{"instance_id":"i-123","name":"web","match_key":"tags.Name","matched":true,"desired_id":"web","reason":"matched"},
+		{"instance_id":"i-789","name":"","match_key":"tags.Name","matched":false,"reason":"missing_name_tag"}
+	]`, out)
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// GroupBy selects how the table and JSON formats organize reports
+type GroupBy string
+
+const (
+	GroupNone        GroupBy = ""            // One entry per instance, the default
+	GroupApplication GroupBy = "application" // One section per application name
+)
+
+// unnamedApplication labels the group of instances without a Name tag
+const unnamedApplication = "(unnamed)"
+
+// GroupByModes returns the accepted --group-by values.
+func GroupByModes() []string {
+	return []string{string(GroupApplication)}
+}
+
+// ParseGroupBy resolves a --group-by value case-insensitively. An empty
+// name keeps reports ungrouped.
+func ParseGroupBy(name string) (GroupBy, error) {
+	if name == "" {
+		return GroupNone, nil
+	}
+	for _, g := range GroupByModes() {
+		if strings.EqualFold(name, g) {
+			return GroupBy(g), nil
+		}
+	}
+	return "", errors.NewUnsupportedGroupBy(name, GroupByModes())
+}
+
+// ApplicationGroup holds the reports of the instances sharing an
+// application name, the Name tag instances are matched by
+type ApplicationGroup struct {
+	Application string                     `json:"application"`
+	Instances   []driftchecker.DriftReport `json:"instances"`
+}
+
+// GroupedDocument is the JSON report grouped by application
+type GroupedDocument struct {
+	SchemaVersion int                `json:"schema_version"`
+	Title         string             `json:"title,omitempty"` // Set by WithTitle
+	Meta          *Meta              `json:"meta,omitempty"`  // Set by WithMeta
+	GroupBy       GroupBy            `json:"group_by"`
+	Groups        []ApplicationGroup `json:"groups"`
+}
+
+// GroupByApplication collects the reports under their application name.
+// Groups are sorted by name and keep the order of their reports; instances
+// without a name are grouped under "(unnamed)".
+func GroupByApplication(reports []driftchecker.DriftReport) []ApplicationGroup {
+	index := make(map[string]int)
+	groups := []ApplicationGroup{}
+	for _, report := range reports {
+		name := report.Name
+		if name == "" {
+			name = unnamedApplication
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, ApplicationGroup{Application: name})
+		}
+		groups[i].Instances = append(groups[i].Instances, report)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Application < groups[j].Application
+	})
+	return groups
+}
+
+func writeGroupedJSON(w io.Writer, reports []driftchecker.DriftReport, title string, meta *Meta, pretty bool) error {
+	encoder := json.NewEncoder(w)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(GroupedDocument{
+		SchemaVersion: SchemaVersion,
+		Title:         title,
+		Meta:          meta,
+		GroupBy:       GroupApplication,
+		Groups:        GroupByApplication(reports),
+	})
+}
+
+// writeGroupedTable writes one table section per application, headed by
+// the application name and its instance count
+func writeGroupedTable(w io.Writer, reports []driftchecker.DriftReport) {
+	for i, group := range GroupByApplication(reports) {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		noun := "instances"
+		if len(group.Instances) == 1 {
+			noun = "instance"
+		}
+		fmt.Fprintf(w, "Application: %s (%d %s)\n", group.Application, len(group.Instances), noun)
+		writeTable(w, group.Instances, false)
+	}
+}
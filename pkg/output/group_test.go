@@ -0,0 +1,104 @@
+package output_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fleetReports() []driftchecker.DriftReport {
+	return []driftchecker.DriftReport{
+		{InstanceID: "i-2", Name: "web", Managed: true, Drifts: []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-2"}}},
+		{InstanceID: "i-1", Name: "api", Managed: true, Drifts: []driftchecker.DriftDetail{{Attribute: "instance_type", ExpectedValue: "t3.micro", ActualValue: "t3.large"}}},
+		{InstanceID: "i-3", Name: "web", Managed: true, Drifts: []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-3"}}},
+		{InstanceID: "i-4", Drifts: []driftchecker.DriftDetail{{Attribute: "instance_added", ActualValue: "i-4"}}},
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	groupBy, err := output.ParseGroupBy("")
+	require.NoError(t, err)
+	assert.Equal(t, output.GroupNone, groupBy)
+
+	groupBy, err = output.ParseGroupBy("Application")
+	require.NoError(t, err)
+	assert.Equal(t, output.GroupApplication, groupBy)
+
+	_, err = output.ParseGroupBy("region")
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedGroupBy{})
+	assert.EqualError(t, err, `unsupported grouping "region" (supported: application)`)
+}
+
+func TestGroupByApplication(t *testing.T) {
+	groups := output.GroupByApplication(fleetReports())
+
+	require.Len(t, groups, 3)
+	assert.Equal(t, "(unnamed)", groups[0].Application)
+	assert.Equal(t, "api", groups[1].Application)
+	assert.Equal(t, "web", groups[2].Application)
+
+	var webIDs []string
+	for _, report := range groups[2].Instances {
+		webIDs = append(webIDs, report.InstanceID)
+	}
+	assert.Equal(t, []string{"i-2", "i-3"}, webIDs, "instances keep their report order")
+
+	assert.Empty(t, output.GroupByApplication(nil))
+}
+
+func TestRenderGroupedJSON(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.JSON, fleetReports()[:3], output.WithGroupBy(output.GroupApplication)))
+
+	assert.JSONEq(t, `{
+		"schema_version": 1,
+		"group_by": "application",
+		"groups": [
+			{"application": "api", "instances": [
+				{"instance_id": "i-1", "name": "api", "managed": true, "drifts": [{"attribute": "instance_type", "expected": "t3.micro", "actual": "t3.large"}]}
+			]},
+			{"application": "web", "instances": [
+				{"instance_id": "i-2", "name": "web", "managed": true, "drifts": [{"attribute": "ami", "expected": "ami-1", "actual": "ami-2"}]},
+				{"instance_id": "i-3", "name": "web", "managed": true, "drifts": [{"attribute": "ami", "expected": "ami-1", "actual": "ami-3"}]}
+			]}
+		]
+	}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, output.Render(&buf, output.JSON, nil, output.WithGroupBy(output.GroupApplication)))
+	assert.JSONEq(t, `{"schema_version": 1, "group_by": "application", "groups": []}`, buf.String())
+}
+
+func TestRenderGroupedTable(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.Table, fleetReports(), output.WithGroupBy(output.GroupApplication)))
+	out := buf.String()
+
+	sections := regexp.MustCompile(`(?m)^Application: (.+)$`).FindAllStringSubmatch(out, -1)
+	require.Len(t, sections, 3)
+	assert.Equal(t, "(unnamed) (1 instance)", sections[0][1])
+	assert.Equal(t, "api (1 instance)", sections[1][1])
+	assert.Equal(t, "web (2 instances)", sections[2][1])
+
+	// The application is the section heading, not a column
+	assert.Regexp(t, regexp.MustCompile(`INSTANCE ID\s+ATTRIBUTE\s+EXPECTED\s+ACTUAL`), out)
+	assert.NotContains(t, out, "APPLICATION")
+
+	web := out[strings.Index(out, "Application: web"):]
+	assert.Contains(t, web, "i-2")
+	assert.Contains(t, web, "i-3")
+	assert.NotRegexp(t, regexp.MustCompile(`\bi-1\b`), web)
+}
+
+func TestRenderGroupByIgnoredByOtherFormats(t *testing.T) {
+	var grouped, plain strings.Builder
+	require.NoError(t, output.Render(&grouped, output.CSV, fleetReports(), output.WithGroupBy(output.GroupApplication)))
+	require.NoError(t, output.Render(&plain, output.CSV, fleetReports()))
+	assert.Equal(t, plain.String(), grouped.String())
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/olekukonko/tablewriter"
+)
+
+// InstanceFormats returns the formats live instances can be printed in
+func InstanceFormats() []string {
+	return []string{string(Table), string(JSON)}
+}
+
+// ParseInstanceFormat validates the format of a live instance listing. An
+// empty name yields a table.
+func ParseInstanceFormat(name string) (Format, error) {
+	if name == "" {
+		return Table, nil
+	}
+	for _, f := range InstanceFormats() {
+		if strings.EqualFold(name, f) {
+			return Format(f), nil
+		}
+	}
+	return "", errors.NewUnsupportedOutputFormat(name, InstanceFormats())
+}
+
+// RenderInstances writes live instances to w, either as an indented JSON
+// array, the layout the json input format reads back, or as a table of
+// their main fields
+func RenderInstances(w io.Writer, format Format, instances []cloud.Instance) error {
+	switch format {
+	case JSON:
+		if instances == nil {
+			instances = []cloud.Instance{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(instances)
+	case Table, "":
+		writeInstanceTable(w, instances)
+		return nil
+	default:
+		return errors.NewUnsupportedOutputFormat(string(format), InstanceFormats())
+	}
+}
+
+func writeInstanceTable(w io.Writer, instances []cloud.Instance) {
+	withProvider := false
+	for _, instance := range instances {
+		if instance.Provider != "" {
+			withProvider = true
+			break
+		}
+	}
+
+	header := []string{"Instance ID", "Name"}
+	if withProvider {
+		header = append(header, "Provider")
+	}
+	header = append(header, "AMI", "Instance Type", "Private IP", "Public IP", "Security Groups")
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+
+	for _, instance := range instances {
+		row := []string{instance.InstanceID, instance.Tags["Name"]}
+		if withProvider {
+			row = append(row, instance.Provider)
+		}
+		table.Append(append(row,
+			instance.AMI,
+			instance.InstanceType,
+			instance.PrivateIP,
+			instance.PublicIP,
+			strings.Join(instance.SecurityGroups, ","),
+		))
+	}
+
+	table.Render()
+}
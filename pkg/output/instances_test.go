@@ -0,0 +1,38 @@
+package output_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderInstances(t *testing.T) {
+	instances := []cloud.Instance{{
+		InstanceID:     "i-1",
+		Provider:       "aws",
+		AMI:            "ami-1",
+		InstanceType:   "t3.micro",
+		SecurityGroups: []string{"sg-1", "sg-2"},
+		Tags:           map[string]string{"Name": "web"},
+	}}
+
+	var table strings.Builder
+	require.NoError(t, output.RenderInstances(&table, output.Table, instances))
+	assert.Contains(t, table.String(), "PROVIDER")
+	assert.Contains(t, table.String(), "web")
+	assert.Contains(t, table.String(), "sg-1,sg-2")
+
+	var empty strings.Builder
+	require.NoError(t, output.RenderInstances(&empty, output.JSON, nil))
+	assert.Equal(t, "[]\n", empty.String())
+
+	err := output.RenderInstances(&strings.Builder{}, output.CSV, instances)
+	assert.Error(t, err)
+
+	_, err = output.ParseInstanceFormat("html")
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package output
+
+// Meta records the inputs of a drift check, so a stored JSON report can be
+// understood without the command that produced it. Credentials in paths
+// are masked.
+type Meta struct {
+	Attributes  []string `json:"attributes"`
+	InputFormat string   `json:"input_format"` // Parser of the desired config, e.g. terraform
+	Providers   []string `json:"providers,omitempty"`
+	Region      string   `json:"region,omitempty"`
+
+	// Desired state source; only the one the check read is set
+	StatePath   string   `json:"state_path,omitempty"`
+	ConfigFiles []string `json:"config_files,omitempty"`
+	Baseline    string   `json:"baseline,omitempty"`
+	Inline      bool     `json:"inline_config,omitempty"` // Desired config sent with the request
+
+	Filters MetaFilters `json:"filters"`
+}
+
+// MetaFilters are the instance selection and output filters of a check.
+// Unset filters are omitted.
+type MetaFilters struct {
+	Instances        []string `json:"instances,omitempty"`
+	ExcludeInstances []string `json:"exclude_instances,omitempty"`
+	VpcID            string   `json:"vpc_id,omitempty"`
+	SubnetID         string   `json:"subnet_id,omitempty"`
+	Only             []string `json:"only,omitempty"`
+	FailOnSeverity   string   `json:"fail_on_severity,omitempty"`
+}
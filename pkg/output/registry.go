@@ -0,0 +1,96 @@
+package output
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Renderer writes drift reports in one output format
+type Renderer interface {
+	Render(w io.Writer, reports []driftchecker.DriftReport) error
+}
+
+// RendererFactory builds the renderer of one output format with the
+// settings of a Render call
+type RendererFactory func(settings RenderSettings) Renderer
+
+// Registry maps output formats to the renderers that write them. Supporting
+// a new format is a call to Register. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[Format]RendererFactory
+}
+
+// renderers backs Render, ParseFormat and RegisterRenderer
+var renderers = DefaultRegistry()
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[Format]RendererFactory)}
+}
+
+// DefaultRegistry returns a registry holding the built in table, JSON, CSV,
+// HTML, summary, SARIF and template renderers
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Table, func(s RenderSettings) Renderer { return TableRenderer{GroupBy: s.GroupBy} })
+	r.Register(JSON, func(s RenderSettings) Renderer {
+		return JSONRenderer{Pretty: s.PrettyJSON, Title: s.Title, GroupBy: s.GroupBy, Meta: s.Meta}
+	})
+	r.Register(CSV, func(RenderSettings) Renderer { return CSVRenderer{} })
+	r.Register(HTML, func(s RenderSettings) Renderer { return HTMLRenderer{Title: s.Title} })
+	r.Register(Summary, func(RenderSettings) Renderer { return SummaryRenderer{} })
+	r.Register(SARIF, func(RenderSettings) Renderer { return SARIFRenderer{} })
+	r.Register(Template, func(s RenderSettings) Renderer { return TemplateRenderer{Template: s.Template, Title: s.Title} })
+	return r
+}
+
+// Register adds or replaces the renderer for a format
+func (r *Registry) Register(format Format, factory RendererFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[format] = factory
+}
+
+// Lookup builds the renderer registered for a format, or returns
+// ErrUnsupportedOutputFormat when there is none
+func (r *Registry) Lookup(format Format, settings RenderSettings) (Renderer, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[format]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errors.NewUnsupportedOutputFormat(string(format), r.Formats())
+	}
+	return factory(settings), nil
+}
+
+// Formats returns the registered formats in alphabetical order
+func (r *Registry) Formats() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	formats := make([]string, 0, len(r.factories))
+	for format := range r.factories {
+		formats = append(formats, string(format))
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// RegisterRenderer makes Render and ParseFormat accept a format, adding one
+// or replacing a built in renderer
+func RegisterRenderer(format Format, factory RendererFactory) {
+	renderers.Register(format, factory)
+}
+
+// LookupRenderer builds the renderer for a format with the given options
+func LookupRenderer(format Format, opts ...RenderOption) (Renderer, error) {
+	var settings RenderSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return renderers.Lookup(format, settings)
+}
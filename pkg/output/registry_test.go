@@ -0,0 +1,81 @@
+package output_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countRenderer writes the number of reports, prefixed by its title
+type countRenderer struct {
+	title string
+}
+
+func (r countRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	_, err := fmt.Fprintf(w, "%s%d\n", r.title, len(reports))
+	return err
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	r := output.DefaultRegistry()
+	assert.Equal(t, []string{"csv", "html", "json", "sarif", "summary", "table", "template"}, r.Formats())
+
+	renderer, err := r.Lookup(output.Table, output.RenderSettings{GroupBy: output.GroupApplication})
+	require.NoError(t, err)
+	assert.Equal(t, output.TableRenderer{GroupBy: output.GroupApplication}, renderer)
+
+	renderer, err = r.Lookup(output.JSON, output.RenderSettings{PrettyJSON: true, Title: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, output.JSONRenderer{Pretty: true, Title: "prod"}, renderer)
+
+	for _, format := range []output.Format{output.CSV, output.HTML, output.Summary, output.SARIF} {
+		_, err := r.Lookup(format, output.RenderSettings{})
+		assert.NoError(t, err, format)
+	}
+}
+
+func TestRegistryUnknownFormat(t *testing.T) {
+	r := output.NewRegistry()
+
+	_, err := r.Lookup(output.Table, output.RenderSettings{})
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedOutputFormat{})
+
+	r.Register(output.Table, func(s output.RenderSettings) output.Renderer { return countRenderer{title: s.Title} })
+	renderer, err := r.Lookup(output.Table, output.RenderSettings{Title: "reports: "})
+	require.NoError(t, err)
+	assert.Equal(t, countRenderer{title: "reports: "}, renderer)
+
+	_, err = r.Lookup("xml", output.RenderSettings{})
+	assert.EqualError(t, err, `unsupported output format "xml" (supported: table)`)
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	var buf strings.Builder
+	err := output.Render(&buf, "count", sampleReports())
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedOutputFormat{})
+
+	output.RegisterRenderer("count", func(s output.RenderSettings) output.Renderer { return countRenderer{title: s.Title} })
+
+	format, err := output.ParseFormat("COUNT")
+	require.NoError(t, err)
+	assert.Equal(t, output.Format("count"), format)
+	assert.Contains(t, output.Formats(), "count")
+
+	require.NoError(t, output.Render(&buf, format, sampleReports(), output.WithTitle("reports: ")))
+	assert.Equal(t, "reports: 1\n", buf.String())
+}
+
+func TestPrintTableRenderer(t *testing.T) {
+	var renderer output.Renderer = output.TableRenderer{}
+
+	var buf strings.Builder
+	require.NoError(t, renderer.Render(&buf, nil))
+	assert.Equal(t, captureOutput(func() { output.PrintTable(nil) }), buf.String())
+}
@@ -0,0 +1,262 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Format selects how drift reports are rendered
+type Format string
+
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	HTML  Format = "html"
+
+	Summary Format = "summary" // Aggregated counts only, no per-drift rows
+	SARIF   Format = "sarif"   // SARIF 2.1.0 log for code scanning platforms
+
+	Template Format = "template" // User supplied text/template, see WithTemplate
+)
+
+// Formats returns the output formats with a registered renderer, in
+// alphabetical order
+func Formats() []string {
+	return renderers.Formats()
+}
+
+// ParseFormat validates an output format name. An empty name yields an
+// empty Format, meaning the caller's default applies.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return "", nil
+	}
+	for _, f := range Formats() {
+		if strings.EqualFold(name, f) {
+			return Format(f), nil
+		}
+	}
+	return "", errors.NewUnsupportedOutputFormat(name, Formats())
+}
+
+// FormatFromPath infers the output format from a file extension,
+// falling back to a plain table.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".csv":
+		return CSV
+	case ".html", ".htm":
+		return HTML
+	case ".sarif":
+		return SARIF
+	default:
+		return Table
+	}
+}
+
+// RenderOption customises how Render writes a report.
+type RenderOption func(*RenderSettings)
+
+// RenderSettings are the options of one Render call, handed to the
+// factory of the renderer for the requested format
+type RenderSettings struct {
+	PrettyJSON bool
+	Title      string
+	GroupBy    GroupBy
+	Template   *texttemplate.Template // Parsed --template-file of the template format
+	Meta       *Meta                  // Inputs of the check, recorded in JSON reports
+}
+
+// WithPrettyJSON indents JSON reports by two spaces instead of writing them
+// compactly. Other formats ignore it.
+func WithPrettyJSON(pretty bool) RenderOption {
+	return func(o *RenderSettings) {
+		o.PrettyJSON = pretty
+	}
+}
+
+// WithTitle labels the report, e.g. "prod us-east-1", in the JSON
+// document and the HTML header. Other formats ignore it.
+func WithTitle(title string) RenderOption {
+	return func(o *RenderSettings) {
+		o.Title = title
+	}
+}
+
+// WithGroupBy organizes table and JSON reports into groups, e.g. one per
+// application. Other formats ignore it.
+func WithGroupBy(groupBy GroupBy) RenderOption {
+	return func(o *RenderSettings) {
+		o.GroupBy = groupBy
+	}
+}
+
+// WithTemplate renders the template format through t, parsed with
+// ParseTemplateFile. Other formats ignore it.
+func WithTemplate(t *texttemplate.Template) RenderOption {
+	return func(o *RenderSettings) {
+		o.Template = t
+	}
+}
+
+// WithMeta records the inputs of the check in the meta object of JSON
+// reports. A nil meta leaves it out; other formats ignore it.
+func WithMeta(meta *Meta) RenderOption {
+	return func(o *RenderSettings) {
+		o.Meta = meta
+	}
+}
+
+// Render writes the drift reports to w with the renderer registered for
+// the format. An empty format renders a table.
+func Render(w io.Writer, format Format, reports []driftchecker.DriftReport, opts ...RenderOption) error {
+	if format == "" {
+		format = Table
+	}
+	renderer, err := LookupRenderer(format, opts...)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(w, reports)
+}
+
+// JSONRenderer writes the JSON document, grouped by application when
+// GroupBy asks for it
+type JSONRenderer struct {
+	Pretty  bool
+	Title   string
+	GroupBy GroupBy
+	Meta    *Meta
+}
+
+func (r JSONRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	if r.GroupBy == GroupApplication {
+		return writeGroupedJSON(w, reports, r.Title, r.Meta, r.Pretty)
+	}
+	return writeJSON(w, reports, r.Title, r.Meta, r.Pretty)
+}
+
+// CSVRenderer writes one CSV record per drift
+type CSVRenderer struct{}
+
+func (CSVRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	return writeCSV(w, reports)
+}
+
+// HTMLRenderer writes a standalone HTML page with one table row per drift
+type HTMLRenderer struct {
+	Title string
+}
+
+func (r HTMLRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	return writeHTML(w, reports, r.Title)
+}
+
+// WriteFile renders the drift reports into the file at path, replacing it
+func WriteFile(path string, format Format, reports []driftchecker.DriftReport, opts ...RenderOption) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+
+	if err := Render(f, format, reports, opts...); err != nil {
+		f.Close()
+		return errors.NewWriteOutputError(path, err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.NewWriteOutputError(path, err)
+	}
+	return nil
+}
+
+// SchemaVersion identifies the structure of JSON reports and REST responses.
+// Bump it whenever a field is removed, renamed or changes type; the README
+// lists what each version contains.
+const SchemaVersion = 1
+
+// Document is the JSON report: the drift reports and the schema they follow
+type Document struct {
+	SchemaVersion int                        `json:"schema_version"`
+	Title         string                     `json:"title,omitempty"` // Set by WithTitle
+	Meta          *Meta                      `json:"meta,omitempty"`  // Set by WithMeta
+	Reports       []driftchecker.DriftReport `json:"reports"`
+}
+
+// writeJSON encodes the drift values as their native JSON types, so lists
+// stay arrays and flags stay booleans. Only the table, CSV and HTML formats
+// go through formatValue. The report is compact unless pretty is set.
+func writeJSON(w io.Writer, reports []driftchecker.DriftReport, title string, meta *Meta, pretty bool) error {
+	if reports == nil {
+		reports = []driftchecker.DriftReport{}
+	}
+	encoder := json.NewEncoder(w)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(Document{SchemaVersion: SchemaVersion, Title: title, Meta: meta, Reports: reports})
+}
+
+func writeCSV(w io.Writer, reports []driftchecker.DriftReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"instance_id", "name", "provider", "attribute", "expected", "actual", "added", "removed"}); err != nil {
+		return err
+	}
+	for _, report := range reports {
+		for _, drift := range report.Drifts {
+			record := []string{
+				report.InstanceID,
+				report.Name,
+				report.Provider,
+				drift.Attribute,
+				formatValue(drift.ExpectedValue),
+				formatValue(drift.ActualValue),
+				formatValue(drift.Added),
+				formatValue(drift.Removed),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"value":   formatValue,
+	"changes": formatChanges,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ with .Title }}{{ . }} - {{ end }}Drift report</title></head>
+<body>
+{{- with .Title }}
+<h1>{{ . }}</h1>
+{{- end }}
+<table>
+<tr><th>Instance ID</th><th>Application</th><th>Provider</th><th>Attribute</th><th>Expected</th><th>Actual</th></tr>
+{{- range $report := .Reports }}{{ range .Drifts }}
+<tr><td>{{ $report.InstanceID }}</td><td>{{ $report.Name }}</td><td>{{ $report.Provider }}</td><td>{{ .Attribute }}</td><td>{{ value .ExpectedValue }}</td><td>{{ value .ActualValue }}{{ with changes . }} ({{ . }}){{ end }}</td></tr>
+{{- end }}{{ end }}
+</table>
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, reports []driftchecker.DriftReport, title string) error {
+	return htmlReport.Execute(w, struct {
+		Title   string
+		Reports []driftchecker.DriftReport
+	}{title, reports})
+}
@@ -0,0 +1,253 @@
+package output_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReports() []driftchecker.DriftReport {
+	return []driftchecker.DriftReport{
+		{
+			InstanceID: "i-123",
+			Name:       "web",
+			Managed:    true,
+			Drifts: []driftchecker.DriftDetail{
+				{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-2"},
+				{Attribute: "security_groups", ExpectedValue: []string{"sg-1"}, ActualValue: []string{"sg-1", "sg-2"}, Added: []string{"sg-2"}},
+			},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"table", "json", "CSV", "html", "summary", "SARIF"} {
+		format, err := output.ParseFormat(name)
+		require.NoError(t, err)
+		assert.Equal(t, output.Format(strings.ToLower(name)), format)
+	}
+
+	format, err := output.ParseFormat("")
+	require.NoError(t, err)
+	assert.Empty(t, format)
+
+	_, err = output.ParseFormat("xml")
+	assert.ErrorAs(t, err, &errors.ErrUnsupportedOutputFormat{})
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, output.JSON, output.FormatFromPath("drift_report.json"))
+	assert.Equal(t, output.CSV, output.FormatFromPath("out/report.CSV"))
+	assert.Equal(t, output.HTML, output.FormatFromPath("report.html"))
+	assert.Equal(t, output.SARIF, output.FormatFromPath("drift.sarif"))
+	assert.Equal(t, output.Table, output.FormatFromPath("report.txt"))
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.JSON, sampleReports()))
+
+	assert.JSONEq(t, `{
+		"schema_version": 1,
+		"reports": [{
+			"instance_id": "i-123",
+			"name": "web",
+			"managed": true,
+			"drifts": [
+				{"attribute": "ami", "expected": "ami-1", "actual": "ami-2"},
+				{"attribute": "security_groups", "expected": ["sg-1"], "actual": ["sg-1", "sg-2"], "added": ["sg-2"]}
+			]
+		}]
+	}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, output.Render(&buf, output.JSON, nil))
+	assert.JSONEq(t, `{"schema_version": 1, "reports": []}`, buf.String())
+}
+
+func TestRenderJSONPretty(t *testing.T) {
+	reports := []driftchecker.DriftReport{{InstanceID: "i-1", Drifts: []driftchecker.DriftDetail{{Attribute: "ami"}}}}
+
+	var compact strings.Builder
+	require.NoError(t, output.Render(&compact, output.JSON, reports))
+	assert.Equal(t, `{"schema_version":1,"reports":[{"instance_id":"i-1","name":"","managed":false,"drifts":[{"attribute":"ami","expected":null,"actual":null}]}]}`+"\n", compact.String())
+
+	var pretty strings.Builder
+	require.NoError(t, output.Render(&pretty, output.JSON, reports, output.WithPrettyJSON(true)))
+	assert.Equal(t, `{
+  "schema_version": 1,
+  "reports": [
+    {
+      "instance_id": "i-1",
+      "name": "",
+      "managed": false,
+      "drifts": [
+        {
+          "attribute": "ami",
+          "expected": null,
+          "actual": null
+        }
+      ]
+    }
+  ]
+}
+`, pretty.String())
+}
+
+func TestRenderJSONKeepsValueTypes(t *testing.T) {
+	reports := []driftchecker.DriftReport{{
+		InstanceID: "i-123",
+		Name:       "web",
+		Drifts: []driftchecker.DriftDetail{
+			{Attribute: "security_groups", ExpectedValue: []string{"sg-1", "sg-2"}, ActualValue: []string{}},
+			{Attribute: "root_block_device.volume_size", ExpectedValue: 100, ActualValue: 50},
+			{Attribute: "disable_api_termination", ExpectedValue: true, ActualValue: false},
+			{Attribute: "tags", ExpectedValue: map[string]string{"Env": "prod"}, ActualValue: nil},
+		},
+	}}
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.JSON, reports))
+
+	var decoded struct {
+		Reports []struct {
+			Drifts []struct {
+				Attribute string      `json:"attribute"`
+				Expected  interface{} `json:"expected"`
+				Actual    interface{} `json:"actual"`
+			} `json:"drifts"`
+		} `json:"reports"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &decoded))
+	drifts := decoded.Reports[0].Drifts
+	require.Len(t, drifts, 4)
+	assert.Equal(t, []interface{}{"sg-1", "sg-2"}, drifts[0].Expected)
+	assert.Equal(t, []interface{}{}, drifts[0].Actual)
+	assert.Equal(t, float64(100), drifts[1].Expected)
+	assert.Equal(t, true, drifts[2].Expected)
+	assert.Equal(t, false, drifts[2].Actual)
+	assert.Equal(t, map[string]interface{}{"Env": "prod"}, drifts[3].Expected)
+	assert.Nil(t, drifts[3].Actual)
+}
+
+func TestRenderJSONCleanInstances(t *testing.T) {
+	reports := append(sampleReports(), driftchecker.DriftReport{
+		InstanceID: "i-456",
+		Name:       "api",
+		Managed:    true,
+		Status:     driftchecker.StatusOK,
+		Drifts:     []driftchecker.DriftDetail{},
+	})
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.JSON, reports))
+
+	var document struct {
+		Reports []map[string]interface{} `json:"reports"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &document))
+	require.Len(t, document.Reports, 2)
+	assert.NotContains(t, document.Reports[0], "status", "drifted instances have no status")
+	assert.Equal(t, "ok", document.Reports[1]["status"])
+	assert.Equal(t, []interface{}{}, document.Reports[1]["drifts"])
+}
+
+func TestRenderTitle(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.JSON, nil, output.WithTitle("prod us-east-1")))
+	assert.JSONEq(t, `{"schema_version": 1, "title": "prod us-east-1", "reports": []}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, output.Render(&buf, output.HTML, sampleReports(), output.WithTitle("prod <us-east-1>")))
+	assert.Contains(t, buf.String(), "<title>prod &lt;us-east-1&gt; - Drift report</title>")
+	assert.Contains(t, buf.String(), "<h1>prod &lt;us-east-1&gt;</h1>")
+
+	buf.Reset()
+	require.NoError(t, output.Render(&buf, output.HTML, sampleReports()))
+	assert.Contains(t, buf.String(), "<title>Drift report</title>")
+	assert.NotContains(t, buf.String(), "<h1>", "untitled reports have no header")
+}
+
+func TestRenderMeta(t *testing.T) {
+	meta := &output.Meta{
+		Attributes:  []string{"ami"},
+		InputFormat: "json",
+		Providers:   []string{"aws"},
+		Region:      "us-east-1",
+		Baseline:    "baseline.json",
+		Filters:     output.MetaFilters{VpcID: "vpc-1"},
+	}
+	want := `"meta": {"attributes": ["ami"], "input_format": "json", "providers": ["aws"], "region": "us-east-1", "baseline": "baseline.json", "filters": {"vpc_id": "vpc-1"}}`
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.JSON, nil, output.WithMeta(meta)))
+	assert.JSONEq(t, `{"schema_version": 1, `+want+`, "reports": []}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, output.Render(&buf, output.JSON, nil, output.WithMeta(meta), output.WithGroupBy(output.GroupApplication)))
+	assert.JSONEq(t, `{"schema_version": 1, `+want+`, "group_by": "application", "groups": []}`, buf.String())
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.CSV, sampleReports()))
+
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"instance_id", "name", "provider", "attribute", "expected", "actual", "added", "removed"},
+		{"i-123", "web", "", "ami", "ami-1", "ami-2", "", ""},
+		{"i-123", "web", "", "security_groups", "sg-1", "sg-1, sg-2", "sg-2", ""},
+	}, records)
+}
+
+func TestRenderHTML(t *testing.T) {
+	reports := sampleReports()
+	reports[0].Name = "<web>"
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.HTML, reports))
+
+	assert.Contains(t, buf.String(), "<td>i-123</td><td>&lt;web&gt;</td><td></td><td>ami</td><td>ami-1</td><td>ami-2</td>")
+	assert.Contains(t, buf.String(), "<td>security_groups</td><td>sg-1</td><td>sg-1, sg-2 (&#43;sg-2)</td>")
+}
+
+func TestRenderSummary(t *testing.T) {
+	reports := append(sampleReports(), driftchecker.DriftReport{
+		InstanceID: "i-456",
+		Name:       "db",
+		Drifts:     []driftchecker.DriftDetail{{Attribute: "instance_added", ActualValue: "i-456"}},
+	})
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.Summary, reports))
+
+	assert.Equal(t, "Instances with drift: 2\nAdded: 1\nRemoved: 0\nChanged: 1\nAttribute drifts: 2\n", buf.String())
+	for _, row := range []string{"i-123", "web", "ami-1", "security_groups"} {
+		assert.NotContains(t, buf.String(), row, "no per-drift rows")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, output.WriteFile(path, output.JSON, sampleReports()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded output.Document
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, output.SchemaVersion, decoded.SchemaVersion)
+	assert.Equal(t, "i-123", decoded.Reports[0].InstanceID)
+
+	err = output.WriteFile(filepath.Join(t.TempDir(), "missing", "report.json"), output.JSON, nil)
+	assert.ErrorAs(t, err, &errors.ErrWriteOutput{})
+}
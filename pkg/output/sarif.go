@@ -0,0 +1,159 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+)
+
+// SARIF schema and version of the documents written by SARIFRenderer
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIFLog is the minimal subset of a SARIF 2.1.0 log that drift reports
+// need: one run with one rule per drifted attribute and one result per drift
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name  string      `json:"name"`
+	Rules []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"` // error, warning or note
+	Message    SARIFMessage           `json:"message"`
+	Locations  []SARIFLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SARIFLocation places a result on the drifted instance. Instances are no
+// files, so only a logical location is given.
+type SARIFLocation struct {
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations"`
+}
+
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`               // Instance ID
+	FullyQualifiedName string `json:"fullyQualifiedName"` // provider/application/instance ID
+	Kind               string `json:"kind"`
+}
+
+// SARIFRenderer writes a SARIF 2.1.0 log for code scanning platforms, one
+// result per drift. Clean reports have no drift and yield no results.
+type SARIFRenderer struct{}
+
+func (SARIFRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	return writeSARIF(w, reports)
+}
+
+func writeSARIF(w io.Writer, reports []driftchecker.DriftReport) error {
+	attributes := make(map[string]bool)
+	for _, report := range reports {
+		for _, drift := range report.Drifts {
+			attributes[drift.Attribute] = true
+		}
+	}
+	ruleIDs := make([]string, 0, len(attributes))
+	for attr := range attributes {
+		ruleIDs = append(ruleIDs, attr)
+	}
+	sort.Strings(ruleIDs)
+
+	rules := make([]SARIFRule, len(ruleIDs))
+	ruleIndex := make(map[string]int, len(ruleIDs))
+	for i, id := range ruleIDs {
+		rules[i] = SARIFRule{ID: id, ShortDescription: SARIFMessage{Text: "Drift of " + id}}
+		ruleIndex[id] = i
+	}
+
+	results := make([]SARIFResult, 0)
+	for _, report := range reports {
+		for _, drift := range report.Drifts {
+			result := SARIFResult{
+				RuleID:    drift.Attribute,
+				RuleIndex: ruleIndex[drift.Attribute],
+				Level:     sarifLevel(drift.Severity),
+				Message:   SARIFMessage{Text: sarifMessage(report, drift)},
+				Locations: []SARIFLocation{{LogicalLocations: []SARIFLogicalLocation{{
+					Name:               report.InstanceID,
+					FullyQualifiedName: sarifQualifiedName(report),
+					Kind:               "resource",
+				}}}},
+			}
+			if drift.Severity != "" {
+				result.Properties = map[string]interface{}{"severity": drift.Severity}
+			}
+			results = append(results, result)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(SARIFLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []SARIFRun{{
+			Tool:    SARIFTool{Driver: SARIFDriver{Name: "ec2drift", Rules: rules}},
+			Results: results,
+		}},
+	})
+}
+
+// sarifLevel maps drift severities to SARIF levels. Drift without a
+// severity is reported at the default severity.
+func sarifLevel(severity driftchecker.Severity) string {
+	switch severity {
+	case driftchecker.SeverityCritical:
+		return "error"
+	case driftchecker.SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+func sarifMessage(report driftchecker.DriftReport, drift driftchecker.DriftDetail) string {
+	text := fmt.Sprintf("%s drifted on %s (%s): expected %s, actual %s",
+		drift.Attribute, report.InstanceID, report.Name, formatValue(drift.ExpectedValue), formatValue(drift.ActualValue))
+	if changes := formatChanges(drift); changes != "" {
+		text += " (" + changes + ")"
+	}
+	return text
+}
+
+func sarifQualifiedName(report driftchecker.DriftReport) string {
+	name := report.Name + "/" + report.InstanceID
+	if report.Provider != "" {
+		name = report.Provider + "/" + name
+	}
+	return name
+}
@@ -0,0 +1,74 @@
+package output_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSARIF(t *testing.T) {
+	reports := sampleReports()
+	reports[0].Provider = "aws"
+	reports[0].Drifts[0].Severity = driftchecker.SeverityCritical
+	reports = append(reports,
+		driftchecker.DriftReport{
+			InstanceID: "i-456",
+			Name:       "db",
+			Managed:    true,
+			Drifts:     []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-3", ActualValue: "ami-4", Severity: driftchecker.SeverityInfo}},
+		},
+		driftchecker.DriftReport{InstanceID: "i-789", Name: "cache", Managed: true, Status: driftchecker.StatusOK},
+	)
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.SARIF, reports))
+
+	var log output.SARIFLog
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Equal(t, output.SARIFSchema, log.Schema)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "ec2drift", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "ami", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "security_groups", run.Tool.Driver.Rules[1].ID)
+
+	// One result per drift, none for the clean report
+	require.Len(t, run.Results, 3)
+	for _, result := range run.Results {
+		rule := run.Tool.Driver.Rules[result.RuleIndex]
+		assert.Equal(t, rule.ID, result.RuleID)
+		require.Len(t, result.Locations, 1)
+		require.Len(t, result.Locations[0].LogicalLocations, 1)
+		assert.NotEmpty(t, result.Message.Text)
+	}
+
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "ami drifted on i-123 (web): expected ami-1, actual ami-2", run.Results[0].Message.Text)
+	assert.Equal(t, output.SARIFLogicalLocation{Name: "i-123", FullyQualifiedName: "aws/web/i-123", Kind: "resource"},
+		run.Results[0].Locations[0].LogicalLocations[0])
+
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Equal(t, "security_groups drifted on i-123 (web): expected sg-1, actual sg-1, sg-2 (+sg-2)", run.Results[1].Message.Text)
+
+	assert.Equal(t, "note", run.Results[2].Level)
+	assert.Equal(t, "db/i-456", run.Results[2].Locations[0].LogicalLocations[0].FullyQualifiedName)
+}
+
+func TestRenderSARIFNoDrift(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.SARIF, nil))
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &log))
+	runs := log["runs"].([]interface{})
+	require.Len(t, runs, 1)
+	assert.Equal(t, []interface{}{}, runs[0].(map[string]interface{})["results"])
+}
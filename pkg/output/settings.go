@@ -0,0 +1,53 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/oldmonad/ec2Drift/pkg/config/env"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/olekukonko/tablewriter"
+)
+
+// RenderConfigSettings writes the effective configuration to w, either as
+// an indented JSON object keyed by variable name or as a two column table
+// in the order of the settings. It accepts the formats of InstanceFormats.
+func RenderConfigSettings(w io.Writer, format Format, settings []env.Setting) error {
+	switch format {
+	case JSON:
+		values := make(map[string]string, len(settings))
+		for _, setting := range settings {
+			values[setting.Name] = setting.Value
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case Table, "":
+		writeSettingsTable(w, settings)
+		return nil
+	default:
+		return errors.NewUnsupportedOutputFormat(string(format), InstanceFormats())
+	}
+}
+
+func writeSettingsTable(w io.Writer, settings []env.Setting) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Setting", "Value"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+
+	for _, setting := range settings {
+		table.Append([]string{setting.Name, setting.Value})
+	}
+
+	table.Render()
+}
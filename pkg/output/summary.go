@@ -0,0 +1,114 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+)
+
+// ExitSummary is the machine readable outcome of a run, printed as a single
+// JSON line on stderr so stdout only carries the report itself.
+type ExitSummary struct {
+	DriftDetected bool  `json:"drift_detected"`
+	Instances     int   `json:"instances"` // Instances with at least one report entry
+	Added         int   `json:"added"`
+	Removed       int   `json:"removed"`
+	Changed       int   `json:"changed"`
+	Unmanaged     int   `json:"unmanaged"`
+	Missing       int   `json:"missing"`
+	Clean         int   `json:"clean,omitempty"` // Matched instances without drift, reported with --include-no-drift
+	DurationMS    int64 `json:"duration_ms"`
+
+	APICalls            int            `json:"api_calls"`                        // Cloud API calls made by the run
+	APICallsByOperation map[string]int `json:"api_calls_by_operation,omitempty"` // e.g. DescribeInstances pages
+
+	Warnings []cloud.Warning `json:"warnings,omitempty"` // Live data that could not be fetched
+}
+
+// NewExitSummary counts the reports per drift category. Whether they count
+// as drift depends on the run, e.g. its severity threshold, so the caller
+// decides.
+func NewExitSummary(reports []driftchecker.DriftReport, driftDetected bool, duration time.Duration) ExitSummary {
+	summary := ExitSummary{
+		DriftDetected: driftDetected,
+		DurationMS:    duration.Milliseconds(),
+	}
+
+	for _, report := range reports {
+		if report.Clean() {
+			summary.Clean++
+			continue
+		}
+		switch reportCategory(report) {
+		case driftchecker.CategoryAdded:
+			summary.Added++
+		case driftchecker.CategoryRemoved:
+			summary.Removed++
+		case driftchecker.CategoryUnmanaged:
+			summary.Unmanaged++
+		case driftchecker.CategoryMissing:
+			summary.Missing++
+		default:
+			summary.Changed++
+		}
+		summary.Instances++
+	}
+	return summary
+}
+
+// reportCategory returns the drift category of a report. Instance level
+// entries are never mixed with attribute drift in one report.
+func reportCategory(report driftchecker.DriftReport) string {
+	for _, drift := range report.Drifts {
+		switch drift.Attribute {
+		case "instance_added":
+			return driftchecker.CategoryAdded
+		case "instance_removed":
+			return driftchecker.CategoryRemoved
+		case "instance_unmanaged":
+			return driftchecker.CategoryUnmanaged
+		case "instance_missing":
+			return driftchecker.CategoryMissing
+		}
+	}
+	return driftchecker.CategoryChanged
+}
+
+// PrintExitSummary writes the summary to stderr as one line of JSON.
+func PrintExitSummary(summary ExitSummary) error {
+	return WriteExitSummary(os.Stderr, summary)
+}
+
+// WriteExitSummary works like PrintExitSummary but writes to w
+func WriteExitSummary(w io.Writer, summary ExitSummary) error {
+	return json.NewEncoder(w).Encode(summary)
+}
+
+// SummaryRenderer writes the report counts without any per-drift rows
+type SummaryRenderer struct{}
+
+func (SummaryRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	return writeSummary(w, reports)
+}
+
+// writeSummary prints the report counts of the summary format, one per
+// line, without any per-drift rows
+func writeSummary(w io.Writer, reports []driftchecker.DriftReport) error {
+	summary := NewExitSummary(reports, driftchecker.HasDrift(reports), 0)
+
+	attributeDrifts := 0
+	for _, report := range reports {
+		if reportCategory(report) == driftchecker.CategoryChanged {
+			attributeDrifts += len(report.Drifts)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "Instances with drift: %d\nAdded: %d\nRemoved: %d\nChanged: %d\nAttribute drifts: %d\n",
+		summary.Instances, summary.Added, summary.Removed, summary.Changed, attributeDrifts)
+	return err
+}
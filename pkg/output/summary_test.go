@@ -0,0 +1,47 @@
+package output_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExitSummary(t *testing.T) {
+	reports := []driftchecker.DriftReport{
+		{InstanceID: "i-1", Drifts: []driftchecker.DriftDetail{{Attribute: "instance_added"}}},
+		{InstanceID: "i-2", Drifts: []driftchecker.DriftDetail{{Attribute: "instance_unmanaged"}}},
+		{InstanceID: "i-3", Drifts: []driftchecker.DriftDetail{{Attribute: "ami"}, {Attribute: "tags.Env"}}},
+		{InstanceID: "web", Drifts: []driftchecker.DriftDetail{{Attribute: "instance_removed"}}},
+	}
+
+	summary := output.NewExitSummary(reports, true, 812*time.Millisecond)
+
+	assert.Equal(t, output.ExitSummary{
+		DriftDetected: true,
+		Instances:     4,
+		Added:         1,
+		Removed:       1,
+		Changed:       1,
+		Unmanaged:     1,
+		DurationMS:    812,
+	}, summary)
+
+	// Drift below a severity threshold is counted but not reported as drift
+	assert.False(t, output.NewExitSummary(reports, false, 0).DriftDetected)
+}
+
+func TestNewExitSummaryCountsCleanInstances(t *testing.T) {
+	reports := []driftchecker.DriftReport{
+		{InstanceID: "i-1", Managed: true, Drifts: []driftchecker.DriftDetail{{Attribute: "ami"}}},
+		{InstanceID: "i-2", Managed: true, Status: driftchecker.StatusOK, Drifts: []driftchecker.DriftDetail{}},
+		{InstanceID: "i-3", Managed: true, Status: driftchecker.StatusOK, Drifts: []driftchecker.DriftDetail{}},
+	}
+
+	summary := output.NewExitSummary(reports, true, 0)
+	assert.Equal(t, 1, summary.Instances, "clean instances have no drift")
+	assert.Equal(t, 1, summary.Changed)
+	assert.Equal(t, 2, summary.Clean)
+}
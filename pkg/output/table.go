@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -11,13 +12,52 @@ import (
 	"github.com/olekukonko/tablewriter"
 )
 
+// TableRenderer writes the drift reports as a colored table, with one
+// section per application when GroupBy asks for it
+type TableRenderer struct {
+	GroupBy GroupBy
+}
+
+func (r TableRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	if r.GroupBy == GroupApplication {
+		writeGroupedTable(w, reports)
+		return nil
+	}
+	WriteTable(w, reports)
+	return nil
+}
+
+// PrintTable writes the drift reports to stdout as a colored table
 func PrintTable(reports []driftchecker.DriftReport) {
+	_ = TableRenderer{}.Render(os.Stdout, reports)
+}
+
+// WriteTable writes the drift reports to w as a colored table
+func WriteTable(w io.Writer, reports []driftchecker.DriftReport) {
+	writeTable(w, reports, true)
+}
+
+// writeTable writes the table, leaving out the application column when the
+// reports were already grouped by it
+func writeTable(w io.Writer, reports []driftchecker.DriftReport, withApplication bool) {
 	red := color.New(color.FgRed).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
 	green := color.New(color.FgGreen).SprintFunc()
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Instance ID", "Application", "Attribute", "Expected", "Actual"})
+	// Only show the provider column when reports were tagged with one,
+	// which instances are when fetched from more than one cloud provider
+	withProvider := hasProvider(reports)
+
+	header := []string{"Instance ID"}
+	if withApplication {
+		header = append(header, "Application")
+	}
+	if withProvider {
+		header = append(header, "Provider")
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(append(header, "Attribute", "Expected", "Actual"))
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -30,10 +70,31 @@ func PrintTable(reports []driftchecker.DriftReport) {
 	table.SetTablePadding("\t")
 	table.SetNoWhiteSpace(true)
 
+	// identity returns the leading cells of a report's rows
+	identity := func(report driftchecker.DriftReport) []string {
+		row := []string{report.InstanceID}
+		if withApplication {
+			row = append(row, report.Name)
+		}
+		if withProvider {
+			row = append(row, report.Provider)
+		}
+		return row
+	}
+
 	for _, report := range reports {
+		// Instances checked without drift get a single row of their own
+		if report.Clean() {
+			table.Append(append(identity(report), green("no drift"), "", ""))
+			continue
+		}
+
 		for _, drift := range report.Drifts {
 			expVal := formatValue(drift.ExpectedValue)
 			actVal := formatValue(drift.ActualValue)
+			if changes := formatChanges(drift); changes != "" {
+				actVal += " (" + changes + ")"
+			}
 
 			var expColored, actColored string
 			if expVal == actVal {
@@ -44,19 +105,35 @@ func PrintTable(reports []driftchecker.DriftReport) {
 				actColored = red(actVal)
 			}
 
-			table.Append([]string{
-				report.InstanceID,
-				report.Name,
-				drift.Attribute,
-				expColored,
-				actColored,
-			})
+			table.Append(append(identity(report), drift.Attribute, expColored, actColored))
 		}
 	}
 
 	table.Render()
 }
 
+func hasProvider(reports []driftchecker.DriftReport) bool {
+	for _, report := range reports {
+		if report.Provider != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatChanges lists the entries added to and removed from a list
+// attribute as "+sg-3, -sg-1", empty when the drift has none
+func formatChanges(drift driftchecker.DriftDetail) string {
+	changes := make([]string, 0, len(drift.Added)+len(drift.Removed))
+	for _, v := range drift.Added {
+		changes = append(changes, "+"+v)
+	}
+	for _, v := range drift.Removed {
+		changes = append(changes, "-"+v)
+	}
+	return strings.Join(changes, ", ")
+}
+
 func formatValue(v interface{}) string {
 	switch val := v.(type) {
 	case []string:
@@ -11,6 +11,7 @@ import (
 	"github.com/oldmonad/ec2Drift/internal/driftchecker"
 	"github.com/oldmonad/ec2Drift/pkg/output"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func init() {
@@ -239,3 +240,66 @@ func TestPrintTableEdgeCases(t *testing.T) {
 		assert.Contains(t, output, "\x1b[31m1\x1b[0m")
 	})
 }
+
+func TestPrintTableWithProvider(t *testing.T) {
+	reports := []driftchecker.DriftReport{
+		{
+			InstanceID: "i-aws",
+			Name:       "web",
+			Provider:   "aws",
+			Drifts: []driftchecker.DriftDetail{
+				{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-2"},
+			},
+		},
+		{
+			InstanceID: "gcp-1",
+			Name:       "api",
+			Provider:   "gcp",
+			Drifts: []driftchecker.DriftDetail{
+				{Attribute: "instance_type", ExpectedValue: "n1-standard-1", ActualValue: "n1-standard-2"},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		output.PrintTable(reports)
+	})
+
+	assert.Regexp(t, regexp.MustCompile(`^INSTANCE ID\s+APPLICATION\s+PROVIDER\s+ATTRIBUTE\s+EXPECTED\s+ACTUAL`), output)
+	assert.Regexp(t, regexp.MustCompile(`i-aws\s+web\s+aws\s+ami\s+`), output)
+	assert.Regexp(t, regexp.MustCompile(`gcp-1\s+api\s+gcp\s+instance_type\s+`), output)
+}
+
+func TestWriteTableCleanInstances(t *testing.T) {
+	reports := []driftchecker.DriftReport{
+		{InstanceID: "i-1", Name: "web", Managed: true, Drifts: []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-2"}}},
+		{InstanceID: "i-2", Name: "api", Managed: true, Status: driftchecker.StatusOK, Drifts: []driftchecker.DriftDetail{}},
+	}
+
+	var buf strings.Builder
+	output.WriteTable(&buf, reports)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3, "header, one drift row and one clean row")
+	assert.Regexp(t, regexp.MustCompile(`^i-1\s+web\s+ami\s`), lines[1])
+	assert.Regexp(t, regexp.MustCompile(`^i-2\s+api\s+\S*no drift`), lines[2])
+}
+
+func TestWriteTableListChanges(t *testing.T) {
+	reports := []driftchecker.DriftReport{{
+		InstanceID: "i-1",
+		Name:       "web",
+		Drifts: []driftchecker.DriftDetail{{
+			Attribute:     "security_groups",
+			ExpectedValue: []string{"sg-web", "sg-ssh"},
+			ActualValue:   []string{"sg-web", "sg-admin"},
+			Added:         []string{"sg-admin"},
+			Removed:       []string{"sg-ssh"},
+		}},
+	}}
+
+	var buf strings.Builder
+	output.WriteTable(&buf, reports)
+
+	assert.Contains(t, buf.String(), "sg-web, sg-admin (+sg-admin, -sg-ssh)")
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// TemplateData is what a report template is executed with
+type TemplateData struct {
+	Title   string
+	Reports []driftchecker.DriftReport
+	Summary ExitSummary // Report counts; duration, API calls and warnings are not set
+}
+
+// TemplateFuncs are the helpers available to report templates on top of
+// the text/template builtins. The color helpers follow --color.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"join":    func(sep string, items []string) string { return strings.Join(items, sep) },
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"value":   formatValue,
+		"changes": formatChanges,
+		"colored": func() bool { return !color.NoColor },
+		"red":     color.New(color.FgRed).SprintFunc(),
+		"green":   color.New(color.FgGreen).SprintFunc(),
+		"yellow":  color.New(color.FgYellow).SprintFunc(),
+		"bold":    color.New(color.Bold).SprintFunc(),
+	}
+}
+
+// ParseTemplate parses a report template with TemplateFuncs. The name
+// appears in parse and execution errors.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs()).Parse(text)
+}
+
+// ParseTemplateFile reads and parses the report template at path, so a
+// broken template is reported before any check runs
+func ParseTemplateFile(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewErrInvalidTemplate(path, err)
+	}
+	t, err := ParseTemplate(filepath.Base(path), string(text))
+	if err != nil {
+		return nil, errors.NewErrInvalidTemplate(path, err)
+	}
+	return t, nil
+}
+
+// TemplateRenderer executes a user supplied template with TemplateData
+type TemplateRenderer struct {
+	Template *template.Template
+	Title    string
+}
+
+func (r TemplateRenderer) Render(w io.Writer, reports []driftchecker.DriftReport) error {
+	if r.Template == nil {
+		return errors.NewErrTemplateFile(string(Template))
+	}
+	if reports == nil {
+		reports = []driftchecker.DriftReport{}
+	}
+	return r.Template.Execute(w, TemplateData{
+		Title:   r.Title,
+		Reports: reports,
+		Summary: NewExitSummary(reports, driftchecker.HasDrift(reports), 0),
+	})
+}
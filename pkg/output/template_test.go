@@ -0,0 +1,55 @@
+package output_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	path := filepath.Join(t.TempDir(), "report.md.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`# {{ .Title | upper }}
+{{ .Summary.Changed }} changed, colored: {{ colored }}
+{{ range .Reports }}{{ range .Drifts }}- {{ bold .Attribute }} {{ value .ExpectedValue }} -> {{ red (value .ActualValue) }}{{ with .Added }} added {{ join "," . }}{{ end }}
+{{ end }}{{ end }}`), 0o600))
+
+	tmpl, err := output.ParseTemplateFile(path)
+	require.NoError(t, err)
+
+	var buf strings.Builder
+	require.NoError(t, output.Render(&buf, output.Template, sampleReports(),
+		output.WithTemplate(tmpl), output.WithTitle("prod")))
+	assert.Equal(t, `# PROD
+1 changed, colored: false
+- ami ami-1 -> ami-2
+- security_groups sg-1 -> sg-1, sg-2 added sg-2
+`, buf.String())
+}
+
+func TestParseTemplateFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := output.ParseTemplateFile(filepath.Join(dir, "missing.tmpl"))
+	assert.ErrorAs(t, err, &errors.ErrInvalidTemplate{})
+
+	broken := filepath.Join(dir, "broken.tmpl")
+	require.NoError(t, os.WriteFile(broken, []byte("{{ range .Reports }}"), 0o600))
+	_, err = output.ParseTemplateFile(broken)
+	assert.ErrorAs(t, err, &errors.ErrInvalidTemplate{})
+
+	// The template format cannot render without a template
+	var buf strings.Builder
+	err = output.Render(&buf, output.Template, sampleReports())
+	assert.ErrorAs(t, err, &errors.ErrTemplateFile{})
+}
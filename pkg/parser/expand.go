@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"os"
+	"regexp"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// envVarPattern matches $$ (left untouched so Terraform escapes survive),
+// ${VAR} and $VAR. References such as ${var.ami} are not valid variable
+// names and are left for the parser.
+var envVarPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ExpandEnv replaces ${VAR} and $VAR references in the config content with
+// values from the process environment. Referencing a variable that is not
+// set returns an ErrUndefinedEnvVars listing every missing name.
+func ExpandEnv(content []byte) ([]byte, error) {
+	var missing []string
+	seen := make(map[string]bool)
+
+	expanded := envVarPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		groups := envVarPattern.FindSubmatch(match)
+		name := string(groups[1])
+		if name == "" {
+			name = string(groups[2])
+		}
+		if name == "" {
+			return match // $$
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if !seen[name] {
+				seen[name] = true
+				missing = append(missing, name)
+			}
+			return match
+		}
+		return []byte(value)
+	})
+
+	if len(missing) > 0 {
+		return nil, errors.NewUndefinedEnvVars(missing)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,54 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("AMI_ID", "ami-123456")
+	t.Setenv("INSTANCE_TYPE", "t2.micro")
+
+	content := []byte(`
+resource "aws_instance" "web" {
+  ami           = "${AMI_ID}"
+  instance_type = "$INSTANCE_TYPE"
+  user_data     = "$${HOME} ${var.region}"
+}`)
+
+	expanded, err := parser.ExpandEnv(content)
+	require.NoError(t, err)
+
+	assert.Equal(t, `
+resource "aws_instance" "web" {
+  ami           = "ami-123456"
+  instance_type = "t2.micro"
+  user_data     = "$${HOME} ${var.region}"
+}`, string(expanded))
+}
+
+func TestExpandEnvUndefinedVariable(t *testing.T) {
+	t.Setenv("AMI_ID", "ami-123456")
+
+	content := []byte(`ami = "${AMI_ID}" type = "${MISSING_TYPE}" size = "$MISSING_SIZE" again = "$MISSING_TYPE"`)
+
+	_, err := parser.ExpandEnv(content)
+	require.Error(t, err)
+
+	var undefinedErr errors.ErrUndefinedEnvVars
+	require.ErrorAs(t, err, &undefinedErr)
+	assert.Equal(t, []string{"MISSING_TYPE", "MISSING_SIZE"}, undefinedErr.Names)
+	assert.EqualError(t, err, "undefined environment variables in config: MISSING_TYPE, MISSING_SIZE")
+}
+
+func TestExpandEnvEmptyValue(t *testing.T) {
+	t.Setenv("EMPTY", "")
+
+	expanded, err := parser.ExpandEnv([]byte(`tag = "${EMPTY}"`))
+	require.NoError(t, err)
+	assert.Equal(t, `tag = ""`, string(expanded))
+}
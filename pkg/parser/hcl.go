@@ -15,6 +15,32 @@ import (
 // TerraformParser is a parser for Terraform HCL files
 type TerraformParser struct{}
 
+// terraformAttributes are the attributes the aws_instance schema below
+// fills in. Security groups and public IPs are not read.
+var terraformAttributes = []string{
+	"ami",
+	"instance_type",
+	"tags",
+	"private_ip",
+	"root_block_device",
+	"metadata_options.http_tokens",
+	"disable_api_termination",
+	"user_data",
+	"instance_lifecycle",
+	"monitoring",
+	"host_id",
+	"affinity",
+	"capacity_reservation_id",
+	"root_device_type",
+	"associate_public_ip_address",
+	"instance_state", // Implied: running unless --expected-state says otherwise
+}
+
+// SupportedAttributes returns the attributes the Terraform parser populates
+func (p *TerraformParser) SupportedAttributes() []string {
+	return terraformAttributes
+}
+
 // Config represents the top-level structure of a Terraform configuration
 type Config struct {
 	Providers []struct {
@@ -22,6 +48,13 @@ type Config struct {
 		Body hcl.Body `hcl:",remain"`     // raw body for future extensions
 	} `hcl:"provider,block"`
 	Resources []Resource `hcl:"resource,block"` // All defined resources in the file
+	Defaults  *Defaults  `hcl:"defaults,block"` // Optional values inherited by every aws_instance
+}
+
+// Defaults holds top-level values for aws_instance resources that omit them
+type Defaults struct {
+	AMI          string `hcl:"ami,optional"`
+	InstanceType string `hcl:"instance_type,optional"`
 }
 
 // Resource holds the type, name, and body of a Terraform resource block
@@ -33,31 +66,72 @@ type Resource struct {
 
 // EC2Instance models attributes specific to aws_instance
 type EC2Instance struct {
-	AMI             string            `hcl:"ami"`                        // AMI ID
-	InstanceType    string            `hcl:"instance_type"`              // EC2 instance type
+	AMI             string            `hcl:"ami,optional"`               // AMI ID, required unless set in defaults
+	InstanceType    string            `hcl:"instance_type,optional"`     // EC2 instance type, required unless set in defaults
 	Tags            map[string]string `hcl:"tags,optional"`              // Optional tags
+	PrivateIP       string            `hcl:"private_ip,optional"`        // Optional fixed private IP
+	SubnetID        string            `hcl:"subnet_id,optional"`         // Optional subnet, used by --subnet-id
 	RootBlockDevice *RootBlockDevice  `hcl:"root_block_device,block"`    // Optional root block device config
+	MetadataOptions *MetadataOptions  `hcl:"metadata_options,block"`     // Optional instance metadata options
+	DisableApiTermination *bool       `hcl:"disable_api_termination,optional"` // Termination protection, nil when not set
+	UserData        string            `hcl:"user_data,optional"`         // Bootstrap script, compared by hash
+	InstanceMarketOptions *InstanceMarketOptions `hcl:"instance_market_options,block"` // Optional spot settings
+	Monitoring      *bool             `hcl:"monitoring,optional"`        // Detailed CloudWatch monitoring, nil when not set
+	HostID          string            `hcl:"host_id,optional"`           // Dedicated host the instance is placed on
+	Affinity        string            `hcl:"affinity,optional"`          // Dedicated host affinity: default or host
+	RootDeviceType  string            `hcl:"root_device_type,optional"`  // ebs or instance-store
+	AssociatePublicIP *bool           `hcl:"associate_public_ip_address,optional"` // nil when not set
+	CapacityReservationSpecification *CapacityReservationSpecification `hcl:"capacity_reservation_specification,block"` // Optional reservation targeting
+}
+
+// CapacityReservationSpecification holds the capacity reservation an EC2
+// instance targets
+type CapacityReservationSpecification struct {
+	Target *CapacityReservationTarget `hcl:"capacity_reservation_target,block"` // nil when only a preference is set
+	Remain hcl.Body                   `hcl:",remain"`                           // the preference is not compared
+}
+
+// CapacityReservationTarget names the capacity reservation to run in
+type CapacityReservationTarget struct {
+	CapacityReservationID string   `hcl:"capacity_reservation_id,optional"`
+	Remain                hcl.Body `hcl:",remain"` // reservation groups are not compared
+}
+
+// InstanceMarketOptions holds the purchasing option of EC2 instances
+type InstanceMarketOptions struct {
+	MarketType string   `hcl:"market_type,optional"` // spot, on-demand when unset
+	Remain     hcl.Body `hcl:",remain"`              // spot options are not compared
+}
+
+// MetadataOptions holds the instance metadata service settings for EC2 instances
+type MetadataOptions struct {
+	HttpTokens string   `hcl:"http_tokens,optional"` // optional or required (IMDSv2)
+	Remain     hcl.Body `hcl:",remain"`              // other options are not compared
 }
 
 // RootBlockDevice holds volume configuration for EC2 instances
 type RootBlockDevice struct {
-	VolumeSize int    `hcl:"volume_size,optional"` // in GiB
-	VolumeType string `hcl:"volume_type,optional"` // e.g. gp2, io1
+	VolumeSize          int    `hcl:"volume_size,optional"`           // in GiB
+	VolumeType          string `hcl:"volume_type,optional"`           // e.g. gp2, io1
+	Encrypted           *bool  `hcl:"encrypted,optional"`             // nil when not set
+	DeleteOnTermination *bool  `hcl:"delete_on_termination,optional"` // nil when not set
 }
 
 // Parse decodes the Terraform HCL content and extracts EC2 instances
 func (p *TerraformParser) Parse(content []byte) ([]cloud.Instance, error) {
-	config, err := parseTerraformFile(content)
-	if err != nil {
-		return nil, err
-	}
+	instances, _, err := p.ParseWithWarnings(content)
+	return instances, err
+}
 
-	instances, err := config.GetEC2Instances()
+// ParseWithWarnings works like Parse and also returns a warning for each
+// aws_instance that was only partially decoded or had to be skipped
+func (p *TerraformParser) ParseWithWarnings(content []byte) ([]cloud.Instance, []cloud.Warning, error) {
+	config, err := parseTerraformFile(content)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return instances, nil
+	return config.ec2Instances()
 }
 
 // parseTerraformFile parses raw HCL and populates the Config struct
@@ -102,10 +176,18 @@ func parseTerraformFile(content []byte) (*Config, error) {
 
 // GetEC2Instances extracts aws_instance resources and maps them to cloud.Instance
 func (config *Config) GetEC2Instances() ([]cloud.Instance, error) {
+	instances, _, err := config.ec2Instances()
+	return instances, err
+}
+
+// ec2Instances extracts the aws_instance resources along with a warning
+// for each one decoded by the fallback or skipped
+func (config *Config) ec2Instances() ([]cloud.Instance, []cloud.Warning, error) {
 	log := logger.WithField("component", "terraform-parser")
 	log.Debug("Extracting EC2 instances from Terraform config")
 
 	var tfInstances []cloud.Instance
+	var warnings []cloud.Warning
 	for _, res := range config.Resources {
 		if res.Type != "aws_instance" {
 			continue
@@ -131,14 +213,14 @@ func (config *Config) GetEC2Instances() ([]cloud.Instance, error) {
 				if !isMap {
 					log.Error("Invalid tags type in aws_instance resource",
 						zap.String("name", res.Name))
-					return nil, errors.ErrInvalidTagsType{ResourceName: res.Name}
+					return nil, nil, errors.ErrInvalidTagsType{ResourceName: res.Name}
 				}
 			}
 
 			// Fallback: decode only essential fields
 			var minInst struct {
-				AMI          string   `hcl:"ami"`
-				InstanceType string   `hcl:"instance_type"`
+				AMI          string   `hcl:"ami,optional"`
+				InstanceType string   `hcl:"instance_type,optional"`
 				Remain       hcl.Body `hcl:",remain"`
 			}
 			fbDiags := gohcl.DecodeBody(res.Body, nil, &minInst)
@@ -146,6 +228,8 @@ func (config *Config) GetEC2Instances() ([]cloud.Instance, error) {
 				log.Error("Fallback decoding failed",
 					zap.String("name", res.Name),
 					zap.String("error", fbDiags.Error()))
+				warnings = append(warnings, cloud.Warning{InstanceID: res.Name,
+					Message: "aws_instance skipped, it could not be decoded: " + fbDiags.Error()})
 				continue
 			}
 
@@ -155,6 +239,27 @@ func (config *Config) GetEC2Instances() ([]cloud.Instance, error) {
 				zap.String("name", res.Name),
 				zap.String("ami", instance.AMI),
 				zap.String("instance_type", instance.InstanceType))
+			warnings = append(warnings, cloud.Warning{InstanceID: res.Name,
+				Message: "aws_instance only partially decoded, attributes other than ami and instance_type may be missing: " + diags.Error()})
+		}
+
+		// Inherit the defaults, then enforce the required fields
+		if config.Defaults != nil {
+			if instance.AMI == "" {
+				instance.AMI = config.Defaults.AMI
+			}
+			if instance.InstanceType == "" {
+				instance.InstanceType = config.Defaults.InstanceType
+			}
+		}
+		if instance.AMI == "" || instance.InstanceType == "" {
+			log.Error("aws_instance resource misses a required attribute",
+				zap.String("name", res.Name),
+				zap.Bool("ami_set", instance.AMI != ""),
+				zap.Bool("instance_type_set", instance.InstanceType != ""))
+			warnings = append(warnings, cloud.Warning{InstanceID: res.Name,
+				Message: "aws_instance skipped, it sets no ami or instance_type and no defaults block provides it"})
+			continue
 		}
 
 		// Ensure tags map is not nil
@@ -169,23 +274,56 @@ func (config *Config) GetEC2Instances() ([]cloud.Instance, error) {
 			InstanceType:   instance.InstanceType,
 			SecurityGroups: []string{},
 			Tags:           instance.Tags,
+			PrivateIP:      instance.PrivateIP,
+			SubnetID:       instance.SubnetID,
+			HostID:         instance.HostID,
+			Affinity:       instance.Affinity,
+			RootDeviceType: instance.RootDeviceType,
+
+			AssociatePublicIP: instance.AssociatePublicIP,
+
+			DisableApiTermination: instance.DisableApiTermination,
+			UserDataHash:          cloud.HashUserData([]byte(instance.UserData)),
 		}
 
 		// Attach root block device config if present
 		if instance.RootBlockDevice != nil {
 			ci.RootBlockDevice = struct {
-				VolumeSize int    `json:"volume_size"`
-				VolumeType string `json:"volume_type"`
+				VolumeSize          int    `json:"volume_size"`
+				VolumeType          string `json:"volume_type"`
+				Encrypted           *bool  `json:"encrypted,omitempty"`
+				DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 			}{
-				VolumeSize: instance.RootBlockDevice.VolumeSize,
-				VolumeType: instance.RootBlockDevice.VolumeType,
+				VolumeSize:          instance.RootBlockDevice.VolumeSize,
+				VolumeType:          instance.RootBlockDevice.VolumeType,
+				Encrypted:           instance.RootBlockDevice.Encrypted,
+				DeleteOnTermination: instance.RootBlockDevice.DeleteOnTermination,
 			}
 		}
 
+		if instance.MetadataOptions != nil {
+			ci.MetadataHttpTokens = instance.MetadataOptions.HttpTokens
+		}
+
+		if instance.Monitoring != nil {
+			ci.MonitoringState = "disabled"
+			if *instance.Monitoring {
+				ci.MonitoringState = "enabled"
+			}
+		}
+
+		if spec := instance.CapacityReservationSpecification; spec != nil && spec.Target != nil {
+			ci.CapacityReservationID = spec.Target.CapacityReservationID
+		}
+
+		if instance.InstanceMarketOptions != nil {
+			ci.InstanceLifecycle = cloud.NormalizeLifecycle(instance.InstanceMarketOptions.MarketType)
+		}
+
 		tfInstances = append(tfInstances, ci)
 	}
 
 	log.Info("Extracted EC2 instances from Terraform config",
 		zap.Int("count", len(tfInstances)))
-	return tfInstances, nil
+	return tfInstances, warnings, nil
 }
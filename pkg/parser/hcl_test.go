@@ -7,6 +7,7 @@ import (
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
 	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/oldmonad/ec2Drift/pkg/utils/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -21,6 +22,10 @@ func TestMain(m *testing.M) {
 
 // TestTerraformParser_Parse verifies the behavior of the TerraformParser's Parse method
 // under different HCL input scenarios.
+func boolPtr(b bool) *bool {
+	return &b
+}
+
 func TestTerraformParser_Parse(t *testing.T) {
 	// Define test cases
 	tests := []struct {
@@ -48,6 +53,7 @@ resource "aws_instance" "web" {
   root_block_device {
     volume_size = 28
     volume_type = "gp3"
+    encrypted   = true
   }
 }
 
@@ -59,8 +65,9 @@ resource "aws_instance" "db" {
     Environment = "production"
   }
   root_block_device {
-    volume_size = 26
-    volume_type = "gp4"
+    volume_size           = 26
+    volume_type           = "gp4"
+    delete_on_termination = false
   }
 }
 `,
@@ -75,11 +82,14 @@ resource "aws_instance" "db" {
 						"Environment": "production",
 					},
 					RootBlockDevice: struct {
-						VolumeSize int    `json:"volume_size"`
-						VolumeType string `json:"volume_type"`
+						VolumeSize          int    `json:"volume_size"`
+						VolumeType          string `json:"volume_type"`
+						Encrypted           *bool  `json:"encrypted,omitempty"`
+						DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 					}{
 						VolumeSize: 28,
 						VolumeType: "gp3",
+						Encrypted:  boolPtr(true),
 					},
 				},
 				{
@@ -92,11 +102,14 @@ resource "aws_instance" "db" {
 						"Environment": "production",
 					},
 					RootBlockDevice: struct {
-						VolumeSize int    `json:"volume_size"`
-						VolumeType string `json:"volume_type"`
+						VolumeSize          int    `json:"volume_size"`
+						VolumeType          string `json:"volume_type"`
+						Encrypted           *bool  `json:"encrypted,omitempty"`
+						DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 					}{
-						VolumeSize: 26,
-						VolumeType: "gp4",
+						VolumeSize:          26,
+						VolumeType:          "gp4",
+						DeleteOnTermination: boolPtr(false),
 					},
 				},
 			},
@@ -122,6 +135,299 @@ resource "aws_instance" "db" {
 			expected:    []cloud.Instance{}, // No EC2 instances expected
 			expectError: false,
 		},
+		{
+			name: "EC2 instance with metadata options",
+			input: `
+		resource "aws_instance" "imds" {
+		  ami           = "ami-imds"
+		  instance_type = "t3.micro"
+		  metadata_options {
+		    http_endpoint = "enabled"
+		    http_tokens   = "required"
+		  }
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:         "imds",
+					AMI:                "ami-imds",
+					InstanceType:       "t3.micro",
+					SecurityGroups:     []string{},
+					Tags:               map[string]string{},
+					MetadataHttpTokens: "required",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance with termination protection",
+			input: `
+		resource "aws_instance" "protected" {
+		  ami                     = "ami-protected"
+		  instance_type           = "t3.micro"
+		  disable_api_termination = true
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:            "protected",
+					AMI:                   "ami-protected",
+					InstanceType:          "t3.micro",
+					SecurityGroups:        []string{},
+					Tags:                  map[string]string{},
+					DisableApiTermination: boolPtr(true),
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance in a subnet",
+			input: `
+		resource "aws_instance" "private" {
+		  ami           = "ami-private"
+		  instance_type = "t3.micro"
+		  subnet_id     = "subnet-123"
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:     "private",
+					AMI:            "ami-private",
+					InstanceType:   "t3.micro",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+					SubnetID:       "subnet-123",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance with user data",
+			input: `
+		resource "aws_instance" "bootstrapped" {
+		  ami           = "ami-bootstrapped"
+		  instance_type = "t3.micro"
+		  user_data     = "#!/bin/bash\necho hello"
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:     "bootstrapped",
+					AMI:            "ami-bootstrapped",
+					InstanceType:   "t3.micro",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+					UserDataHash:   cloud.HashUserData([]byte("#!/bin/bash\necho hello")),
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance with detailed monitoring",
+			input: `
+		resource "aws_instance" "monitored" {
+		  ami           = "ami-monitored"
+		  instance_type = "t3.micro"
+		  monitoring    = true
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:      "monitored",
+					AMI:             "ami-monitored",
+					InstanceType:    "t3.micro",
+					SecurityGroups:  []string{},
+					Tags:            map[string]string{},
+					MonitoringState: "enabled",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance on a dedicated host",
+			input: `
+		resource "aws_instance" "licensed" {
+		  ami           = "ami-licensed"
+		  instance_type = "m5.large"
+		  host_id       = "h-0aaa1111bbbb2222c"
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:     "licensed",
+					AMI:            "ami-licensed",
+					InstanceType:   "m5.large",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+					HostID:         "h-0aaa1111bbbb2222c",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance with public IP association",
+			input: `
+		resource "aws_instance" "public" {
+		  ami                         = "ami-public"
+		  instance_type               = "t3.micro"
+		  associate_public_ip_address = false
+		}
+
+		resource "aws_instance" "unset" {
+		  ami           = "ami-unset"
+		  instance_type = "t3.micro"
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:        "public",
+					AMI:               "ami-public",
+					InstanceType:      "t3.micro",
+					SecurityGroups:    []string{},
+					Tags:              map[string]string{},
+					AssociatePublicIP: boolPtr(false),
+				},
+				{
+					InstanceID:     "unset",
+					AMI:            "ami-unset",
+					InstanceType:   "t3.micro",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance in a capacity reservation",
+			input: `
+		resource "aws_instance" "reserved" {
+		  ami           = "ami-reserved"
+		  instance_type = "m5.large"
+		  affinity      = "host"
+
+		  root_device_type = "ebs"
+
+		  capacity_reservation_specification {
+		    capacity_reservation_target {
+		      capacity_reservation_id = "cr-0123456789abcdef0"
+		    }
+		  }
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:            "reserved",
+					AMI:                   "ami-reserved",
+					InstanceType:          "m5.large",
+					SecurityGroups:        []string{},
+					Tags:                  map[string]string{},
+					Affinity:              "host",
+					CapacityReservationID: "cr-0123456789abcdef0",
+					RootDeviceType:        "ebs",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 spot instance",
+			input: `
+		resource "aws_instance" "batch" {
+		  ami           = "ami-batch"
+		  instance_type = "c5.large"
+		  instance_market_options {
+		    market_type = "spot"
+		    spot_options {
+		      max_price = "0.05"
+		    }
+		  }
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:        "batch",
+					AMI:               "ami-batch",
+					InstanceType:      "c5.large",
+					SecurityGroups:    []string{},
+					Tags:              map[string]string{},
+					InstanceLifecycle: "spot",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instances inheriting defaults",
+			input: `
+		defaults {
+		  ami           = "ami-default"
+		  instance_type = "t3.micro"
+		}
+
+		resource "aws_instance" "web" {
+		  tags = {
+		    Name = "web"
+		  }
+		}
+
+		resource "aws_instance" "db" {
+		  ami           = "ami-db"
+		  instance_type = "r5.large"
+		}
+
+		resource "aws_instance" "api" {
+		  instance_type = "t3.small"
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:     "web",
+					AMI:            "ami-default",
+					InstanceType:   "t3.micro",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{"Name": "web"},
+				},
+				{
+					InstanceID:     "db",
+					AMI:            "ami-db",
+					InstanceType:   "r5.large",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+				},
+				{
+					InstanceID:     "api",
+					AMI:            "ami-default",
+					InstanceType:   "t3.small",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "EC2 instance missing a field the defaults do not set",
+			input: `
+		defaults {
+		  ami = "ami-default"
+		}
+
+		resource "aws_instance" "web" {
+		  instance_type = "t3.micro"
+		}
+
+		resource "aws_instance" "db" {
+		  ami = "ami-db"
+		}
+		`,
+			expected: []cloud.Instance{
+				{
+					InstanceID:     "web",
+					AMI:            "ami-default",
+					InstanceType:   "t3.micro",
+					SecurityGroups: []string{},
+					Tags:           map[string]string{},
+				},
+			},
+			expectError: false,
+		},
 		{
 			name: "minimal EC2 instance configuration",
 			input: `
@@ -138,8 +444,10 @@ resource "aws_instance" "db" {
 					SecurityGroups: []string{},
 					Tags:           map[string]string{},
 					RootBlockDevice: struct {
-						VolumeSize int    `json:"volume_size"`
-						VolumeType string `json:"volume_type"`
+						VolumeSize          int    `json:"volume_size"`
+						VolumeType          string `json:"volume_type"`
+						Encrypted           *bool  `json:"encrypted,omitempty"`
+						DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 					}{},
 				},
 			},
@@ -162,8 +470,10 @@ resource "aws_instance" "db" {
 					SecurityGroups: []string{},
 					Tags:           map[string]string{},
 					RootBlockDevice: struct {
-						VolumeSize int    `json:"volume_size"`
-						VolumeType string `json:"volume_type"`
+						VolumeSize          int    `json:"volume_size"`
+						VolumeType          string `json:"volume_type"`
+						Encrypted           *bool  `json:"encrypted,omitempty"`
+						DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
 					}{},
 				},
 			},
@@ -213,3 +523,47 @@ resource "aws_instance" "db" {
 		})
 	}
 }
+
+func TestTerraformParserUnsupportedAttributes(t *testing.T) {
+	p := &parser.TerraformParser{}
+
+	// The aws_instance schema reads neither security groups nor public IPs
+	attrs, err := validator.NewValidator().ValidateAttributes(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"public_ip", "security_groups"}, parser.UnsupportedAttributes(p, attrs))
+
+	assert.Empty(t, parser.UnsupportedAttributes(p, []string{"tags.Env", "root_block_device.encrypted", "ami"}))
+	assert.Empty(t, parser.UnsupportedAttributes(&parser.JSONParser{}, attrs), "JSON populates every attribute")
+}
+
+func TestTerraformParserWarnings(t *testing.T) {
+	p := &parser.TerraformParser{}
+
+	instances, warnings, err := p.ParseWithWarnings([]byte(`
+		resource "aws_instance" "fallback" {
+		  ami           = "ami-fallback"
+		  instance_type = "t2.medium"
+		  invalid_field = "value"
+		}
+
+		resource "aws_instance" "incomplete" {
+		  tags = { Env = "dev" }
+		}
+
+		resource "aws_instance" "clean" {
+		  ami           = "ami-clean"
+		  instance_type = "t2.micro"
+		}
+		`))
+	require.NoError(t, err)
+
+	require.Len(t, instances, 2)
+	assert.Equal(t, "fallback", instances[0].InstanceID)
+	assert.Equal(t, "clean", instances[1].InstanceID)
+
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "fallback", warnings[0].InstanceID)
+	assert.Contains(t, warnings[0].Message, "partially decoded")
+	assert.Equal(t, "incomplete", warnings[1].InstanceID)
+	assert.Contains(t, warnings[1].Message, "skipped")
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
@@ -8,10 +9,38 @@ import (
 
 type JSONParser struct{}
 
+// jsonInstance accepts raw user_data next to the instance fields, hashing
+// it like the Terraform parser does
+type jsonInstance struct {
+	cloud.Instance
+	UserData string `json:"user_data"`
+}
+
+// jsonDocument is a baseline written by baseline refresh, whose metadata
+// header is not compared
+type jsonDocument struct {
+	Instances []jsonInstance `json:"instances"`
+}
+
+// Parse reads a list of instances, or the instances of a baseline document
 func (p *JSONParser) Parse(content []byte) ([]cloud.Instance, error) {
-	var instances []cloud.Instance
-	if err := json.Unmarshal(content, &instances); err != nil {
+	var raw []jsonInstance
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		var doc jsonDocument
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+		raw = doc.Instances
+	} else if err := json.Unmarshal(content, &raw); err != nil {
 		return nil, err
 	}
+
+	var instances []cloud.Instance
+	for _, r := range raw {
+		if r.UserData != "" {
+			r.Instance.UserDataHash = cloud.HashUserData([]byte(r.UserData))
+		}
+		instances = append(instances, r.Instance)
+	}
 	return instances, nil
 }
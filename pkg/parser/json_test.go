@@ -0,0 +1,34 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParserHashesUserData(t *testing.T) {
+	p := &parser.JSONParser{}
+
+	instances, err := p.Parse([]byte(`[
+		{"instance_id": "web", "ami": "ami-123", "user_data": "#!/bin/bash"},
+		{"instance_id": "db", "ami": "ami-456", "user_data_hash": "abc"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, cloud.HashUserData([]byte("#!/bin/bash")), instances[0].UserDataHash)
+	assert.Equal(t, "abc", instances[1].UserDataHash, "a precomputed hash is kept")
+}
+
+func TestJSONParserBaselineDocument(t *testing.T) {
+	p := &parser.JSONParser{}
+
+	instances, err := p.Parse([]byte(`{
+		"metadata": {"created_at": "2026-10-16T09:00:00Z", "provider": "aws", "instance_count": 1, "checksum": "sha256:00"},
+		"instances": [{"instance_id": "web", "ami": "ami-123"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, []cloud.Instance{{InstanceID: "web", AMI: "ami-123"}}, instances)
+}
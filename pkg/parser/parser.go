@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/oldmonad/ec2Drift/pkg/cloud"
 )
 
@@ -8,6 +11,48 @@ type Parser interface {
 	Parse(content []byte) ([]cloud.Instance, error)
 }
 
+// WarningParser is implemented by parsers that can recover from problems
+// in part of the config. The warnings name the instance, by its resource
+// name, that was decoded partially or skipped.
+type WarningParser interface {
+	ParseWithWarnings(content []byte) ([]cloud.Instance, []cloud.Warning, error)
+}
+
+// AttributeSupporter is implemented by parsers that only populate some of
+// the attributes drift can be checked on. Parsers without it populate all.
+type AttributeSupporter interface {
+	SupportedAttributes() []string
+}
+
+// UnsupportedAttributes returns the attributes p never populates, in the
+// order requested. Comparing them against the live state is meaningless.
+func UnsupportedAttributes(p Parser, attrs []string) []string {
+	supporter, ok := p.(AttributeSupporter)
+	if !ok {
+		return nil
+	}
+	supported := supporter.SupportedAttributes()
+
+	var unsupported []string
+	for _, attr := range attrs {
+		if !supportsAttribute(supported, attr) {
+			unsupported = append(unsupported, attr)
+		}
+	}
+	return unsupported
+}
+
+// supportsAttribute matches attributes by their dotted path, so tags
+// covers tags.Env and root_block_device.volume_size covers root_block_device
+func supportsAttribute(supported []string, attr string) bool {
+	for _, s := range supported {
+		if attr == s || strings.HasPrefix(attr, s+".") || strings.HasPrefix(s, attr+".") {
+			return true
+		}
+	}
+	return false
+}
+
 type ParserType string
 
 const (
@@ -15,3 +60,14 @@ const (
 	JSON      ParserType = "json"
 	Unknown   ParserType = "unknown"
 )
+
+// DetectFormat returns the input format of a desired config path by its
+// extension, ignoring the query of git references. Anything but .json,
+// e.g. .tf or .tfvars, reads as Terraform like the --input-format default.
+func DetectFormat(path string) ParserType {
+	path, _, _ = strings.Cut(path, "?")
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return JSON
+	}
+	return Terraform
+}
@@ -0,0 +1,24 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]parser.ParserType{
+		"state/prod.json":   parser.JSON,
+		"state/PROD.JSON":   parser.JSON,
+		"state/main.tf":     parser.Terraform,
+		"state/prod.tfvars": parser.Terraform,
+		"state":             parser.Terraform,
+		"git::https://github.com/org/infra.git//envs/prod.json?ref=v2": parser.JSON,
+		"git::https://github.com/org/infra.git//envs/main.tf?ref=v2":   parser.Terraform,
+	}
+
+	for path, expected := range tests {
+		assert.Equal(t, expected, parser.DetectFormat(path), path)
+	}
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Factory builds a parser for one input format
+type Factory func() Parser
+
+// Registry maps input formats to the parsers that read them. Supporting a
+// new format is a call to Register. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[ParserType]Factory
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[ParserType]Factory)}
+}
+
+// DefaultRegistry returns a registry holding the built in Terraform and
+// JSON parsers
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Terraform, func() Parser { return &TerraformParser{} })
+	r.Register(JSON, func() Parser { return &JSONParser{} })
+	return r
+}
+
+// Register adds or replaces the parser for a format
+func (r *Registry) Register(format ParserType, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[format] = factory
+}
+
+// Lookup builds the parser registered for a format, or returns
+// ErrUnknownParser when there is none
+func (r *Registry) Lookup(format ParserType) (Parser, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[format]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errors.NewErrUnknownParser(string(format), r.Formats())
+	}
+	return factory(), nil
+}
+
+// Formats returns the registered formats in alphabetical order
+func (r *Registry) Formats() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	formats := make([]string, 0, len(r.factories))
+	for format := range r.factories {
+		formats = append(formats, string(format))
+	}
+	sort.Strings(formats)
+	return formats
+}
@@ -0,0 +1,35 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	r := parser.DefaultRegistry()
+	assert.Equal(t, []string{"json", "terraform"}, r.Formats())
+
+	p, err := r.Lookup(parser.Terraform)
+	require.NoError(t, err)
+	assert.IsType(t, &parser.TerraformParser{}, p)
+
+	p, err = r.Lookup(parser.JSON)
+	require.NoError(t, err)
+	assert.IsType(t, &parser.JSONParser{}, p)
+}
+
+func TestRegistryUnregisteredFormat(t *testing.T) {
+	r := parser.NewRegistry()
+
+	_, err := r.Lookup(parser.Terraform)
+	assert.ErrorAs(t, err, &errors.ErrUnknownParser{})
+
+	r.Register(parser.Terraform, func() parser.Parser { return &parser.JSONParser{} })
+	p, err := r.Lookup(parser.Terraform)
+	require.NoError(t, err)
+	assert.IsType(t, &parser.JSONParser{}, p, "a registration replaces the parser for its format")
+}
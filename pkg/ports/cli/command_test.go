@@ -2,30 +2,63 @@ package cli_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/fatih/color"
+	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/baseline"
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
+	config "github.com/oldmonad/ec2Drift/pkg/config/cloud"
+	awsConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
 	"github.com/oldmonad/ec2Drift/pkg/config/env"
+	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/hook"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/output"
 	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
 	"github.com/oldmonad/ec2Drift/pkg/ports/cli"
+	"github.com/oldmonad/ec2Drift/pkg/utils/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
+func TestMain(m *testing.M) {
+	logger.SetLogger(zap.NewNop())
+	m.Run()
+}
+
 // Mock AppRunner simulates the application runner for testing purposes
 type MockAppRunner struct {
 	mock.Mock
 }
 
 // Run simulates the Run method of the application runner
-func (m *MockAppRunner) Run(ctx context.Context, attrs []string, format parser.ParserType, output ports.Runtype) error {
-	args := m.Called(ctx, attrs, format, output)
+func (m *MockAppRunner) Run(ctx context.Context, attrs []string, format parser.ParserType, output ports.Runtype, opts app.RunOptions) error {
+	args := m.Called(ctx, attrs, format, output, opts)
 	return args.Error(0)
 }
 
 // Mock Validator simulates the validator for testing purposes
+type MockCloudProvider struct {
+	mock.Mock
+}
+
+func (m *MockCloudProvider) FetchInstances(ctx context.Context, cfg config.ProviderConfig) ([]cloud.Instance, error) {
+	args := m.Called(ctx, cfg)
+	instances, _ := args.Get(0).([]cloud.Instance)
+	return instances, args.Error(1)
+}
+
 type MockValidator struct {
 	mock.Mock
 }
@@ -42,6 +75,12 @@ func (m *MockValidator) ValidateAttributes(attrs []string) ([]string, error) {
 	return args.Get(0).([]string), args.Error(1)
 }
 
+// ValidateOnlyFilters simulates validating the output filter input
+func (m *MockValidator) ValidateOnlyFilters(filters []string) ([]string, error) {
+	args := m.Called(filters)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // Mock Server simulates the server for testing purposes
 type MockServer struct {
 	mock.Mock
@@ -119,9 +158,15 @@ func TestInitiateCommands(t *testing.T) {
 	// Initiate root command and verify its structure
 	rootCmd := cmd.InitiateCommands()
 	assert.Equal(t, "ec2drift", rootCmd.Use)
-	assert.Len(t, rootCmd.Commands(), 2)
-	assert.Equal(t, "run", rootCmd.Commands()[0].Use)
-	assert.Equal(t, "serve", rootCmd.Commands()[1].Use)
+	// Cobra sorts subcommands by name
+	assert.Len(t, rootCmd.Commands(), 7)
+	assert.Equal(t, "baseline", rootCmd.Commands()[0].Use)
+	assert.Equal(t, "compare", rootCmd.Commands()[1].Use)
+	assert.Equal(t, "config", rootCmd.Commands()[2].Use)
+	assert.Equal(t, "export", rootCmd.Commands()[3].Use)
+	assert.Equal(t, "fetch", rootCmd.Commands()[4].Use)
+	assert.Equal(t, "run", rootCmd.Commands()[5].Use)
+	assert.Equal(t, "serve", rootCmd.Commands()[6].Use)
 }
 
 // TestRunCommandSuccess tests the successful execution of the "run" command
@@ -133,9 +178,10 @@ func TestRunCommandSuccess(t *testing.T) {
 	// Set up validator mock expectations
 	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
 	mockValidator.On("ValidateAttributes", []string{"attr1"}).Return([]string{"valid_attr1"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
 
 	// Set up app runner mock expectations
-	mockApp.On("Run", mock.Anything, []string{"valid_attr1"}, parser.ParserType("terraform"), ports.CLI).Return(nil)
+	mockApp.On("Run", mock.Anything, []string{"valid_attr1"}, parser.ParserType("terraform"), ports.CLI, mock.Anything).Return(nil)
 
 	// Create command and initiate root command
 	cmd := cli.NewCommand(
@@ -147,7 +193,7 @@ func TestRunCommandSuccess(t *testing.T) {
 	rootCmd := cmd.InitiateCommands()
 
 	// Set args to run the command with flags
-	rootCmd.SetArgs([]string{"run", "--format", "terraform", "--attributes", "attr1"})
+	rootCmd.SetArgs([]string{"run", "--input-format", "terraform", "--attributes", "attr1"})
 
 	// Execute the root command
 	err := rootCmd.Execute()
@@ -176,7 +222,7 @@ func TestRunCommandInvalidFormat(t *testing.T) {
 		testEnv.Configurations,
 	)
 	rootCmd := cmd.InitiateCommands()
-	rootCmd.SetArgs([]string{"run", "--format", "invalid-format", "--attributes", "attr1"})
+	rootCmd.SetArgs([]string{"run", "--input-format", "invalid-format", "--attributes", "attr1"})
 
 	// Execute and capture error
 	err := rootCmd.Execute()
@@ -185,7 +231,7 @@ func TestRunCommandInvalidFormat(t *testing.T) {
 	// Assert error message is as expected
 	assert.Contains(t, cleanedErr, "invalid format specified")
 	mockValidator.AssertExpectations(t)
-	mockApp.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockApp.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 // TestServeCommandSuccess tests the successful execution of the "serve" command
@@ -222,6 +268,22 @@ func TestServeCommandSuccess(t *testing.T) {
 	mockServer.AssertNumberOfCalls(t, "Start", 1)
 }
 
+// TestServeCommandScheduleWithoutStatePath tests that serve refuses to start
+// scheduled checks without their input, while other commands still run
+func TestServeCommandScheduleWithoutStatePath(t *testing.T) {
+	mockServer := new(MockServer)
+	testEnv := NewTestEnvConfigurations()
+	testEnv.Schedule = "15m"
+
+	cmd := cli.NewCommand(new(MockAppRunner), new(MockValidator), mockServer, testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"serve"})
+
+	err := rootCmd.Execute()
+	assert.ErrorAs(t, err, &cerrors.ErrInvalidSchedule{})
+	mockServer.AssertNotCalled(t, "Start", mock.Anything)
+}
+
 // TestServeCommandPortError tests the "serve" command when there is a port error
 func TestServeCommandPortError(t *testing.T) {
 	mockApp := new(MockAppRunner)
@@ -258,6 +320,56 @@ func TestServeCommandPortError(t *testing.T) {
 	mockServer.AssertNumberOfCalls(t, "Start", 1)
 }
 
+// TestServeCommandPortFlag tests that --port overrides HTTP_PORT and is
+// validated before the server starts
+func TestServeCommandPortFlag(t *testing.T) {
+	serve := func(t *testing.T, server *MockServer, args ...string) error {
+		testEnv := NewTestEnvConfigurations()
+		testEnv.HttpPort = 9090 // As if HTTP_PORT=9090
+
+		cmd := cli.NewCommand(new(MockAppRunner), new(MockValidator), server, testEnv.Configurations)
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs(append([]string{"serve"}, args...))
+		return rootCmd.Execute()
+	}
+
+	t.Run("flag overrides env", func(t *testing.T) {
+		mockServer := new(MockServer)
+		mockServer.On("Start", "8181").Return(nil)
+
+		require.NoError(t, serve(t, mockServer, "--port", "8181"))
+		mockServer.AssertExpectations(t)
+	})
+
+	t.Run("env applies without the flag", func(t *testing.T) {
+		mockServer := new(MockServer)
+		mockServer.On("Start", "9090").Return(nil)
+
+		require.NoError(t, serve(t, mockServer))
+		mockServer.AssertExpectations(t)
+	})
+
+	t.Run("invalid flag", func(t *testing.T) {
+		mockServer := new(MockServer)
+
+		err := serve(t, mockServer, "--port", "http")
+		var parseErr cerrors.ErrPortParse
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, "--port", parseErr.Source)
+		assert.Contains(t, err.Error(), `invalid --port="http"`)
+		mockServer.AssertNotCalled(t, "Start", mock.Anything)
+	})
+
+	t.Run("flag out of range", func(t *testing.T) {
+		mockServer := new(MockServer)
+
+		err := serve(t, mockServer, "--port", "70000")
+		assert.ErrorAs(t, err, &cerrors.ErrPortOutOfRange{})
+		assert.EqualError(t, err, "--port out of bounds: 70000 (must be 1–65535)")
+		mockServer.AssertNotCalled(t, "Start", mock.Anything)
+	})
+}
+
 // TestRunCommandInvalidAttributes tests the "run" command when invalid attributes are provided
 func TestRunCommandInvalidAttributes(t *testing.T) {
 	mockApp := new(MockAppRunner)
@@ -294,6 +406,862 @@ func TestRunCommandInvalidAttributes(t *testing.T) {
 	mockApp.AssertNotCalled(t, "Run")
 }
 
+// TestRunCommandOnlyFilter tests that the --only filter is validated and passed to the app runner
+func TestRunCommandOnlyFilter(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami", "tags"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{"added", "tags"}).Return([]string{"added", "tags"}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami", "tags"}, parser.ParserType("terraform"), ports.CLI,
+		app.RunOptions{Only: []string{"added", "tags"}, DefaultAttributes: true}).Return(nil)
+
+	cmd := cli.NewCommand(
+		mockApp,
+		mockValidator,
+		new(MockServer),
+		testEnv.Configurations,
+	)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--only", "added,tags"})
+
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	mockValidator.AssertExpectations(t)
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandInvalidOnlyFilter tests that an invalid --only filter stops the run
+func TestRunCommandInvalidOnlyFilter(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{"bogus"}).Return([]string{}, errors.New("invalid attributes: [bogus]"))
+
+	cmd := cli.NewCommand(
+		mockApp,
+		mockValidator,
+		new(MockServer),
+		testEnv.Configurations,
+	)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--only", "bogus"})
+
+	err := rootCmd.Execute()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid attributes: [bogus]")
+	mockApp.AssertNotCalled(t, "Run")
+}
+
+// TestRunCommandTimeout tests that a slow run is cancelled once --timeout elapses
+func TestRunCommandTimeout(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+
+	// Simulate a cloud provider that only returns once the context is cancelled
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("terraform"), ports.CLI, mock.Anything).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(context.DeadlineExceeded)
+
+	cmd := cli.NewCommand(
+		mockApp,
+		mockValidator,
+		new(MockServer),
+		testEnv.Configurations,
+	)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--timeout", "50ms"})
+
+	err := rootCmd.Execute()
+
+	require.Error(t, err)
+	var timeoutErr cerrors.ErrRunTimeout
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.Contains(t, err.Error(), "drift check timed out after 50ms")
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandPageSize tests that --page-size is validated and passed to the app runner
+func TestRunCommandPageSize(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("terraform"), ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool { return opts.PageSize == 100 })).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--page-size", "100"})
+
+	assert.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+
+	// Out of range page sizes stop the run before it starts
+	mockApp = new(MockAppRunner)
+	cmd = cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd = cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--page-size", "2000"})
+
+	err := rootCmd.Execute()
+	var rangeErr cerrors.ErrPageSizeOutOfRange
+	assert.ErrorAs(t, err, &rangeErr)
+	mockApp.AssertNotCalled(t, "Run")
+}
+
+// TestRunCommandCallTimeout tests that --call-timeout is passed to the app runner
+func TestRunCommandCallTimeout(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("terraform"), ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool { return opts.CallTimeout == 10*time.Second })).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--call-timeout", "10s"})
+
+	assert.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandAutoAttributes tests that --auto-attributes is passed to the app runner
+func TestRunCommandAutoAttributes(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami", "tags"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami", "tags"}, parser.ParserType("terraform"), ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool { return opts.AutoAttributes })).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--auto-attributes"})
+
+	assert.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandFailOnSeverity tests that --fail-on-severity is validated and passed to the app runner
+func TestRunCommandFailOnSeverity(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("terraform"), ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool { return opts.FailOnSeverity == driftchecker.SeverityCritical })).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--fail-on-severity", "critical"})
+	assert.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+
+	rootCmd = cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--fail-on-severity", "urgent"})
+	assert.ErrorAs(t, rootCmd.Execute(), &cerrors.ErrUnsupportedSeverity{})
+}
+
+// TestColorFlag tests that --color is validated and applied before the command runs
+func TestColorFlag(t *testing.T) {
+	original := color.NoColor
+	t.Cleanup(func() { color.NoColor = original })
+
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("terraform"), ports.CLI, mock.Anything).Return(nil)
+
+	for mode, expected := range map[string]bool{"always": false, "never": true, "auto": true} {
+		color.NoColor = !expected
+		cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs([]string{"run", "--color", mode})
+
+		require.NoError(t, rootCmd.Execute(), mode)
+		assert.Equal(t, expected, color.NoColor, mode)
+	}
+
+	cmd := cli.NewCommand(new(MockAppRunner), mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--color", "sometimes"})
+
+	err := rootCmd.Execute()
+	assert.ErrorAs(t, err, &cerrors.ErrUnsupportedColorMode{})
+}
+
+// TestRunCommandNoAttributesSelected tests that an empty attribute set is surfaced as an error
+func TestRunCommandNoAttributesSelected(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{}, cerrors.NewErrNoAttributesSelected())
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run"})
+
+	err := rootCmd.Execute()
+	assert.ErrorAs(t, err, &cerrors.ErrNoAttributesSelected{})
+	mockApp.AssertNotCalled(t, "Run")
+}
+
+// TestRunCommandOutputFlags tests that the output flags are validated and passed to the app runner
+func TestRunCommandOutputFlags(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.ParserType("terraform"), nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("terraform"), ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool {
+			return opts.Output == output.CSV && opts.OutputFile == "/tmp/report.csv" && opts.Quiet && opts.ExitSummary
+		})).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--output", "csv", "--output-file", "/tmp/report.csv", "--quiet", "--exit-summary"})
+
+	assert.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+
+	// Unknown formats stop the run before it starts
+	mockApp = new(MockAppRunner)
+	cmd = cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd = cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "-o", "xml"})
+
+	err := rootCmd.Execute()
+	assert.ErrorAs(t, err, &cerrors.ErrUnsupportedOutputFormat{})
+	mockApp.AssertNotCalled(t, "Run")
+}
+
+// TestRunCommandDeprecatedFormatFlag tests that --format still selects the
+// desired config format and warns that it is deprecated
+func TestRunCommandDeprecatedFormatFlag(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "json").Return(parser.JSON, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.CLI, mock.Anything).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	// Cobra prints flag warnings to the output writer, stderr by default
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"run", "--format", "json"})
+
+	require.NoError(t, rootCmd.Execute())
+	assert.Contains(t, out.String(), "Flag --format has been deprecated, use --input-format instead")
+	mockValidator.AssertExpectations(t)
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandOutputFormatAlias tests that --output-format selects the report format
+func TestRunCommandOutputFormatAlias(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool { return opts.Output == output.JSON })).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--output-format", "json"})
+
+	require.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandDriftIsSilent tests that drift reported by the app is
+// returned for the exit code without cobra printing it as a failure
+func TestRunCommandDriftIsSilent(t *testing.T) {
+	for name, runErr := range map[string]error{
+		"drift":                 cerrors.NewDriftDetected(),
+		"no matching instances": cerrors.NewErrNoMatchingInstances(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockApp := new(MockAppRunner)
+			mockValidator := new(MockValidator)
+			testEnv := NewTestEnvConfigurations()
+
+			mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+			mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+			mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+			mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI, mock.Anything).
+				Return(runErr)
+
+			var stderr strings.Builder
+			cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+			rootCmd := cmd.InitiateCommands()
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetOut(&stderr)
+			rootCmd.SetArgs([]string{"run"})
+
+			assert.Equal(t, runErr, rootCmd.Execute())
+			assert.Empty(t, stderr.String(), "no error or usage printed")
+			mockApp.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCompareCommand tests that the "compare" command reports the drift
+// between two state files without a cloud provider
+func TestCompareCommand(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	cmd := cli.NewCommand(app.NewApp(env.Configurations{}), validator.NewValidator(), new(MockServer), &env.Configurations{})
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"compare",
+		"--old-state", filepath.Join("testdata", "old.tf"),
+		"--new-state", filepath.Join("testdata", "new.tf"),
+		"--attributes", "ami,instance_type",
+		"--output-file", reportPath, "--quiet",
+	})
+	require.ErrorAs(t, rootCmd.Execute(), &cerrors.ErrDriftDetected{}, "drift is returned for exit code 2")
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var document output.Document
+	require.NoError(t, json.Unmarshal(data, &document))
+	reports := document.Reports
+	require.Len(t, reports, 1)
+	assert.Equal(t, "web-server", reports[0].Name)
+	require.Len(t, reports[0].Drifts, 1)
+	assert.Equal(t, "instance_type", reports[0].Drifts[0].Attribute)
+	assert.Equal(t, "t2.small", reports[0].Drifts[0].ExpectedValue)
+	assert.Equal(t, "t3.medium", reports[0].Drifts[0].ActualValue)
+}
+
+// TestComparePresetFlag tests that --preset adds the attributes of the preset
+// and that unknown presets are rejected
+func TestComparePresetFlag(t *testing.T) {
+	t.Run("compute", func(t *testing.T) {
+		reportPath := filepath.Join(t.TempDir(), "report.json")
+
+		cmd := cli.NewCommand(app.NewApp(env.Configurations{}), validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs([]string{"compare",
+			"--old-state", filepath.Join("testdata", "old.tf"),
+			"--new-state", filepath.Join("testdata", "new.tf"),
+			"--preset", "compute",
+			"--output-file", reportPath, "--quiet",
+		})
+		require.ErrorAs(t, rootCmd.Execute(), &cerrors.ErrDriftDetected{})
+
+		data, err := os.ReadFile(reportPath)
+		require.NoError(t, err)
+		var document output.Document
+		require.NoError(t, json.Unmarshal(data, &document))
+		require.Len(t, document.Reports, 1)
+		require.Len(t, document.Reports[0].Drifts, 1)
+		assert.Equal(t, "instance_type", document.Reports[0].Drifts[0].Attribute)
+	})
+
+	t.Run("unknown preset", func(t *testing.T) {
+		cmd := cli.NewCommand(app.NewApp(env.Configurations{}), validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs([]string{"compare",
+			"--old-state", filepath.Join("testdata", "old.tf"),
+			"--new-state", filepath.Join("testdata", "new.tf"),
+			"--preset", "billing",
+		})
+		err := rootCmd.Execute()
+		require.ErrorAs(t, err, &cerrors.ErrUnknownPreset{})
+		assert.Contains(t, err.Error(), `"billing"`)
+	})
+}
+
+// TestCompareOutputDirFlag tests that --output-dir writes the reports of
+// each application to a file of its own
+func TestCompareOutputDirFlag(t *testing.T) {
+	compare := func(extra ...string) error {
+		cmd := cli.NewCommand(app.NewApp(env.Configurations{}), validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs(append([]string{"compare",
+			"--old-state", filepath.Join("testdata", "old.tf"),
+			"--new-state", filepath.Join("testdata", "new.tf"),
+			"--quiet",
+		}, extra...))
+		return rootCmd.Execute()
+	}
+
+	t.Run("one file per application", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "reports")
+		require.ErrorAs(t, compare("--output-dir", dir, "--group-by", "application"), &cerrors.ErrDriftDetected{})
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "web-server.json", entries[0].Name())
+
+		data, err := os.ReadFile(filepath.Join(dir, "web-server.json"))
+		require.NoError(t, err)
+		var document output.GroupedDocument
+		require.NoError(t, json.Unmarshal(data, &document))
+		require.Len(t, document.Groups, 1)
+		assert.Equal(t, "web-server", document.Groups[0].Application)
+		require.Len(t, document.Groups[0].Instances, 1)
+		assert.Equal(t, "instance_type", document.Groups[0].Instances[0].Drifts[0].Attribute)
+	})
+
+	t.Run("needs group-by application", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "reports")
+		require.ErrorAs(t, compare("--output-dir", dir), &cerrors.ErrOutputDirNeedsGroupBy{})
+		assert.NoDirExists(t, dir)
+	})
+}
+
+// TestCompareIgnoreCaseValuesFlag tests that --ignore-case-values stops
+// values that only differ in case from being reported as drift
+func TestCompareIgnoreCaseValuesFlag(t *testing.T) {
+	dir := t.TempDir()
+	oldState := filepath.Join(dir, "old.tf")
+	newState := filepath.Join(dir, "new.tf")
+	require.NoError(t, os.WriteFile(oldState, []byte(`resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "T3.Micro"
+
+  tags = {
+    Name = "web-server"
+  }
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(newState, []byte(`resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+
+  tags = {
+    Name = "web-server"
+  }
+}
+`), 0o644))
+	compare := func(extra ...string) error {
+		cmd := cli.NewCommand(app.NewApp(env.Configurations{}), validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs(append([]string{"compare", "--old-state", oldState, "--new-state", newState, "--quiet"}, extra...))
+		return rootCmd.Execute()
+	}
+
+	require.ErrorAs(t, compare(), &cerrors.ErrDriftDetected{})
+	assert.NoError(t, compare("--ignore-case-values"))
+}
+
+// TestExportCommand tests that the "export" command writes the live
+// instances of the provider to the output file
+func TestExportCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	live := []cloud.Instance{{
+		InstanceID:     "i-123",
+		AMI:            "ami-123",
+		InstanceType:   "t3.micro",
+		SecurityGroups: []string{"sg-1"},
+		Tags:           map[string]string{"Name": "web"},
+	}}
+
+	provider := new(MockCloudProvider)
+	provider.On("FetchInstances", mock.Anything, mock.MatchedBy(func(cfg config.ProviderConfig) bool {
+		return cfg.GetRegion() == "eu-west-1"
+	})).Return(live, nil).Once()
+
+	a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+	a.SetCloudProvider(config.AWS, provider)
+
+	cmd := cli.NewCommand(a, validator.NewValidator(), new(MockServer), &env.Configurations{})
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"export", "--output", path, "--region", "eu-west-1", "-a", "ami"})
+	require.NoError(t, rootCmd.Execute())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"instance_id": "i-123",
+		"ami": "ami-123",
+		"instance_type": "t3.micro",
+		"security_groups": ["sg-1"],
+		"tags": {"Name": "web"},
+		"root_block_device": {"volume_size": 0, "volume_type": ""}
+	}]`, string(data))
+	provider.AssertExpectations(t)
+
+	// The export reads back as desired state for compare
+	instances, err := a.ParseConfigInstances(data, parser.JSON)
+	require.NoError(t, err)
+	assert.Equal(t, []cloud.Instance{{
+		InstanceID:     "i-123",
+		AMI:            "ami-123",
+		InstanceType:   "t3.micro",
+		SecurityGroups: []string{"sg-1"},
+		Tags:           map[string]string{"Name": "web"},
+	}}, instances)
+}
+
+// TestBaselineRefreshCommand tests that baseline refresh replaces the baseline with the live state and its metadata
+func TestBaselineRefreshCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"instance_id": "i-old"}]`), 0o644))
+	live := []cloud.Instance{{
+		InstanceID:   "i-123",
+		AMI:          "ami-123",
+		InstanceType: "t3.micro",
+		Tags:         map[string]string{"Name": "web"},
+		AccountID:    "123456789012",
+	}}
+
+	provider := new(MockCloudProvider)
+	provider.On("FetchInstances", mock.Anything, mock.MatchedBy(func(cfg config.ProviderConfig) bool {
+		return cfg.GetRegion() == "eu-west-1"
+	})).Return(live, nil).Once()
+
+	a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+	a.SetCloudProvider(config.AWS, provider)
+
+	cmd := cli.NewCommand(a, validator.NewValidator(), new(MockServer), &env.Configurations{})
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"baseline", "refresh", "--file", path, "--region", "eu-west-1"})
+	require.NoError(t, rootCmd.Execute())
+	provider.AssertExpectations(t)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc baseline.Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "aws", doc.Metadata.Provider)
+	assert.Equal(t, "eu-west-1", doc.Metadata.Region)
+	assert.Equal(t, "123456789012", doc.Metadata.Account)
+	assert.Equal(t, 1, doc.Metadata.InstanceCount)
+	assert.WithinDuration(t, time.Now(), doc.Metadata.CreatedAt, time.Minute)
+	sum, err := baseline.Checksum(doc.Instances)
+	require.NoError(t, err)
+	assert.Equal(t, sum, doc.Metadata.Checksum)
+
+	// The refreshed baseline reads back for --baseline
+	instances, err := a.ParseConfigInstances(data, parser.JSON)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-123", instances[0].InstanceID)
+}
+
+// TestConfigShowCommand tests that config show prints the effective configuration with secrets masked
+func TestConfigShowCommand(t *testing.T) {
+	a := app.NewApp(env.Configurations{
+		StatePath:         "./samples/main.tf",
+		HttpPort:          9090,
+		CloudProviderType: config.AWS,
+		CloudConfig:       &awsConfig.Config{AccessKey: "AKIAEXAMPLE", SecretKey: "wJalrXUtnFEMI", Region: "eu-west-1"},
+	})
+	show := func(t *testing.T, args ...string) string {
+		var out strings.Builder
+		cmd := cli.NewCommand(a, validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs(append([]string{"config", "show"}, args...))
+		require.NoError(t, rootCmd.Execute())
+		return out.String()
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var values map[string]string
+		require.NoError(t, json.Unmarshal([]byte(show(t, "-o", "json")), &values))
+		assert.Equal(t, "AKIA****", values["AWS_ACCESS_KEY_ID"])
+		assert.Equal(t, "****", values["AWS_SECRET_ACCESS_KEY"])
+		assert.Equal(t, "eu-west-1", values["AWS_REGION"])
+		assert.Equal(t, "aws", values["CLOUD_PROVIDER"])
+		assert.Equal(t, "./samples/main.tf", values["STATE_PATH"])
+		assert.Equal(t, "9090", values["HTTP_PORT"])
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out := show(t)
+		assert.Contains(t, out, "SETTING")
+		assert.Regexp(t, `AWS_REGION\s+eu-west-1`, out)
+		assert.Regexp(t, `STATE_PATH\s+\./samples/main\.tf`, out)
+		assert.NotContains(t, out, "AKIAEXAMPLE")
+		assert.NotContains(t, out, "wJalrXUtnFEMI")
+	})
+}
+
+// TestFetchCommand tests that fetch prints the provider's instances without comparing them
+func TestFetchCommand(t *testing.T) {
+	live := []cloud.Instance{{
+		InstanceID:     "i-123",
+		AMI:            "ami-123",
+		InstanceType:   "t3.micro",
+		SecurityGroups: []string{"sg-1", "sg-2"},
+		Tags:           map[string]string{"Name": "web"},
+		PrivateIP:      "10.0.0.1",
+	}}
+
+	t.Run("JSON", func(t *testing.T) {
+		provider := new(MockCloudProvider)
+		provider.On("FetchInstances", mock.Anything, mock.MatchedBy(func(cfg config.ProviderConfig) bool {
+			return cfg.GetRegion() == "eu-west-1"
+		})).Return(live, nil).Once()
+
+		a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+		a.SetCloudProvider(config.AWS, provider)
+
+		var out strings.Builder
+		cmd := cli.NewCommand(a, validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"fetch", "--output", "json", "--region", "eu-west-1"})
+		require.NoError(t, rootCmd.Execute())
+
+		assert.JSONEq(t, `[{
+			"instance_id": "i-123",
+			"ami": "ami-123",
+			"instance_type": "t3.micro",
+			"security_groups": ["sg-1", "sg-2"],
+			"tags": {"Name": "web"},
+			"private_ip": "10.0.0.1",
+			"root_block_device": {"volume_size": 0, "volume_type": ""}
+		}]`, out.String())
+		provider.AssertExpectations(t)
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		provider := new(MockCloudProvider)
+		provider.On("FetchInstances", mock.Anything, mock.Anything).Return(live, nil).Once()
+
+		a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+		a.SetCloudProvider(config.AWS, provider)
+
+		var out strings.Builder
+		cmd := cli.NewCommand(a, validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"fetch"})
+		require.NoError(t, rootCmd.Execute())
+
+		assert.Contains(t, out.String(), "INSTANCE ID")
+		assert.Contains(t, out.String(), "i-123")
+		assert.Contains(t, out.String(), "sg-1,sg-2")
+		provider.AssertExpectations(t)
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		provider := new(MockCloudProvider)
+		a := app.NewApp(env.Configurations{CloudProviderType: config.AWS, CloudConfig: &awsConfig.Config{Region: "us-east-1"}})
+		a.SetCloudProvider(config.AWS, provider)
+
+		cmd := cli.NewCommand(a, validator.NewValidator(), new(MockServer), &env.Configurations{})
+		rootCmd := cmd.InitiateCommands()
+		rootCmd.SetArgs([]string{"fetch", "--output", "csv"})
+		assert.Error(t, rootCmd.Execute())
+		provider.AssertNotCalled(t, "FetchInstances", mock.Anything, mock.Anything)
+	})
+}
+
+// TestCompareCommandRequiresStateFiles tests that both state files must be given
+func TestCompareCommandRequiresStateFiles(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	cmd := cli.NewCommand(mockApp, new(MockValidator), new(MockServer), NewTestEnvConfigurations().Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"compare", "--old-state", "old.tf"})
+
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, `required flag(s) "new-state" not set`)
+	mockApp.AssertNotCalled(t, "Run")
+}
+
+// TestRunCommandExec tests that the exec hook and its default timeout reach the app runner
+func TestRunCommandExec(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		app.RunOptions{Only: []string{}, DefaultAttributes: true, Exec: "./notify.sh", ExecTimeout: hook.DefaultTimeout}).
+		Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--exec", "./notify.sh"})
+
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandTemplateFile tests that the report template is parsed before the run and implies the template format
+func TestRunCommandTemplateFile(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		mock.MatchedBy(func(opts app.RunOptions) bool {
+			return opts.Output == output.Template && opts.Template != nil && opts.Template.Name() == "report.tmpl"
+		})).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--template-file", "testdata/report.tmpl"})
+
+	require.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		err  interface{}
+	}{
+		{"template format without file", []string{"run", "-o", "template"}, &cerrors.ErrTemplateFile{}},
+		{"file with another format", []string{"run", "-o", "json", "--template-file", "testdata/report.tmpl"}, &cerrors.ErrTemplateFile{}},
+		{"missing file", []string{"run", "--template-file", "testdata/missing.tmpl"}, &cerrors.ErrInvalidTemplate{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApp := new(MockAppRunner)
+			cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+			rootCmd := cmd.InitiateCommands()
+			rootCmd.SetArgs(tc.args)
+
+			assert.ErrorAs(t, rootCmd.Execute(), tc.err)
+			mockApp.AssertNotCalled(t, "Run")
+		})
+	}
+}
+
+// TestRunCommandCheckCredExpiry tests that the credential expiry check and its buffer reach the app runner
+func TestRunCommandCheckCredExpiry(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		app.RunOptions{Only: []string{}, DefaultAttributes: true, CheckCredExpiry: true, CredExpiryBuffer: 30 * time.Minute}).
+		Return(cerrors.NewErrCredentialsExpiringSoon(time.Now(), 30*time.Minute))
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--check-cred-expiry", "--cred-expiry-buffer", "30m"})
+
+	err := rootCmd.Execute()
+	assert.ErrorAs(t, err, &cerrors.ErrCredentialsExpiringSoon{})
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandInstanceSelection tests that the instance selection flags reach the app runner
+func TestRunCommandInstanceSelection(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		app.RunOptions{Only: []string{}, DefaultAttributes: true, Instances: []string{"i-123", "i-456"}, ExcludeInstances: []string{"i-456"}}).
+		Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--instances", "i-123,i-456", "--exclude-instances", "i-456"})
+
+	require.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+}
+
+// TestRunCommandBaseline tests that --baseline reaches the app runner and excludes --config-file
+func TestRunCommandBaseline(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		app.RunOptions{Only: []string{}, DefaultAttributes: true, Baseline: "baseline.json"}).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--baseline", "baseline.json"})
+	require.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+
+	rootCmd = cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--baseline", "baseline.json", "--config-file", "main.tf"})
+	assert.ErrorContains(t, rootCmd.Execute(), "none of the others can be")
+}
+
+func TestRunCommandGroupBy(t *testing.T) {
+	mockApp := new(MockAppRunner)
+	mockValidator := new(MockValidator)
+	testEnv := NewTestEnvConfigurations()
+
+	mockValidator.On("ValidateFormat", "terraform").Return(parser.Terraform, nil)
+	mockValidator.On("ValidateAttributes", []string{}).Return([]string{"ami"}, nil)
+	mockValidator.On("ValidateOnlyFilters", []string{}).Return([]string{}, nil)
+	mockApp.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.CLI,
+		app.RunOptions{Only: []string{}, DefaultAttributes: true, GroupBy: output.GroupApplication}).Return(nil)
+
+	cmd := cli.NewCommand(mockApp, mockValidator, new(MockServer), testEnv.Configurations)
+	rootCmd := cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--group-by", "application"})
+	require.NoError(t, rootCmd.Execute())
+	mockApp.AssertExpectations(t)
+
+	rootCmd = cmd.InitiateCommands()
+	rootCmd.SetArgs([]string{"run", "--group-by", "region"})
+	assert.ErrorAs(t, rootCmd.Execute(), &cerrors.ErrUnsupportedGroupBy{})
+	mockApp.AssertNumberOfCalls(t, "Run", 1)
+}
+
 // cleanCobraError cleans up the error message returned by Cobra command execution
 func cleanCobraError(err error) string {
 	if err == nil {
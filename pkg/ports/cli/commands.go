@@ -1,12 +1,27 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
 	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	awsConfig "github.com/oldmonad/ec2Drift/pkg/config/cloud/aws"
 	"github.com/oldmonad/ec2Drift/pkg/config/env"
+	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/hook"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/output"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
 	"github.com/oldmonad/ec2Drift/pkg/ports/rest"
 	validation "github.com/oldmonad/ec2Drift/pkg/utils/validator"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 // Command encapsulates CLI dependencies and logic
@@ -34,52 +49,681 @@ func NewCommand(
 
 // InitiateCommands initializes the root command and all CLI subcommands
 func (cf *Command) InitiateCommands() *cobra.Command {
+	var colorMode string // Report coloring: auto, always or never
+
 	rootCmd := &cobra.Command{
 		Use:   "ec2drift",
 		Short: "Detect drift between configuration and cloud provider",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Decide on coloring before any report is printed
+			mode, err := output.ParseColorMode(colorMode)
+			if err != nil {
+				return err
+			}
+			output.ApplyColorMode(mode, os.Stdout)
+			return nil
+		},
 	}
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", string(output.ColorAuto),
+		"color the report: auto (only on a terminal, honours NO_COLOR), always or never")
 
 	// Attach "run" and "serve" subcommands to root
 	rootCmd.AddCommand(cf.createRunCommand())
 	rootCmd.AddCommand(cf.createServeCommand())
+	rootCmd.AddCommand(cf.createCompareCommand())
+	rootCmd.AddCommand(cf.createExportCommand())
+	rootCmd.AddCommand(cf.createFetchCommand())
+	rootCmd.AddCommand(cf.createConfigCommand())
+	rootCmd.AddCommand(cf.createBaselineCommand())
 
 	return rootCmd
 }
 
 // createRunCommand defines the "run" subcommand which executes drift detection logic
 func (cf *Command) createRunCommand() *cobra.Command {
-	var format string          // Input format: terraform or json
-	var attributeList []string // List of specific attributes to validate
+	var format string             // Desired config format: terraform or json
+	var attributeList []string    // List of specific attributes to validate
+	var presets []string          // Attribute presets added to attributeList
+	var onlyList []string         // Drift categories or attributes to keep in the output
+	var timeout time.Duration     // Deadline for the whole run, zero disables it
+	var callTimeout time.Duration // Deadline for each cloud API call, zero disables it
+	var endpointURL string        // AWS endpoint overriding AWS_ENDPOINT_URL
+	var unmanagedOK bool          // Treat live instances missing from the config as unmanaged
+	var explain bool              // Print how live instances were matched to the config
+	var noExpand bool             // Disable ${VAR} expansion in the desired config
+	var autoAttributes bool       // Compare only the attributes set in the desired config
+	var strictMatch bool          // Report desired instances that cannot be matched as drift
+	var includeNoDrift bool       // Also report matched instances without drift
+	var ignoreCase bool           // Compare string values ignoring case
+	var expectedState string      // State desired instances are expected in
+	var configFiles []string      // Config files or directories merged instead of STATE_PATH
+	var baseline string           // Exported snapshot compared instead of the desired config
+	var instanceIDs []string      // Live instance IDs to check
+	var excludeIDs []string       // Live instance IDs to skip
+	var vpcID string              // VPC the compared instances must be in
+	var subnetID string           // Subnet the compared instances must be in
+	var pageSize int              // DescribeInstances page size, zero uses the SDK default
+	var maxInstances int          // Live instance count that fails the run, zero is unlimited
+	var outputFormat string       // Report format: table, json, csv or html
+	var outputFile string         // File to write the report to, overrides OUTPUT_PATH
+	var outputDir string          // Directory to write one report per application to
+	var templateFile string       // text/template rendering --output template
+	var quiet bool                // Suppress the report on stdout
+	var jsonPretty bool           // Indent JSON reports
+	var groupBy string            // Grouping of table and JSON reports, e.g. application
+	var reportTitle string        // Label for JSON and HTML reports, overrides REPORT_TITLE
+	var exitSummary bool          // Print a JSON summary of the run to stderr
+	var failOnSeverity string     // Lowest drift severity that counts as drift
+	var checkCredExpiry bool      // Fail early when the credentials expire soon
+	var credBuffer time.Duration  // Credential lifetime the run needs left
+	var execCommand string        // Command run after the check with the JSON report
+	var execTimeout time.Duration // Deadline for the exec command
 
 	runCmd := &cobra.Command{
 		Use:   "run",
 		Short: "Run drift check",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Validate and parse input format (e.g., terraform, json)
+			// Validate and parse the desired config format (e.g., terraform, json)
 			parserType, err := cf.validator.ValidateFormat(format)
 			if err != nil {
 				return err
 			}
 
 			// Validate user-provided attribute filters
-			validAttributes, err := cf.validator.ValidateAttributes(attributeList)
+			validAttributes, err := selectAttributes(cf.validator, attributeList, presets)
+			if err != nil {
+				return err
+			}
+
+			// Validate the output filter (categories or attribute names)
+			onlyFilters, err := cf.validator.ValidateOnlyFilters(onlyList)
+			if err != nil {
+				return err
+			}
+
+			// Validate the page size against the range AWS accepts
+			if err := awsConfig.ValidatePageSize(pageSize); err != nil {
+				return err
+			}
+
+			// Validate the report format
+			reportFormat, err := output.ParseFormat(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			// Parse the report template before anything is fetched
+			reportFormat, reportTemplate, err := parseReportTemplate(reportFormat, templateFile)
+			if err != nil {
+				return err
+			}
+
+			reportGrouping, err := output.ParseGroupBy(groupBy)
 			if err != nil {
 				return err
 			}
+			// Files of the output directory are named after the application
+			if outputDir != "" && reportGrouping != output.GroupApplication {
+				return cerrors.NewOutputDirNeedsGroupBy(outputDir)
+			}
+
+			// Validate the state instances are expected in
+			var instanceState string
+			if expectedState != "" {
+				if instanceState, err = driftchecker.ParseInstanceState(expectedState); err != nil {
+					return err
+				}
+			}
+
+			// Validate the severity threshold
+			var severityThreshold driftchecker.Severity
+			if failOnSeverity != "" {
+				if severityThreshold, err = driftchecker.ParseSeverity(failOnSeverity); err != nil {
+					return err
+				}
+			}
+
+			opts := app.RunOptions{
+				Only:           onlyFilters,
+				UnmanagedOK:    unmanagedOK,
+				Explain:        explain,
+				NoExpand:       noExpand,
+				AutoAttributes: autoAttributes,
+				StrictMatch:    strictMatch,
+				IncludeNoDrift: includeNoDrift,
+				IgnoreCase:     ignoreCase,
+				ExpectedState:  instanceState,
+				ConfigFiles:    configFiles,
+				Baseline:       baseline,
+				PageSize:       int32(pageSize),
+				CallTimeout:    callTimeout,
+				EndpointURL:    endpointURL,
+				Output:         reportFormat,
+				OutputFile:     outputFile,
+				OutputDir:      outputDir,
+				Quiet:          quiet,
+				JSONPretty:     jsonPretty,
+				Template:       reportTemplate,
+				GroupBy:        reportGrouping,
+				ReportTitle:    reportTitle,
+				ExitSummary:    exitSummary,
+				FailOnSeverity: severityThreshold,
+
+				Instances:        instanceIDs,
+				ExcludeInstances: excludeIDs,
+				VpcID:            vpcID,
+				SubnetID:         subnetID,
+				MaxInstances:     maxInstances,
+
+				// Without --attributes or --preset the default set is compared
+				DefaultAttributes: len(attributeList) == 0 && len(presets) == 0,
+			}
+			if checkCredExpiry {
+				opts.CheckCredExpiry = true
+				opts.CredExpiryBuffer = credBuffer
+			}
+			if execCommand != "" {
+				opts.Exec = execCommand
+				opts.ExecTimeout = execTimeout
+			}
+
+			ctx := cmd.Context()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
 
 			// Run the application drift detection logic
-			return cf.app.Run(cmd.Context(), validAttributes, parserType, ports.CLI)
+			err = cf.app.Run(ctx, validAttributes, parserType, ports.CLI, opts)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				logger.Log.Error("Drift check timed out", zap.Duration("timeout", timeout))
+				return cerrors.NewErrRunTimeout(timeout, err)
+			}
+			return driftVerdict(cmd, err)
 		},
 	}
 
 	// Register CLI flags
-	runCmd.Flags().StringVar(&format, "format", "terraform", "input format: terraform or json")
+	runCmd.Flags().StringVar(&format, "input-format", "terraform", "format of the desired config: terraform or json")
+	// --format predates the report formats and reads like one of them
+	runCmd.Flags().StringVar(&format, "format", "terraform", "deprecated alias of --input-format")
+	_ = runCmd.Flags().MarkDeprecated("format", "use --input-format instead")
 	runCmd.Flags().StringSliceVarP(&attributeList, "attributes", "a", []string{},
-		"optional attributes to check for drift (comma-separated or multiple flags)")
+		"optional attributes to check for drift (comma-separated or multiple flags); aliases: image=ami, sg=security_groups, type=instance_type")
+	runCmd.Flags().StringSliceVar(&presets, "preset", []string{}, presetUsage)
+	runCmd.Flags().StringSliceVar(&onlyList, "only", []string{},
+		"only output drift of the given categories (added, removed, changed) or attributes (e.g. tags)")
+	runCmd.Flags().BoolVar(&unmanagedOK, "unmanaged-ok", false,
+		"report live instances missing from the desired config as unmanaged instead of drift")
+	runCmd.Flags().BoolVar(&explain, "explain", false,
+		"print, as JSON, whether each live instance matched the desired config and by which key")
+	runCmd.Flags().BoolVar(&noExpand, "no-expand", false,
+		"do not expand ${VAR} and $VAR environment variable references in the desired config")
+	runCmd.Flags().BoolVar(&autoAttributes, "auto-attributes", false,
+		"compare each instance only on the attributes its desired config sets, within --attributes")
+	runCmd.Flags().StringArrayVar(&configFiles, "config-file", nil,
+		"desired config file or directory to read instead of STATE_PATH; repeat to merge several, names must be unique")
+	runCmd.Flags().BoolVar(&strictMatch, "strict-match", false,
+		"report desired instances without a Name tag, which can never be matched, as instance_missing drift")
+	runCmd.Flags().BoolVar(&includeNoDrift, "include-no-drift", false,
+		"also report matched instances without drift, with status ok, for audits; they never count as drift")
+	runCmd.Flags().BoolVar(&ignoreCase, "ignore-case-values", false, ignoreCaseUsage)
+	runCmd.Flags().StringVar(&expectedState, "expected-state", "", expectedStateUsage)
+	runCmd.Flags().StringVar(&baseline, "baseline", "",
+		"JSON snapshot written by export to compare the live state against instead of the desired config")
+	runCmd.MarkFlagsMutuallyExclusive("baseline", "config-file")
+	runCmd.Flags().StringSliceVar(&instanceIDs, "instances", nil,
+		"only check these live instance IDs and the desired instances matched to them")
+	runCmd.Flags().StringSliceVar(&excludeIDs, "exclude-instances", nil,
+		"skip these live instance IDs and the desired instances matched to them, after --instances")
+	runCmd.Flags().StringVar(&vpcID, "vpc-id", "",
+		"only compare live instances in this VPC, the desired instances matched to them and unmatched ones declaring it")
+	runCmd.Flags().StringVar(&subnetID, "subnet-id", "",
+		"only compare live instances in this subnet, the desired instances matched to them and unmatched ones declaring it")
+	runCmd.Flags().IntVar(&pageSize, "page-size", 0,
+		"number of instances requested per DescribeInstances page (5-1000, default: SDK default)")
+	runCmd.Flags().IntVar(&maxInstances, "max-instances", 0, maxInstancesUsage)
+	runCmd.Flags().StringVar(&endpointURL, "endpoint-url", "",
+		"AWS endpoint to fetch from instead of AWS_ENDPOINT_URL, e.g. http://localhost:4566 for LocalStack")
+	runCmd.Flags().StringVarP(&outputFormat, "output", "o", "",
+		"report format: table, json, csv, html, summary, sarif or template (default table, files use their extension)")
+	runCmd.Flags().StringVar(&outputFormat, "output-format", "", "alias of --output")
+	runCmd.Flags().StringVar(&outputFile, "output-file", "",
+		"write the report to this file, overriding OUTPUT_PATH")
+	runCmd.Flags().StringVar(&outputDir, "output-dir", "", outputDirUsage)
+	runCmd.Flags().StringVar(&templateFile, "template-file", "",
+		"Go text/template file rendering the reports with --output template, which it implies")
+	runCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "do not print the report to stdout")
+	runCmd.Flags().BoolVar(&jsonPretty, "json-pretty", false, "indent JSON reports, on stdout and in --output-file")
+	runCmd.Flags().StringVar(&groupBy, "group-by", "",
+		"group table and JSON reports: application nests instances under their Name tag (default one entry per instance)")
+	runCmd.Flags().StringVar(&reportTitle, "report-title", "",
+		"label for JSON and HTML reports, e.g. \"prod us-east-1\", overriding REPORT_TITLE")
+	runCmd.Flags().BoolVar(&exitSummary, "exit-summary", false,
+		"print a one line JSON summary of the run to stderr, whatever the --output format")
+	runCmd.Flags().StringVar(&failOnSeverity, "fail-on-severity", "",
+		"only count drift at or above this severity (info, warning, critical) as drift; unmapped attributes are warning")
+	runCmd.Flags().BoolVar(&checkCredExpiry, "check-cred-expiry", false,
+		"fail before scanning when temporary AWS credentials expire within --cred-expiry-buffer (expiry read from AWS_CREDENTIAL_EXPIRATION)")
+	runCmd.Flags().DurationVar(&credBuffer, "cred-expiry-buffer", 15*time.Minute,
+		"credential lifetime that must remain for --check-cred-expiry")
+	runCmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "maximum duration of the drift check (0 disables the timeout)")
+	runCmd.Flags().DurationVar(&callTimeout, "call-timeout", 0,
+		"maximum duration of each cloud API call, bounded by --timeout (0 disables it)")
+	runCmd.Flags().StringVar(&execCommand, "exec", "",
+		"shell command run after the check with the JSON report on stdin and EC2DRIFT_DRIFT_DETECTED set; failures only log a warning")
+	runCmd.Flags().DurationVar(&execTimeout, "exec-timeout", hook.DefaultTimeout,
+		"maximum duration of the --exec command")
 
 	return runCmd
 }
 
+// createCompareCommand defines the "compare" subcommand which reports the
+// drift between two state files without contacting the cloud provider
+func (cf *Command) createCompareCommand() *cobra.Command {
+	var oldState string        // State file treated as the baseline
+	var newState string        // State file compared against the baseline
+	var format string          // Format of both state files: terraform or json
+	var attributeList []string // List of specific attributes to compare
+	var presets []string       // Attribute presets added to attributeList
+	var onlyList []string      // Drift categories or attributes to keep in the output
+	var noExpand bool          // Disable ${VAR} expansion in the state files
+	var ignoreCase bool        // Compare string values ignoring case
+	var expectedState string   // State desired instances are expected in
+	var outputFormat string    // Report format: table, json, csv or html
+	var outputFile string      // File to write the report to, overrides OUTPUT_PATH
+	var outputDir string       // Directory to write one report per application to
+	var templateFile string    // text/template rendering --output template
+	var quiet bool             // Suppress the report on stdout
+	var jsonPretty bool        // Indent JSON reports
+	var groupBy string         // Grouping of table and JSON reports, e.g. application
+	var reportTitle string     // Label for JSON and HTML reports, overrides REPORT_TITLE
+	var failOnSeverity string  // Lowest drift severity that counts as drift
+
+	compareCmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Compare two state files offline",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			comparer, ok := cf.app.(app.StateComparer)
+			if !ok {
+				return errors.New("comparing state files is not supported")
+			}
+
+			parserType, err := cf.validator.ValidateFormat(format)
+			if err != nil {
+				return err
+			}
+
+			validAttributes, err := selectAttributes(cf.validator, attributeList, presets)
+			if err != nil {
+				return err
+			}
+
+			onlyFilters, err := cf.validator.ValidateOnlyFilters(onlyList)
+			if err != nil {
+				return err
+			}
+
+			reportFormat, err := output.ParseFormat(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			// Parse the report template before anything is fetched
+			reportFormat, reportTemplate, err := parseReportTemplate(reportFormat, templateFile)
+			if err != nil {
+				return err
+			}
+
+			reportGrouping, err := output.ParseGroupBy(groupBy)
+			if err != nil {
+				return err
+			}
+			// Files of the output directory are named after the application
+			if outputDir != "" && reportGrouping != output.GroupApplication {
+				return cerrors.NewOutputDirNeedsGroupBy(outputDir)
+			}
+
+			var instanceState string
+			if expectedState != "" {
+				if instanceState, err = driftchecker.ParseInstanceState(expectedState); err != nil {
+					return err
+				}
+			}
+
+			var severityThreshold driftchecker.Severity
+			if failOnSeverity != "" {
+				if severityThreshold, err = driftchecker.ParseSeverity(failOnSeverity); err != nil {
+					return err
+				}
+			}
+
+			opts := app.RunOptions{
+				Only:           onlyFilters,
+				NoExpand:       noExpand,
+				IgnoreCase:     ignoreCase,
+				ExpectedState:  instanceState,
+				Output:         reportFormat,
+				OutputFile:     outputFile,
+				OutputDir:      outputDir,
+				Quiet:          quiet,
+				JSONPretty:     jsonPretty,
+				Template:       reportTemplate,
+				GroupBy:        reportGrouping,
+				ReportTitle:    reportTitle,
+				FailOnSeverity: severityThreshold,
+			}
+			return driftVerdict(cmd, comparer.Compare(cmd.Context(), oldState, newState, validAttributes, parserType, ports.CLI, opts))
+		},
+	}
+
+	compareCmd.Flags().StringVar(&oldState, "old-state", "", "state file used as the baseline")
+	compareCmd.Flags().StringVar(&newState, "new-state", "", "state file compared against the baseline")
+	_ = compareCmd.MarkFlagRequired("old-state")
+	_ = compareCmd.MarkFlagRequired("new-state")
+	compareCmd.Flags().StringVar(&format, "input-format", "terraform", "format of both state files: terraform or json")
+	compareCmd.Flags().StringSliceVarP(&attributeList, "attributes", "a", []string{},
+		"optional attributes to compare (comma-separated or multiple flags); aliases: image=ami, sg=security_groups, type=instance_type")
+	compareCmd.Flags().StringSliceVar(&presets, "preset", []string{}, presetUsage)
+	compareCmd.Flags().StringSliceVar(&onlyList, "only", []string{},
+		"only output drift of the given categories (added, removed, changed) or attributes (e.g. tags)")
+	compareCmd.Flags().BoolVar(&noExpand, "no-expand", false,
+		"do not expand ${VAR} and $VAR environment variable references in the state files")
+	compareCmd.Flags().BoolVar(&ignoreCase, "ignore-case-values", false, ignoreCaseUsage)
+	compareCmd.Flags().StringVar(&expectedState, "expected-state", "", expectedStateUsage)
+	compareCmd.Flags().StringVarP(&outputFormat, "output", "o", "",
+		"report format: table, json, csv, html, summary, sarif or template (default table, files use their extension)")
+	compareCmd.Flags().StringVar(&outputFile, "output-file", "",
+		"write the report to this file, overriding OUTPUT_PATH")
+	compareCmd.Flags().StringVar(&outputDir, "output-dir", "", outputDirUsage)
+	compareCmd.Flags().StringVar(&templateFile, "template-file", "",
+		"Go text/template file rendering the reports with --output template, which it implies")
+	compareCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "do not print the report to stdout")
+	compareCmd.Flags().BoolVar(&jsonPretty, "json-pretty", false, "indent JSON reports, on stdout and in --output-file")
+	compareCmd.Flags().StringVar(&groupBy, "group-by", "",
+		"group table and JSON reports: application nests instances under their Name tag (default one entry per instance)")
+	compareCmd.Flags().StringVar(&reportTitle, "report-title", "",
+		"label for JSON and HTML reports, e.g. \"prod us-east-1\", overriding REPORT_TITLE")
+	compareCmd.Flags().StringVar(&failOnSeverity, "fail-on-severity", "",
+		"only count drift at or above this severity (info, warning, critical) as drift; unmapped attributes are warning")
+
+	return compareCmd
+}
+
+const outputDirUsage = "also write the reports of each application to <dir>/<application>.<ext>, " +
+	"in --output or json; needs --group-by application and creates the directory if missing"
+
+var expectedStateUsage = "state instance_state expects desired instances without one to be in: " +
+	strings.Join(driftchecker.InstanceStates(), ", ") + " (default running)"
+
+const ignoreCaseUsage = "compare string values, list entries and tag values ignoring case, e.g. GP2 and gp2; " +
+	"attributes with a COMPARATORS entry keep it"
+
+const maxInstancesUsage = "fail before comparing when the live fetch finds more instances than this, " +
+	"a safety limit for large accounts (0 is unlimited)"
+
+var presetUsage = "attribute presets to add to --attributes (comma-separated or multiple flags): " +
+	strings.Join(validation.PresetNames(), ", ")
+
+// selectAttributes validates the --attributes list together with the
+// attributes of the --preset names
+func selectAttributes(v validation.Validator, attributes, presets []string) ([]string, error) {
+	if len(presets) > 0 {
+		expander, ok := v.(validation.PresetExpander)
+		if !ok {
+			return nil, cerrors.NewErrUnknownPreset(presets[0], nil)
+		}
+		expanded, err := expander.ExpandPresets(presets)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(expanded, attributes...)
+	}
+	return v.ValidateAttributes(attributes)
+}
+
+// liveStateFlags are the flags shared by the commands that only read the
+// live state: export, fetch and baseline refresh
+type liveStateFlags struct {
+	attributeList []string      // Attributes whose extra lookups are made
+	presets       []string      // Attribute presets added to attributeList
+	region        string        // Region overriding AWS_REGION
+	endpointURL   string        // Endpoint overriding AWS_ENDPOINT_URL
+	pageSize      int           // DescribeInstances page size, zero uses the SDK default
+	maxInstances  int           // Live instance count that fails the command, zero is unlimited
+	callTimeout   time.Duration // Deadline for each cloud API call, zero disables it
+	timeout       time.Duration // Deadline for the whole command, zero disables it
+}
+
+func (f *liveStateFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&f.attributeList, "attributes", "a", []string{},
+		"attributes to fetch; disable_api_termination and user_data cost one extra call per instance (default all)")
+	cmd.Flags().StringSliceVar(&f.presets, "preset", []string{}, presetUsage)
+	cmd.Flags().StringVar(&f.region, "region", "", "region to fetch from instead of AWS_REGION")
+	cmd.Flags().StringVar(&f.endpointURL, "endpoint-url", "",
+		"AWS endpoint to fetch from instead of AWS_ENDPOINT_URL, e.g. http://localhost:4566 for LocalStack")
+	cmd.Flags().IntVar(&f.pageSize, "page-size", 0,
+		"number of instances requested per DescribeInstances page (5-1000, default: SDK default)")
+	cmd.Flags().IntVar(&f.maxInstances, "max-instances", 0, maxInstancesUsage)
+	cmd.Flags().DurationVar(&f.callTimeout, "call-timeout", 0,
+		"maximum duration of each cloud API call (0 disables it)")
+	cmd.Flags().DurationVar(&f.timeout, "timeout", 5*time.Minute, "maximum duration of the command (0 disables the timeout)")
+}
+
+// validate checks the flags and returns the attributes and fetch options
+func (f *liveStateFlags) validate(v validation.Validator) ([]string, app.RunOptions, error) {
+	validAttributes, err := selectAttributes(v, f.attributeList, f.presets)
+	if err != nil {
+		return nil, app.RunOptions{}, err
+	}
+	if err := awsConfig.ValidatePageSize(f.pageSize); err != nil {
+		return nil, app.RunOptions{}, err
+	}
+
+	opts := app.RunOptions{
+		Region:       f.region,
+		EndpointURL:  f.endpointURL,
+		PageSize:     int32(f.pageSize),
+		CallTimeout:  f.callTimeout,
+		MaxInstances: f.maxInstances,
+	}
+	return validAttributes, opts, nil
+}
+
+// context bounds the command by --timeout
+func (f *liveStateFlags) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, f.timeout)
+}
+
+// createExportCommand defines the "export" subcommand which writes the live
+// instances to a JSON file, a baseline for the compare command
+func (cf *Command) createExportCommand() *cobra.Command {
+	var outputPath string // File the live instances are written to
+	var live liveStateFlags
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the live state to a JSON file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exporter, ok := cf.app.(app.StateExporter)
+			if !ok {
+				return errors.New("exporting the live state is not supported")
+			}
+
+			validAttributes, opts, err := live.validate(cf.validator)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := live.context(cmd.Context())
+			defer cancel()
+			return exporter.Export(ctx, outputPath, validAttributes, opts)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&outputPath, "output", "o", "", "file the live instances are written to as JSON")
+	_ = exportCmd.MarkFlagRequired("output")
+	live.register(exportCmd)
+
+	return exportCmd
+}
+
+// createBaselineCommand defines the "baseline" subcommand, whose "refresh"
+// subcommand replaces a baseline file for --baseline with the live state
+func (cf *Command) createBaselineCommand() *cobra.Command {
+	var path string // Baseline file to replace
+	var live liveStateFlags
+
+	baselineCmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Manage baselines of the live state",
+	}
+
+	refreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Atomically replace a baseline file with the live state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			refresher, ok := cf.app.(app.BaselineRefresher)
+			if !ok {
+				return errors.New("refreshing baselines is not supported")
+			}
+
+			validAttributes, opts, err := live.validate(cf.validator)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := live.context(cmd.Context())
+			defer cancel()
+			_, err = refresher.RefreshBaseline(ctx, path, validAttributes, opts)
+			return err
+		},
+	}
+	refreshCmd.Flags().StringVarP(&path, "file", "f", "", "baseline file to replace, written to a temporary file and renamed into place")
+	_ = refreshCmd.MarkFlagRequired("file")
+	live.register(refreshCmd)
+
+	baselineCmd.AddCommand(refreshCmd)
+	return baselineCmd
+}
+
+// createFetchCommand defines the "fetch" subcommand which prints the live
+// instances as the provider returns them, to see which fields are populated
+// before writing a desired config
+func (cf *Command) createFetchCommand() *cobra.Command {
+	var outputFormat string // Instance format: table or json
+	var live liveStateFlags
+
+	fetchCmd := &cobra.Command{
+		Use:   "fetch",
+		Short: "Print the live state without comparing it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fetcher, ok := cf.app.(app.StateFetcher)
+			if !ok {
+				return errors.New("fetching the live state is not supported")
+			}
+
+			format, err := output.ParseInstanceFormat(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			validAttributes, opts, err := live.validate(cf.validator)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := live.context(cmd.Context())
+			defer cancel()
+			instances, err := fetcher.Fetch(ctx, validAttributes, opts)
+			if err != nil {
+				return err
+			}
+			return output.RenderInstances(cmd.OutOrStdout(), format, instances)
+		},
+	}
+
+	fetchCmd.Flags().StringVarP(&outputFormat, "output", "o", string(output.Table), "instance format: table or json")
+	live.register(fetchCmd)
+
+	return fetchCmd
+}
+
+// createConfigCommand defines the "config" subcommand, whose "show"
+// subcommand prints the configuration in effect after .env and the
+// environment are merged, with secrets masked
+func (cf *Command) createConfigCommand() *cobra.Command {
+	var outputFormat string // Settings format: table or json
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration with secrets masked",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reader, ok := cf.app.(app.ConfigurationReader)
+			if !ok {
+				return errors.New("showing the configuration is not supported")
+			}
+
+			format, err := output.ParseInstanceFormat(outputFormat)
+			if err != nil {
+				return err
+			}
+
+			configurations := reader.Configurations()
+			return output.RenderConfigSettings(cmd.OutOrStdout(), format, configurations.Settings())
+		},
+	}
+	showCmd.Flags().StringVarP(&outputFormat, "output", "o", string(output.Table), "settings format: table or json")
+
+	configCmd.AddCommand(showCmd)
+	return configCmd
+}
+
+// parseReportTemplate parses the --template-file of the template format. A
+// template file without --output selects the template format.
+func parseReportTemplate(format output.Format, path string) (output.Format, *template.Template, error) {
+	if path == "" {
+		if format == output.Template {
+			return "", nil, cerrors.NewErrTemplateFile(string(format))
+		}
+		return format, nil, nil
+	}
+	if format == "" {
+		format = output.Template
+	}
+	if format != output.Template {
+		return "", nil, cerrors.NewErrTemplateFile(string(format))
+	}
+	t, err := output.ParseTemplateFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return format, t, nil
+}
+
+// driftVerdict returns the outcome of a run as the command result, for
+// main to map to an exit code. Drift and an instance selection matching
+// nothing are not failures of the command: the report has been printed, so
+// cobra is kept from printing the error and usage on top of it.
+func driftVerdict(cmd *cobra.Command, err error) error {
+	if errors.As(err, &cerrors.ErrDriftDetected{}) || errors.As(err, &cerrors.ErrNoMatchingInstances{}) {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return err
+}
+
 // createServeCommand defines the "serve" subcommand which starts the HTTP server
 func (cf *Command) createServeCommand() *cobra.Command {
 	var httpPort string // CLI override for HTTP port (optional)
@@ -88,13 +732,30 @@ func (cf *Command) createServeCommand() *cobra.Command {
 		Use:   "serve",
 		Short: "Start HTTP server",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Start the HTTP server on the configured port
-			return cf.server.Start(cf.envConfigurations.PortToString())
+			if err := cf.envConfigurations.ValidateServe(); err != nil {
+				logger.Log.Error("Invalid serve configuration", zap.Error(err))
+				return err
+			}
+
+			// The --port flag takes precedence over HTTP_PORT
+			source, raw := "HTTP_PORT", cf.envConfigurations.PortToString()
+			if cmd.Flags().Changed("port") {
+				source, raw = "--port", httpPort
+			}
+
+			port, err := env.ParsePort(source, raw)
+			if err != nil {
+				logger.Log.Error("Invalid HTTP port", zap.String("source", source), zap.Error(err))
+				return err
+			}
+
+			// Start the HTTP server on the resolved port
+			return cf.server.Start(strconv.Itoa(port))
 		},
 	}
 
 	// Register CLI flag to allow port override
-	serveCmd.Flags().StringVar(&httpPort, "port", httpPort, "port for HTTP server")
+	serveCmd.Flags().StringVar(&httpPort, "port", httpPort, "port for HTTP server (1-65535), overriding HTTP_PORT")
 
 	return serveCmd
 }
@@ -0,0 +1,17 @@
+resource "aws_instance" "web" {
+  ami           = "ami-0ce8c2b29fcc8a946"
+  instance_type = "t3.medium"
+
+  tags = {
+    Name = "web-server"
+  }
+}
+
+resource "aws_instance" "db" {
+  ami           = "ami-0ce8c2b29fcc8a146"
+  instance_type = "t3.large"
+
+  tags = {
+    Name = "db-server"
+  }
+}
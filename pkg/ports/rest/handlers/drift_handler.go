@@ -3,16 +3,52 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
 	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
 	"github.com/oldmonad/ec2Drift/pkg/utils/validator"
 	"go.uber.org/zap"
 )
 
+// DriftResponse is the body of a completed POST /drift request. Fields are
+// encoded in declaration order, so the shape is stable for clients.
+type DriftResponse struct {
+	SchemaVersion int    `json:"schema_version"`  // See output.SchemaVersion
+	Title         string `json:"title,omitempty"` // REPORT_TITLE, omitted when unset
+	DriftDetected bool   `json:"drift_detected"`
+	Message       string `json:"message"`
+
+	Meta *output.Meta `json:"meta,omitempty"` // Inputs of the check, when the app records them
+
+	// Partial data the run went on without, e.g. instances the config
+	// parser could only partially decode
+	Warnings []cloud.Warning `json:"warnings,omitempty"`
+}
+
+// ErrorResponse is the body of every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"` // Machine readable cause, e.g. CONFIG_PARSE, for some errors
+}
+
+// Error codes set on some error responses
+const (
+	CodeConfigParse   = "CONFIG_PARSE"
+	CodeCloudUpstream = "CLOUD_UPSTREAM"
+)
+
+// MaxRequestBytes caps the size of a POST /drift body, which may carry an
+// inline desired config
+const MaxRequestBytes = 10 << 20
+
 // DriftHandler handles HTTP requests for drift detection
 type DriftHandler struct {
 	app       app.AppRunner       // Application logic handler
@@ -41,19 +77,23 @@ func (h *DriftHandler) HandleDrift(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Request payload structure
-	var req struct {
-		Attrs  []string `json:"attributes"` // Attributes to check for drift
-		Format string   `json:"format"`     // Input format: terraform or json
+	// Parse and validate the request body against the request schema
+	req, err := decodeDriftRequest(http.MaxBytesReader(w, r.Body, MaxRequestBytes), h.inputFormats())
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logger.Log.Warn("Request body too large",
+			zap.Int64("limit_bytes", tooLarge.Limit),
+			zap.String("path", r.URL.Path),
+		)
+		sendError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return
 	}
-
-	// Parse and validate the request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err != nil {
 		logger.Log.Error("Failed to decode request body",
 			zap.Error(err),
 			zap.String("path", r.URL.Path),
 		)
-		sendError(w, http.StatusBadRequest, cerrors.NewErrInvalidJSON(err).Error())
+		sendError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -64,6 +104,16 @@ func (h *DriftHandler) HandleDrift(w http.ResponseWriter, r *http.Request) {
 
 	// Validate the attributes
 	validAttrs, err := h.validator.ValidateAttributes(req.Attrs)
+	if err == nil && len(req.IgnoreAttrs) > 0 {
+		validAttrs, err = h.ignoreAttributes(validAttrs, req.IgnoreAttrs)
+	}
+	if errors.As(err, &cerrors.ErrNoAttributesSelected{}) {
+		logger.Log.Warn("No attributes selected",
+			zap.Strings("requested_attributes", req.Attrs),
+		)
+		sendError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
 	if err != nil {
 		logger.Log.Warn("Attribute validation failed",
 			zap.Error(err),
@@ -84,43 +134,58 @@ func (h *DriftHandler) HandleDrift(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts := app.RunOptions{
+		Region:           req.region(),
+		Config:           req.config,
+		Instances:        req.Filters.Instances,
+		ExcludeInstances: req.Filters.ExcludeInstances,
+		VpcID:            req.Filters.VpcID,
+		SubnetID:         req.Filters.SubnetID,
+		FailOnSeverity:   req.severity,
+
+		DefaultAttributes: len(req.Attrs) == 0,
+	}
+
+	// Validate the output filters
+	if len(req.Filters.Only) > 0 {
+		if opts.Only, err = h.validator.ValidateOnlyFilters(req.Filters.Only); err != nil {
+			logger.Log.Warn("Only filter validation failed",
+				zap.Error(err),
+				zap.Strings("requested_filters", req.Filters.Only),
+			)
+			sendError(w, http.StatusBadRequest, cerrors.NewAttributeValidationError(err).Error())
+			return
+		}
+	}
+
 	logger.Log.Info("Starting drift detection",
 		zap.Strings("valid_attributes", validAttrs),
 		zap.String("format", req.Format),
 		zap.String("parser_type", string(parserType)),
 	)
 
-	// Run the main application logic for drift detection
-	err = h.app.Run(r.Context(), validAttrs, parserType, ports.HTTP)
+	// Stream reports one per line when the client asks for NDJSON
+	if acceptsNDJSON(r) {
+		h.streamDrift(w, r, validAttrs, parserType, req.Format, opts)
+		return
+	}
+
+	// Run the main application logic for drift detection, collecting its
+	// warnings for the response
+	warnings := &cloud.WarningCollector{}
+	ctx := cloud.WithWarningCollector(r.Context(), warnings)
+	meta := runMeta(h.app, validAttrs, parserType, opts)
+	err = h.app.Run(ctx, validAttrs, parserType, ports.HTTP, opts)
 	if err != nil {
-		switch {
-		// Case when drift is detected
-		case errors.As(err, &cerrors.ErrDriftDetected{}):
+		if errors.As(err, &cerrors.ErrDriftDetected{}) {
 			logger.Log.Info("Drift detected in EC2 instances",
 				zap.Strings("attributes", validAttrs),
 				zap.String("format", req.Format),
 			)
-			sendResponse(w, http.StatusOK, map[string]interface{}{
-				"drift_detected": true,
-				"message":        "Drift detected",
-			})
-
-		// Case when no EC2 instances were found
-		case errors.As(err, &cerrors.ErrNoEC2Instances{}):
-			logger.Log.Warn("No EC2 instances found",
-				zap.Error(err),
-			)
-			sendError(w, http.StatusBadRequest, err.Error())
-
-		// Generic application error
-		default:
-			logger.Log.Error("Application error during drift detection",
-				zap.Error(err),
-				zap.Strings("attributes", validAttrs),
-				zap.String("format", req.Format),
-			)
-			sendError(w, http.StatusInternalServerError, cerrors.NewErrAppRun(err).Error())
+			sendResponse(w, http.StatusOK, h.driftResponse(true, "Drift detected", meta, warnings.Warnings()))
+			return
 		}
+		sendRunError(w, err, validAttrs, req.Format)
 		return
 	}
 
@@ -129,10 +194,130 @@ func (h *DriftHandler) HandleDrift(w http.ResponseWriter, r *http.Request) {
 		zap.Strings("attributes", validAttrs),
 		zap.String("format", req.Format),
 	)
-	sendResponse(w, http.StatusOK, map[string]interface{}{
-		"drift_detected": false,
-		"message":        "No drift detected",
-	})
+	sendResponse(w, http.StatusOK, h.driftResponse(false, "No drift detected", meta, warnings.Warnings()))
+}
+
+// ignoreAttributes validates the ignore_attributes of a request and removes
+// them from the selected attributes. Ignoring every selected attribute
+// returns ErrNoAttributesSelected.
+func (h *DriftHandler) ignoreAttributes(selected, ignored []string) ([]string, error) {
+	validIgnored, err := h.validator.ValidateAttributes(ignored)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]string, 0, len(selected))
+	for _, attr := range selected {
+		if !slices.Contains(validIgnored, attr) {
+			kept = append(kept, attr)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, cerrors.NewErrNoAttributesSelected()
+	}
+	return kept, nil
+}
+
+// inputFormats returns the desired config formats the app parses, the
+// built in ones when it does not list them
+func (h *DriftHandler) inputFormats() []string {
+	if lister, ok := h.app.(app.FormatLister); ok {
+		return lister.InputFormats()
+	}
+	return parser.DefaultRegistry().Formats()
+}
+
+// driftResponse builds the response of a completed drift check, labelled
+// with REPORT_TITLE when one is set
+func (h *DriftHandler) driftResponse(driftDetected bool, message string, meta *output.Meta, warnings []cloud.Warning) DriftResponse {
+	return DriftResponse{
+		SchemaVersion: output.SchemaVersion,
+		Title:         reportTitle(h.app),
+		DriftDetected: driftDetected,
+		Message:       message,
+		Meta:          meta,
+		Warnings:      warnings,
+	}
+}
+
+// reportTitle returns the label of apps that have one, empty otherwise
+func reportTitle(runner interface{}) string {
+	if titler, ok := runner.(app.ReportTitler); ok {
+		return titler.ReportTitle()
+	}
+	return ""
+}
+
+// runMeta returns the inputs of a check for apps that record them, nil
+// otherwise
+func runMeta(runner interface{}, attrs []string, format parser.ParserType, opts app.RunOptions) *output.Meta {
+	if describer, ok := runner.(app.RunDescriber); ok {
+		meta := describer.RunMeta(attrs, format, opts)
+		return &meta
+	}
+	return nil
+}
+
+// sendRunError maps an error returned by the application run to a response
+func sendRunError(w http.ResponseWriter, err error, validAttrs []string, format string) {
+	switch {
+	// Case when the effective attribute set is empty
+	case errors.As(err, &cerrors.ErrNoAttributesSelected{}):
+		logger.Log.Warn("No attributes selected",
+			zap.Error(err),
+		)
+		sendError(w, http.StatusUnprocessableEntity, err.Error())
+
+	// Case when the desired config could not be parsed
+	case errors.As(err, &cerrors.ErrConfigParse{}):
+		logger.Log.Warn("Desired config could not be parsed",
+			zap.Error(err),
+		)
+		sendResponse(w, http.StatusUnprocessableEntity, ErrorResponse{Error: err.Error(), Code: CodeConfigParse})
+
+	// Case when the filters matched no live instance
+	case errors.As(err, &cerrors.ErrNoMatchingInstances{}):
+		logger.Log.Warn("No live instance matched the filters",
+			zap.Error(err),
+		)
+		sendError(w, http.StatusUnprocessableEntity, err.Error())
+
+	// Case when the desired config has no source
+	case errors.As(err, &cerrors.ErrMissingPaths{}):
+		logger.Log.Warn("No desired config to compare against",
+			zap.Error(err),
+		)
+		sendError(w, http.StatusUnprocessableEntity, "no desired config: send it in the config field or set STATE_PATH on the server")
+
+	// Case when an inline config is sent to a server with STATE_PATH
+	case errors.As(err, &cerrors.ErrConflictingConfigSources{}):
+		logger.Log.Warn("More than one desired config source",
+			zap.Error(err),
+		)
+		sendError(w, http.StatusUnprocessableEntity, err.Error())
+
+	// Case when no EC2 instances were found
+	case errors.As(err, &cerrors.ErrNoEC2Instances{}):
+		logger.Log.Warn("No EC2 instances found",
+			zap.Error(err),
+		)
+		sendError(w, http.StatusBadRequest, err.Error())
+
+	// Case when the cloud provider could not be reached or rejected the call
+	case errors.As(err, &cerrors.ErrDescribeInstances{}), errors.As(err, &cerrors.ErrAWSConfigLoad{}):
+		logger.Log.Error("Cloud provider error during drift detection",
+			zap.Error(err),
+		)
+		sendResponse(w, http.StatusBadGateway, ErrorResponse{Error: err.Error(), Code: CodeCloudUpstream})
+
+	// Generic application error
+	default:
+		logger.Log.Error("Application error during drift detection",
+			zap.Error(err),
+			zap.Strings("attributes", validAttrs),
+			zap.String("format", format),
+		)
+		sendError(w, http.StatusInternalServerError, cerrors.NewErrAppRun(err).Error())
+	}
 }
 
 // sendError sends an error response with JSON payload
@@ -141,9 +326,7 @@ func sendError(w http.ResponseWriter, statusCode int, message string) {
 		zap.Int("status_code", statusCode),
 		zap.String("message", message),
 	)
-	sendResponse(w, statusCode, map[string]interface{}{
-		"error": message,
-	})
+	sendResponse(w, statusCode, ErrorResponse{Error: message})
 }
 
 // sendResponse writes a JSON response with given status and data
@@ -1,20 +1,31 @@
 package handlers_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/cloud"
 	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/output"
 	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
 	"github.com/oldmonad/ec2Drift/pkg/ports/rest/handlers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -29,8 +40,8 @@ type MockAppRunner struct {
 	mock.Mock
 }
 
-func (m *MockAppRunner) Run(ctx context.Context, args []string, pt parser.ParserType, rt ports.Runtype) error {
-	return m.Called(ctx, args, pt, rt).Error(0)
+func (m *MockAppRunner) Run(ctx context.Context, args []string, pt parser.ParserType, rt ports.Runtype, opts app.RunOptions) error {
+	return m.Called(ctx, args, pt, rt, opts).Error(0)
 }
 
 type MockValidator struct {
@@ -47,6 +58,11 @@ func (m *MockValidator) ValidateFormat(format string) (parser.ParserType, error)
 	return args.Get(0).(parser.ParserType), args.Error(1)
 }
 
+func (m *MockValidator) ValidateOnlyFilters(filters []string) ([]string, error) {
+	args := m.Called(filters)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func TestDriftHandler(t *testing.T) {
 	t.Run("handle non-POST method", func(t *testing.T) {
 		appMock := new(MockAppRunner)
@@ -76,6 +92,160 @@ func TestDriftHandler(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "invalid JSON")
 	})
 
+	t.Run("request schema validation", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			body     string
+			expected string
+		}{
+			{
+				name:     "unknown field",
+				body:     `{"attributes": ["ami"], "format": "json", "dry_run": true}`,
+				expected: `invalid request: \"dry_run\" is not a known field`,
+			},
+			{
+				name:     "attributes as a string",
+				body:     `{"attributes": "ami"}`,
+				expected: `invalid request: \"attributes\" must be an array of strings`,
+			},
+			{
+				name:     "attributes with a non-string item",
+				body:     `{"attributes": ["ami", 42]}`,
+				expected: `invalid request: \"attributes\" must be an array of strings`,
+			},
+			{
+				name:     "format as a number",
+				body:     `{"format": 1}`,
+				expected: `invalid request: \"format\" must be a string`,
+			},
+			{
+				name:     "unknown format",
+				body:     `{"format": "yaml"}`,
+				expected: `invalid request: \"format\" must be one of json, terraform`,
+			},
+			{
+				name:     "unknown severity",
+				body:     `{"fail_on_severity": "urgent"}`,
+				expected: `invalid request: \"fail_on_severity\" must be one of info, warning, critical`,
+			},
+			{
+				name:     "config as an object",
+				body:     `{"config": {"resource": []}}`,
+				expected: `invalid request: \"config\" must be a string`,
+			},
+			{
+				name:     "empty config",
+				body:     `{"config": "  "}`,
+				expected: `invalid request: \"config\" must not be empty`,
+			},
+			{
+				name:     "invalid base64 config",
+				body:     `{"config": "not base64!", "config_encoding": "base64"}`,
+				expected: `invalid request: \"config\" must be valid base64 when config_encoding is base64`,
+			},
+			{
+				name:     "unknown config encoding",
+				body:     `{"config": "[]", "config_encoding": "gzip"}`,
+				expected: `invalid request: \"config_encoding\" must be base64 or left out for plain text`,
+			},
+			{
+				name:     "config encoding without config",
+				body:     `{"config_encoding": "base64"}`,
+				expected: `invalid request: \"config_encoding\" is only allowed together with config`,
+			},
+			{
+				name:     "ignore_attributes as a string",
+				body:     `{"ignore_attributes": "tags"}`,
+				expected: `invalid request: \"ignore_attributes\" must be an array of strings`,
+			},
+			{
+				name:     "several regions",
+				body:     `{"regions": ["us-east-1", "eu-west-1"]}`,
+				expected: `invalid request: \"regions\" must hold at most one region, a check fetches a single region`,
+			},
+			{
+				name:     "malformed region",
+				body:     `{"regions": ["US East"]}`,
+				expected: `invalid request: \"regions\" must hold region names such as us-east-1`,
+			},
+			{
+				name:     "filters as an array",
+				body:     `{"filters": ["vpc-1"]}`,
+				expected: `invalid request: \"filters\" must be an object`,
+			},
+			{
+				name:     "filter of the wrong type",
+				body:     `{"filters": {"vpc_id": ["vpc-1"]}}`,
+				expected: `invalid request: \"filters.vpc_id\" must be a string`,
+			},
+			{
+				name:     "unknown filter",
+				body:     `{"filters": {"tag": "Env"}}`,
+				expected: `invalid request: \"filters.tag\" is not a known field`,
+			},
+			{
+				name:     "top level field inside the filters",
+				body:     `{"attributes": ["ami"], "filters": {"regions": ["us-east-1"]}}`,
+				expected: `invalid request: \"filters.regions\" is not a known field`,
+			},
+			{
+				name:     "empty instance ID",
+				body:     `{"filters": {"instances": ["i-1", " "]}}`,
+				expected: `invalid request: \"filters.instances\" must not hold empty instance IDs`,
+			},
+			{
+				name:     "malformed VPC ID",
+				body:     `{"filters": {"vpc_id": "main"}}`,
+				expected: `invalid request: \"filters.vpc_id\" must be a VPC ID such as vpc-123`,
+			},
+			{
+				name:     "malformed subnet ID",
+				body:     `{"filters": {"subnet_id": "vpc-1"}}`,
+				expected: `invalid request: \"filters.subnet_id\" must be a subnet ID such as subnet-123`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				appMock := new(MockAppRunner)
+				validatorMock := new(MockValidator)
+				handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+				req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(tt.body)))
+				w := httptest.NewRecorder()
+
+				handler.HandleDrift(w, req)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.JSONEq(t, `{"error":"`+tt.expected+`"}`, w.Body.String())
+				validatorMock.AssertNotCalled(t, "ValidateAttributes", mock.Anything)
+				appMock.AssertNotCalled(t, "Run")
+			})
+		}
+	})
+
+	t.Run("missing fields use defaults", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).
+			Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "").
+			Return(parser.Terraform, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.HTTP, mock.Anything).
+			Return(nil)
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		validatorMock.AssertExpectations(t)
+		appMock.AssertExpectations(t)
+	})
+
 	t.Run("attribute validation failure", func(t *testing.T) {
 		appMock := new(MockAppRunner)
 		validatorMock := new(MockValidator)
@@ -131,7 +301,7 @@ func TestDriftHandler(t *testing.T) {
 			Return([]string{"instance-id"}, nil)
 		validatorMock.On("ValidateFormat", "json").
 			Return(parser.JSON, nil)
-		appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.JSON, ports.HTTP).
+		appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.JSON, ports.HTTP, mock.Anything).
 			Return(cerrors.ErrDriftDetected{})
 
 		body := `{"attributes": ["instance-id"], "format": "json"}`
@@ -141,7 +311,159 @@ func TestDriftHandler(t *testing.T) {
 		handler.HandleDrift(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.JSONEq(t, `{"drift_detected":true,"message":"Drift detected"}`, w.Body.String())
+		assert.Equal(t, `{"schema_version":1,"drift_detected":true,"message":"Drift detected"}`+"\n", w.Body.String(), "fields keep their declared order")
+	})
+
+	t.Run("inputs are recorded", func(t *testing.T) {
+		appMock := new(MockDescribedApp)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).
+			Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").
+			Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP, mock.Anything).
+			Return(cerrors.ErrDriftDetected{})
+
+		body := `{"attributes": ["ami"], "format": "json", "fail_on_severity": "critical"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"schema_version": 1, "drift_detected": true, "message": "Drift detected",
+			"meta": {"attributes": ["ami"], "input_format": "json", "region": "us-east-1", "filters": {"fail_on_severity": "critical"}}}`, w.Body.String())
+	})
+
+	t.Run("warnings are returned", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"instance-id"}).
+			Return([]string{"instance-id"}, nil)
+		validatorMock.On("ValidateFormat", "terraform").
+			Return(parser.Terraform, nil)
+		appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.Terraform, ports.HTTP, mock.Anything).
+			Run(func(args mock.Arguments) {
+				cloud.AddWarning(args.Get(0).(context.Context), "web", "aws_instance only partially decoded")
+			}).
+			Return(nil)
+
+		body := `{"attributes": ["instance-id"], "format": "terraform"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.DriftResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.False(t, resp.DriftDetected)
+		assert.Equal(t, []cloud.Warning{{InstanceID: "web", Message: "aws_instance only partially decoded"}}, resp.Warnings)
+	})
+
+	t.Run("config parse error", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"instance-id"}).
+			Return([]string{"instance-id"}, nil)
+		validatorMock.On("ValidateFormat", "json").
+			Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.JSON, ports.HTTP, mock.Anything).
+			Return(cerrors.NewErrConfigParse("json", errors.New("unexpected end of JSON input")))
+
+		body := `{"attributes": ["instance-id"], "format": "json"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var resp handlers.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, handlers.CodeConfigParse, resp.Code)
+		assert.Contains(t, resp.Error, "failed to parse json config")
+	})
+
+	t.Run("cloud provider errors", func(t *testing.T) {
+		cloudErrs := map[string]error{
+			"describe instances": cerrors.NewDescribeInstances(errors.New("ExpiredToken")),
+			"aws config load":    cerrors.NewAWSConfigLoad(errors.New("no credentials")),
+		}
+
+		for name, cloudErr := range cloudErrs {
+			t.Run(name, func(t *testing.T) {
+				appMock := new(MockAppRunner)
+				validatorMock := new(MockValidator)
+				handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+				validatorMock.On("ValidateAttributes", []string{"instance-id"}).
+					Return([]string{"instance-id"}, nil)
+				validatorMock.On("ValidateFormat", "json").
+					Return(parser.JSON, nil)
+				appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.JSON, ports.HTTP, mock.Anything).
+					Return(cloudErr)
+
+				body := `{"attributes": ["instance-id"], "format": "json"}`
+				req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+				w := httptest.NewRecorder()
+
+				handler.HandleDrift(w, req)
+
+				assert.Equal(t, http.StatusBadGateway, w.Code)
+
+				var resp handlers.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, handlers.ErrorResponse{Error: cloudErr.Error(), Code: handlers.CodeCloudUpstream}, resp)
+			})
+		}
+	})
+
+	t.Run("no attributes selected", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{}).
+			Return([]string{}, cerrors.NewErrNoAttributesSelected())
+
+		body := `{"attributes": [], "format": "json"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{"error":"no attributes selected for drift detection"}`, w.Body.String())
+		appMock.AssertNotCalled(t, "Run")
+	})
+
+	t.Run("app rejects empty attribute set", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).
+			Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").
+			Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP, mock.Anything).
+			Return(cerrors.NewErrNoAttributesSelected())
+
+		body := `{"attributes": ["ami"], "format": "json"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	})
 
 	// t.Run("no EC2 instances error", func(t *testing.T) {
@@ -197,7 +519,7 @@ func TestDriftHandler(t *testing.T) {
 			Return([]string{"instance-id"}, nil)
 		validatorMock.On("ValidateFormat", "json").
 			Return(parser.JSON, nil)
-		appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.JSON, ports.HTTP).
+		appMock.On("Run", mock.Anything, []string{"instance-id"}, parser.JSON, ports.HTTP, mock.Anything).
 			Return(nil)
 
 		body := `{"attributes": ["instance-id"], "format": "json"}`
@@ -207,6 +529,404 @@ func TestDriftHandler(t *testing.T) {
 		handler.HandleDrift(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.JSONEq(t, `{"drift_detected":false,"message":"No drift detected"}`, w.Body.String())
+		assert.Equal(t, `{"schema_version":1,"drift_detected":false,"message":"No drift detected"}`+"\n", w.Body.String(), "fields keep their declared order")
+	})
+
+	t.Run("severity threshold is passed to the run", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP,
+			app.RunOptions{FailOnSeverity: driftchecker.SeverityCritical}).Return(nil)
+
+		body := `{"attributes": ["ami"], "format": "json", "fail_on_severity": "Critical"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		appMock.AssertExpectations(t)
+	})
+
+	t.Run("region and filters are passed to the run", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").Return(parser.JSON, nil)
+		validatorMock.On("ValidateOnlyFilters", []string{"changed"}).Return([]string{"changed"}, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP, app.RunOptions{
+			Only:             []string{"changed"},
+			Region:           "eu-west-1",
+			Instances:        []string{"i-1", "i-2"},
+			ExcludeInstances: []string{"i-2"},
+			VpcID:            "vpc-1",
+			SubnetID:         "subnet-1",
+		}).Return(nil)
+
+		body := `{"attributes": ["ami"], "format": "json", "regions": ["eu-west-1"], "filters": {
+			"instances": ["i-1", "i-2"], "exclude_instances": ["i-2"],
+			"vpc_id": "vpc-1", "subnet_id": "subnet-1", "only": ["changed"]}}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		validatorMock.AssertExpectations(t)
+		appMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid only filters", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "").Return(parser.Terraform, nil)
+		validatorMock.On("ValidateOnlyFilters", []string{"modified"}).
+			Return([]string(nil), &cerrors.InvalidAttributesError{InvalidAttrs: []string{"modified"}})
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"filters": {"only": ["modified"]}}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "modified")
+		appMock.AssertNotCalled(t, "Run")
+	})
+
+	t.Run("ignored attributes are left out of the check", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami", "instance_type", "tags"}, nil)
+		validatorMock.On("ValidateAttributes", []string{"tags"}).Return([]string{"tags"}, nil)
+		validatorMock.On("ValidateFormat", "").Return(parser.Terraform, nil)
+		appMock.On("Run", mock.Anything, []string{"ami", "instance_type"}, parser.Terraform, ports.HTTP, app.RunOptions{DefaultAttributes: true}).
+			Return(nil)
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"ignore_attributes": ["tags"]}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		appMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid ignored attributes", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateAttributes", []string{"colour"}).
+			Return([]string(nil), &cerrors.InvalidAttributesError{InvalidAttrs: []string{"colour"}})
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"attributes": ["ami"], "ignore_attributes": ["colour"]}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "colour")
+		appMock.AssertNotCalled(t, "Run")
+	})
+
+	t.Run("ignoring every attribute", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"attributes": ["ami"], "ignore_attributes": ["ami"]}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{"error":"no attributes selected for drift detection"}`, w.Body.String())
+		appMock.AssertNotCalled(t, "Run")
+	})
+
+	t.Run("filters matching no instance", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "").Return(parser.Terraform, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.HTTP, mock.Anything).
+			Return(cerrors.NewErrNoMatchingInstances())
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"filters": {"instances": ["i-404"]}}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{"error":"no live instance matched the instance selection"}`, w.Body.String())
+	})
+
+	t.Run("inline config is passed to the run", func(t *testing.T) {
+		config := `[{"instance_id": "web", "ami": "ami-1", "tags": {"Name": "web"}}]`
+		for name, body := range map[string]string{
+			"plain text": `{"attributes": ["ami"], "format": "json", "config": ` + strconv.Quote(config) + `}`,
+			"base64": `{"attributes": ["ami"], "format": "json", "config_encoding": "base64", "config": "` +
+				base64.StdEncoding.EncodeToString([]byte(config)) + `"}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				appMock := new(MockAppRunner)
+				validatorMock := new(MockValidator)
+				handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+				validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+				validatorMock.On("ValidateFormat", "json").Return(parser.JSON, nil)
+				appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP,
+					app.RunOptions{Config: []byte(config)}).Return(cerrors.ErrDriftDetected{})
+
+				req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+				w := httptest.NewRecorder()
+
+				handler.HandleDrift(w, req)
+
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), `"drift_detected":true`)
+				appMock.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("inline config that cannot be parsed", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP, mock.Anything).
+			Return(cerrors.NewErrConfigParse("json", errors.New("unexpected end of JSON input")))
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"format": "json", "config": "[{"}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"CONFIG_PARSE"`)
+	})
+
+	t.Run("no config and no STATE_PATH", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "").Return(parser.Terraform, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.Terraform, ports.HTTP, app.RunOptions{DefaultAttributes: true}).
+			Return(cerrors.NewErrMissingPaths())
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{"error":"no desired config: send it in the config field or set STATE_PATH on the server"}`, w.Body.String())
+	})
+
+	t.Run("inline config and STATE_PATH", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP, mock.Anything).
+			Return(cerrors.NewErrConflictingConfigSources())
+
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(`{"format": "json", "config": "[]"}`)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "STATE_PATH")
+	})
+
+	t.Run("formats registered with the app", func(t *testing.T) {
+		appMock := &MockFormatsApp{formats: []string{"json", "terraform", "yaml"}}
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string(nil)).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "yaml").Return(parser.ParserType("yaml"), nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.ParserType("yaml"), ports.HTTP, mock.Anything).Return(nil)
+
+		req := httptest.NewRequest("POST", "/drift", strings.NewReader(`{"format": "yaml"}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		appMock.AssertExpectations(t)
+
+		req = httptest.NewRequest("POST", "/drift", strings.NewReader(`{"format": "toml"}`))
+		w = httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "must be one of json, terraform, yaml")
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		appMock := new(MockAppRunner)
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		config := strings.Repeat(" ", handlers.MaxRequestBytes)
+		req := httptest.NewRequest("POST", "/drift", strings.NewReader(`{"config": "`+config+`"}`))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		appMock.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("report title is included in the response", func(t *testing.T) {
+		appMock := &MockTitledApp{title: "prod us-east-1"}
+		validatorMock := new(MockValidator)
+		handler := handlers.NewDriftHandler(appMock, validatorMock)
+
+		validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "json").Return(parser.JSON, nil)
+		appMock.On("Run", mock.Anything, []string{"ami"}, parser.JSON, ports.HTTP, mock.Anything).
+			Return(cerrors.ErrDriftDetected{})
+
+		body := `{"attributes": ["ami"], "format": "json"}`
+		req := httptest.NewRequest("POST", "/drift", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+
+		handler.HandleDrift(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"schema_version":1,"title":"prod us-east-1","drift_detected":true,"message":"Drift detected"}`+"\n", w.Body.String(), "fields keep their declared order")
+	})
+}
+
+type MockDescribedApp struct {
+	MockAppRunner
+}
+
+func (m *MockDescribedApp) RunMeta(attrs []string, format parser.ParserType, opts app.RunOptions) output.Meta {
+	return output.Meta{Attributes: attrs, InputFormat: string(format), Region: "us-east-1", Filters: output.MetaFilters{FailOnSeverity: string(opts.FailOnSeverity)}}
+}
+
+type MockTitledApp struct {
+	MockAppRunner
+	title string
+}
+
+func (m *MockTitledApp) ReportTitle() string {
+	return m.title
+}
+
+type MockFormatsApp struct {
+	MockAppRunner
+	formats []string
+}
+
+func (m *MockFormatsApp) InputFormats() []string {
+	return m.formats
+}
+
+type MockStreamingApp struct {
+	MockAppRunner
+}
+
+func (m *MockStreamingApp) Stream(ctx context.Context, args []string, pt parser.ParserType, opts app.RunOptions) (<-chan driftchecker.DriftReport, error) {
+	ret := m.Called(ctx, args, pt, opts)
+	reports, _ := ret.Get(0).(chan driftchecker.DriftReport)
+	return reports, ret.Error(1)
+}
+
+func TestDriftHandlerStreaming(t *testing.T) {
+	postStream := func(t *testing.T, handler *handlers.DriftHandler) *http.Response {
+		server := httptest.NewServer(http.HandlerFunc(handler.HandleDrift))
+		t.Cleanup(server.Close)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/drift", bytes.NewReader([]byte(`{"attributes":["ami"]}`)))
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/x-ndjson")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	newValidator := func() *MockValidator {
+		validatorMock := new(MockValidator)
+		validatorMock.On("ValidateAttributes", []string{"ami"}).Return([]string{"ami"}, nil)
+		validatorMock.On("ValidateFormat", "").Return(parser.Terraform, nil)
+		return validatorMock
+	}
+
+	t.Run("streams one report per line", func(t *testing.T) {
+		reports := make(chan driftchecker.DriftReport)
+		appMock := new(MockStreamingApp)
+		appMock.On("Stream", mock.Anything, []string{"ami"}, parser.Terraform, app.RunOptions{}).Return(reports, nil)
+
+		// Reports are sent one at a time after the response has started
+		go func() {
+			defer close(reports)
+			reports <- driftchecker.DriftReport{InstanceID: "i-1", Name: "web", Drifts: []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-2"}}}
+			reports <- driftchecker.DriftReport{InstanceID: "i-2", Name: "db", Drifts: []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-3", ActualValue: "ami-4"}}}
+		}()
+
+		resp := postStream(t, handlers.NewDriftHandler(appMock, newValidator()))
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+		assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+		var ids []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var report driftchecker.DriftReport
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &report))
+			ids = append(ids, report.InstanceID)
+		}
+		require.NoError(t, scanner.Err())
+		assert.Equal(t, []string{"i-1", "i-2"}, ids)
+		appMock.AssertNotCalled(t, "Run")
+	})
+
+	t.Run("errors before streaming keep their status", func(t *testing.T) {
+		appMock := new(MockStreamingApp)
+		appMock.On("Stream", mock.Anything, []string{"ami"}, parser.Terraform, app.RunOptions{}).
+			Return(nil, cerrors.NewDescribeInstances(errors.New("throttled")))
+
+		resp := postStream(t, handlers.NewDriftHandler(appMock, newValidator()))
+
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("apps without streaming support", func(t *testing.T) {
+		resp := postStream(t, handlers.NewDriftHandler(new(MockAppRunner), newValidator()))
+
+		assert.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
 	})
 }
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	cerrors "github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// driftRequest is the body accepted by POST /drift
+type driftRequest struct {
+	Attrs  []string `json:"attributes"` // Attributes to check for drift
+	Format string   `json:"format"`     // Desired config format (terraform or json), not the report format
+
+	Config         *string `json:"config"`          // Desired config content, parsed in place of STATE_PATH
+	ConfigEncoding string  `json:"config_encoding"` // Encoding of config: empty for plain text or base64
+
+	IgnoreAttrs []string     `json:"ignore_attributes"` // Attributes left out of the check, applied after attributes
+	Regions     []string     `json:"regions"`           // Region to fetch from instead of AWS_REGION, at most one
+	Filters     driftFilters `json:"filters"`           // Instance selection and output filters, as the run flags
+
+	FailOnSeverity string `json:"fail_on_severity"` // Lowest severity reported as drift_detected
+
+	severity driftchecker.Severity // FailOnSeverity once validated
+	config   []byte                // Config once decoded, nil when it was not sent
+}
+
+// driftFilters select the compared instances and the reported drift like
+// the run flags of the same names
+type driftFilters struct {
+	Instances        []string `json:"instances"`         // Live instance IDs to check, see --instances
+	ExcludeInstances []string `json:"exclude_instances"` // Live instance IDs to skip, see --exclude-instances
+	VpcID            string   `json:"vpc_id"`            // See --vpc-id
+	SubnetID         string   `json:"subnet_id"`         // See --subnet-id
+	Only             []string `json:"only"`              // Drift categories or attributes to report, see --only
+}
+
+// regionPattern matches AWS region names such as us-east-1 or us-gov-west-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// schemaFieldReasons explains the expected type of each field
+var schemaFieldReasons = map[string]string{
+	"attributes": "must be an array of strings",
+	"format":     "must be a string",
+
+	"config":          "must be a string",
+	"config_encoding": "must be a string",
+
+	"ignore_attributes":         "must be an array of strings",
+	"regions":                   "must be an array of strings",
+	"filters":                   "must be an object",
+	"filters.instances":         "must be an array of strings",
+	"filters.exclude_instances": "must be an array of strings",
+	"filters.vpc_id":            "must be a string",
+	"filters.subnet_id":         "must be a string",
+	"filters.only":              "must be an array of strings",
+
+	"fail_on_severity": "must be a string",
+}
+
+// decodeDriftRequest strictly decodes and validates a drift request body.
+// Unknown fields and values of the wrong type are rejected, missing fields
+// fall back to their defaults. The format field must be one of formats.
+func decodeDriftRequest(body io.Reader, formats []string) (driftRequest, error) {
+	var req driftRequest
+
+	// Read whole so unknown fields can be located in it
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return req, cerrors.NewErrInvalidJSON(err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			field := typeErr.Field
+			if _, known := schemaFieldReasons[field]; !known {
+				if i := strings.Index(field, "."); i >= 0 {
+					field = field[:i]
+				}
+			}
+			return req, cerrors.NewErrInvalidRequest(field, schemaFieldReasons[field])
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			// encoding/json has no typed error for unknown fields and
+			// names them without the path of the object holding them
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			if path, ok := unknownFieldPath(data, reflect.TypeOf(req), field); ok {
+				field = path
+			}
+			return req, cerrors.NewErrInvalidRequest(field, "is not a known field")
+		default:
+			return req, cerrors.NewErrInvalidJSON(err)
+		}
+	}
+
+	if req.Format != "" && !slices.Contains(formats, req.Format) {
+		return req, cerrors.NewErrInvalidRequest("format", "must be one of "+strings.Join(formats, ", "))
+	}
+
+	if err := req.decodeConfig(); err != nil {
+		return req, err
+	}
+
+	if len(req.Regions) > 1 {
+		return req, cerrors.NewErrInvalidRequest("regions", "must hold at most one region, a check fetches a single region")
+	}
+	for _, region := range req.Regions {
+		if !regionPattern.MatchString(region) {
+			return req, cerrors.NewErrInvalidRequest("regions", "must hold region names such as us-east-1")
+		}
+	}
+
+	if err := req.Filters.validate(); err != nil {
+		return req, err
+	}
+
+	if req.FailOnSeverity != "" {
+		var err error
+		if req.severity, err = driftchecker.ParseSeverity(req.FailOnSeverity); err != nil {
+			return req, cerrors.NewErrInvalidRequest("fail_on_severity", "must be one of "+strings.Join(driftchecker.Severities(), ", "))
+		}
+	}
+
+	return req, nil
+}
+
+// unknownFieldPath finds the unknown field name in the JSON object data
+// decoded into t and returns its dotted path, e.g. filters.tag. Keys match
+// fields case insensitively like encoding/json.
+func unknownFieldPath(data []byte, t reflect.Type, name string) (string, bool) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return "", false
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := jsonField(t, key)
+		if !ok {
+			if key == name {
+				return key, true
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			if path, ok := unknownFieldPath(object[key], field.Type, name); ok {
+				return key + "." + path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonField returns the exported field of struct t that the JSON key
+// decodes into
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// validate checks the fields of the filters that need no validator. The
+// only selectors are checked against the attributes by the handler.
+func (f driftFilters) validate() error {
+	for _, ids := range []struct {
+		field string
+		ids   []string
+	}{
+		{"filters.instances", f.Instances},
+		{"filters.exclude_instances", f.ExcludeInstances},
+	} {
+		for _, id := range ids.ids {
+			if strings.TrimSpace(id) == "" {
+				return cerrors.NewErrInvalidRequest(ids.field, "must not hold empty instance IDs")
+			}
+		}
+	}
+	if f.VpcID != "" && !strings.HasPrefix(f.VpcID, "vpc-") {
+		return cerrors.NewErrInvalidRequest("filters.vpc_id", "must be a VPC ID such as vpc-123")
+	}
+	if f.SubnetID != "" && !strings.HasPrefix(f.SubnetID, "subnet-") {
+		return cerrors.NewErrInvalidRequest("filters.subnet_id", "must be a subnet ID such as subnet-123")
+	}
+	return nil
+}
+
+// region returns the requested region, empty when none was given
+func (r driftRequest) region() string {
+	if len(r.Regions) == 0 {
+		return ""
+	}
+	return r.Regions[0]
+}
+
+// decodeConfig decodes the inline config, leaving it nil when none was sent
+func (r *driftRequest) decodeConfig() error {
+	switch r.ConfigEncoding {
+	case "", "base64":
+	default:
+		return cerrors.NewErrInvalidRequest("config_encoding", "must be base64 or left out for plain text")
+	}
+	if r.Config == nil {
+		if r.ConfigEncoding != "" {
+			return cerrors.NewErrInvalidRequest("config_encoding", "is only allowed together with config")
+		}
+		return nil
+	}
+
+	content := []byte(*r.Config)
+	if r.ConfigEncoding == "base64" {
+		var err error
+		if content, err = base64.StdEncoding.DecodeString(*r.Config); err != nil {
+			return cerrors.NewErrInvalidRequest("config", "must be valid base64 when config_encoding is base64")
+		}
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return cerrors.NewErrInvalidRequest("config", "must not be empty")
+	}
+	r.config = content
+	return nil
+}
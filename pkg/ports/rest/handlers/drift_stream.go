@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"go.uber.org/zap"
+)
+
+// ndjsonContentType is the media type of newline delimited JSON responses
+const ndjsonContentType = "application/x-ndjson"
+
+// acceptsNDJSON reports whether the Accept header asks for NDJSON.
+// Anything else, including no header, gets the buffered JSON response.
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && mediaType == ndjsonContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// streamDrift writes each drift report as its own JSON line, flushing after
+// every line so clients see results while the check is still running.
+// Errors raised before the first report keep their usual status codes.
+func (h *DriftHandler) streamDrift(w http.ResponseWriter, r *http.Request, validAttrs []string, parserType parser.ParserType, format string, opts app.RunOptions) {
+	streamer, ok := h.app.(app.DriftStreamer)
+	if !ok {
+		sendError(w, http.StatusNotAcceptable, "Streaming is not supported by this server")
+		return
+	}
+
+	reports, err := streamer.Stream(r.Context(), validAttrs, parserType, opts)
+	if err != nil {
+		sendRunError(w, err, validAttrs, format)
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	count := 0
+	encoder := json.NewEncoder(w)
+	for report := range reports {
+		if err := encoder.Encode(report); err != nil {
+			// The client has most likely gone away, stop streaming
+			logger.Log.Warn("Failed to stream drift report", zap.Error(err))
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		count++
+	}
+
+	logger.Log.Info("Streamed drift reports",
+		zap.Int("report_count", count),
+		zap.Strings("attributes", validAttrs),
+		zap.String("format", format),
+	)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Route describes an endpoint the server registered
+type Route struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// IndexResponse is the body of GET /, listing what the server serves
+type IndexResponse struct {
+	Address string  `json:"address"`
+	Routes  []Route `json:"routes"`
+}
+
+// IndexHandler lists the registered routes, so clients can discover them
+type IndexHandler struct {
+	address string
+	routes  []Route
+}
+
+// NewIndexHandler creates a new instance of IndexHandler for a server
+// listening on address
+func NewIndexHandler(address string, routes []Route) *IndexHandler {
+	return &IndexHandler{address: address, routes: routes}
+}
+
+// HandleIndex processes the GET / endpoint. The root pattern matches every
+// unregistered path, which are answered with 404.
+func (h *IndexHandler) HandleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		sendError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		logger.Log.Warn("Invalid method attempted",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sendResponse(w, http.StatusOK, IndexResponse{Address: h.address, Routes: h.routes})
+}
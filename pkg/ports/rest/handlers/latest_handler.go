@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"go.uber.org/zap"
+)
+
+// LatestReport is the outcome of the most recent scheduled drift check
+type LatestReport struct {
+	SchemaVersion int                        `json:"schema_version"`  // Set when served, see output.SchemaVersion
+	Title         string                     `json:"title,omitempty"` // REPORT_TITLE when the check ran
+	CheckedAt     time.Time                  `json:"checked_at"`
+	DriftDetected bool                       `json:"drift_detected"`
+	Meta          *output.Meta               `json:"meta,omitempty"` // Inputs of the check, when the app records them
+	Reports       []driftchecker.DriftReport `json:"reports"`
+	Total         int                        `json:"total"`               // Reports found, set when served
+	Truncated     bool                       `json:"truncated,omitempty"` // Reports holds only the first MAX_REPORTS
+	Error         string                     `json:"error,omitempty"`     // Set when the check failed
+}
+
+// LatestReportStore holds the outcome of the most recent scheduled check.
+// Latest reports false until a check has completed.
+type LatestReportStore interface {
+	Latest() (LatestReport, bool)
+}
+
+// LatestHandler serves the cached result of scheduled drift checks
+type LatestHandler struct {
+	store      LatestReportStore // nil when SCHEDULE is not set
+	maxReports int               // Reports served per response, zero serves all
+}
+
+// NewLatestHandler creates a new instance of LatestHandler. Responses hold
+// at most maxReports reports, all of them when it is zero.
+func NewLatestHandler(store LatestReportStore, maxReports int) *LatestHandler {
+	return &LatestHandler{store: store, maxReports: maxReports}
+}
+
+// HandleLatest processes the GET /drift/latest endpoint
+func (h *LatestHandler) HandleLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		logger.Log.Warn("Invalid method attempted",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+		sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if h.store == nil {
+		sendError(w, http.StatusNotFound, "Scheduled drift checks are not enabled, set SCHEDULE to enable them")
+		return
+	}
+
+	latest, ok := h.store.Latest()
+	if !ok {
+		sendError(w, http.StatusNotFound, "No scheduled drift check has completed yet")
+		return
+	}
+
+	if latest.Reports == nil {
+		latest.Reports = []driftchecker.DriftReport{}
+	}
+	latest.Total = len(latest.Reports)
+	if h.maxReports > 0 && latest.Total > h.maxReports {
+		latest.Reports = latest.Reports[:h.maxReports]
+		latest.Truncated = true
+	}
+	latest.SchemaVersion = output.SchemaVersion
+	sendResponse(w, http.StatusOK, latest)
+}
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the request ID. A value sent by the client or a
+// proxy is kept, otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// statusRecorder captures the status code and body size written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush keeps streamed NDJSON responses flowing through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// AccessLog writes one structured log line per request once it completes,
+// with its method, path, status, response size, start time, latency, remote
+// address and request ID. The request ID is echoed back in the X-Request-ID
+// header.
+func AccessLog(next http.Handler) http.Handler {
+	return AccessLogWith(newRequestID, time.Now)(next)
+}
+
+// AccessLogWith returns the AccessLog middleware generating request IDs with
+// newID and timing requests with now, so tests can fix both
+func AccessLogWith(newID func() string, now func() time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return accessLog(next, newID, now)
+	}
+}
+
+func accessLog(next http.Handler, newID func() string, now func() time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			// Nothing was written, net/http replies 200 with an empty body
+			status = http.StatusOK
+		}
+		logger.Log.Info("HTTP request",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", status),
+			zap.Int("bytes", recorder.bytes),
+			zap.Time("started_at", start),
+			zap.Duration("latency", now().Sub(start)),
+			zap.String("remote_addr", r.RemoteAddr),
+			zap.String("request_id", requestID),
+		)
+	})
+}
+
+// newRequestID returns a random 16 character hex ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/ports/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	original := logger.Log
+	logger.SetLogger(zap.New(core))
+	t.Cleanup(func() { logger.SetLogger(original) })
+
+	handler := rest.AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("short and stout"))
+	}))
+
+	t.Run("logs the request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/drift?format=json", nil)
+		req.RemoteAddr = "10.0.0.7:51234"
+		req.Header.Set(rest.RequestIDHeader, "req-42")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "req-42", rec.Header().Get(rest.RequestIDHeader))
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "HTTP request", entries[0].Message)
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "POST", fields["method"])
+		assert.Equal(t, "/drift", fields["path"])
+		assert.EqualValues(t, http.StatusTeapot, fields["status"])
+		assert.EqualValues(t, len("short and stout"), fields["bytes"])
+		assert.Equal(t, "10.0.0.7:51234", fields["remote_addr"])
+		assert.Equal(t, "req-42", fields["request_id"])
+		assert.Contains(t, fields, "latency")
+	})
+
+	t.Run("generates a request ID", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/drift/latest", nil))
+
+		requestID := rec.Header().Get(rest.RequestIDHeader)
+		assert.Len(t, requestID, 16)
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		assert.Equal(t, requestID, entries[0].ContextMap()["request_id"])
+	})
+
+	t.Run("uses the injected generator and clock", func(t *testing.T) {
+		start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		ticks := 0
+		clock := func() time.Time {
+			ticks++
+			return start.Add(time.Duration(ticks-1) * 250 * time.Millisecond)
+		}
+		fixed := rest.AccessLogWith(func() string { return "fixed-id" }, clock)(http.NotFoundHandler())
+
+		rec := httptest.NewRecorder()
+		fixed.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "fixed-id", rec.Header().Get(rest.RequestIDHeader))
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "fixed-id", fields["request_id"])
+		assert.Equal(t, start, fields["started_at"])
+		assert.Equal(t, 250*time.Millisecond, fields["latency"])
+	})
+}
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/pkg/logger"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/oldmonad/ec2Drift/pkg/ports/rest/handlers"
+	"github.com/oldmonad/ec2Drift/pkg/schedule"
+	"go.uber.org/zap"
+)
+
+// Scheduler runs drift checks on a schedule and keeps the latest result
+// in memory for GET /drift/latest
+type Scheduler struct {
+	checker  app.DriftChecker
+	schedule schedule.Schedule
+	attrs    []string
+	format   parser.ParserType
+	now      func() time.Time // Clock of CheckedAt, the server's when it runs the scheduler
+
+	mu     sync.RWMutex
+	latest handlers.LatestReport
+	ran    bool
+}
+
+// NewScheduler creates a scheduler that checks the given attributes
+func NewScheduler(checker app.DriftChecker, s schedule.Schedule, attrs []string, format parser.ParserType) *Scheduler {
+	return &Scheduler{checker: checker, schedule: s, attrs: attrs, format: format, now: time.Now}
+}
+
+// Start runs checks at every scheduled time until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Log.Warn("Schedule has no upcoming runs, stopping scheduled drift checks")
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce performs a single drift check and stores its outcome. A failed
+// check replaces the previous result so stale reports are never served.
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	logger.Log.Info("Running scheduled drift check", zap.Strings("attributes", s.attrs))
+
+	// Scheduled checks compare the default attribute set
+	opts := app.RunOptions{DefaultAttributes: true}
+	reports, err := s.checker.Check(ctx, s.attrs, s.format, opts)
+	latest := handlers.LatestReport{
+		CheckedAt:     s.now().UTC(),
+		DriftDetected: app.HasDrift(reports, opts),
+		Reports:       reports,
+	}
+	if err != nil {
+		logger.Log.Error("Scheduled drift check failed", zap.Error(err))
+		latest = handlers.LatestReport{CheckedAt: latest.CheckedAt, Error: err.Error()}
+	} else {
+		logger.Log.Info("Scheduled drift check finished", zap.Int("report_count", len(reports)))
+	}
+	if titler, ok := s.checker.(app.ReportTitler); ok {
+		latest.Title = titler.ReportTitle()
+	}
+	if describer, ok := s.checker.(app.RunDescriber); ok {
+		meta := describer.RunMeta(s.attrs, s.format, opts)
+		latest.Meta = &meta
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = latest
+	s.ran = true
+}
+
+// Latest returns the outcome of the most recent check
+func (s *Scheduler) Latest() (handlers.LatestReport, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest, s.ran
+}
@@ -0,0 +1,195 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/internal/app"
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/output"
+	"github.com/oldmonad/ec2Drift/pkg/parser"
+	"github.com/oldmonad/ec2Drift/pkg/ports/rest"
+	"github.com/oldmonad/ec2Drift/pkg/ports/rest/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDriftChecker struct {
+	mock.Mock
+}
+
+func (m *MockDriftChecker) Check(ctx context.Context, attrs []string, pt parser.ParserType, opts app.RunOptions) ([]driftchecker.DriftReport, error) {
+	args := m.Called(ctx, attrs, pt, opts)
+	reports, _ := args.Get(0).([]driftchecker.DriftReport)
+	return reports, args.Error(1)
+}
+
+// onceSchedule fires a single run shortly after the scheduler starts
+type onceSchedule struct {
+	mu    sync.Mutex
+	fired bool
+}
+
+func (s *onceSchedule) Next(after time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fired {
+		return time.Time{}
+	}
+	s.fired = true
+	return after.Add(10 * time.Millisecond)
+}
+
+// startServer starts the server on a free port and stops it when the test ends
+func startServer(t *testing.T, server rest.Server) string {
+	t.Helper()
+
+	port, err := getFreePort()
+	require.NoError(t, err)
+
+	go func() { _ = server.Start(port) }()
+	t.Cleanup(func() { _ = server.Stop() })
+
+	port, err = waitForServer(server, 2*time.Second)
+	require.NoError(t, err)
+	return "http://localhost:" + port
+}
+
+func getLatest(t *testing.T, baseURL string) (int, handlers.LatestReport) {
+	t.Helper()
+
+	resp, err := http.Get(baseURL + "/drift/latest")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var latest handlers.LatestReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&latest))
+	return resp.StatusCode, latest
+}
+
+func TestScheduledDriftCheck(t *testing.T) {
+	reports := []driftchecker.DriftReport{{
+		InstanceID: "i-123",
+		Name:       "web",
+		Drifts:     []driftchecker.DriftDetail{{Attribute: "ami", ExpectedValue: "ami-1", ActualValue: "ami-2"}},
+	}}
+
+	checked := make(chan struct{})
+	checker := new(MockDriftChecker)
+	checker.On("Check", mock.Anything, []string{"ami"}, parser.Terraform, app.RunOptions{DefaultAttributes: true}).
+		Return(reports, nil).
+		Run(func(mock.Arguments) { close(checked) }).
+		Once()
+
+	scheduler := rest.NewScheduler(checker, &onceSchedule{}, []string{"ami"}, parser.Terraform)
+	baseURL := startServer(t, rest.NewServer(new(MockAppRunner), new(MockValidator), rest.WithScheduler(scheduler)))
+
+	select {
+	case <-checked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduled drift check did not run")
+	}
+
+	require.Eventually(t, func() bool {
+		_, ok := scheduler.Latest()
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	status, latest := getLatest(t, baseURL)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, output.SchemaVersion, latest.SchemaVersion)
+	assert.True(t, latest.DriftDetected)
+	assert.Empty(t, latest.Error)
+	assert.False(t, latest.CheckedAt.IsZero())
+	require.Len(t, latest.Reports, 1)
+	assert.Equal(t, "i-123", latest.Reports[0].InstanceID)
+	checker.AssertExpectations(t)
+}
+
+func TestScheduledDriftCheckFailure(t *testing.T) {
+	checker := new(MockDriftChecker)
+	checker.On("Check", mock.Anything, []string{"ami"}, parser.Terraform, app.RunOptions{DefaultAttributes: true}).
+		Return(nil, errors.New("describe instances failed"))
+
+	scheduler := rest.NewScheduler(checker, &onceSchedule{fired: true}, []string{"ami"}, parser.Terraform)
+	baseURL := startServer(t, rest.NewServer(new(MockAppRunner), new(MockValidator), rest.WithScheduler(scheduler)))
+
+	// Nothing has run yet
+	status, _ := getLatest(t, baseURL)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	scheduler.RunOnce(context.Background())
+
+	status, latest := getLatest(t, baseURL)
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, latest.DriftDetected)
+	assert.Equal(t, "describe instances failed", latest.Error)
+	assert.Empty(t, latest.Reports)
+}
+
+func TestScheduledDriftCheckUsesServerClock(t *testing.T) {
+	checker := new(MockDriftChecker)
+	checker.On("Check", mock.Anything, []string{"ami"}, parser.Terraform, app.RunOptions{DefaultAttributes: true}).
+		Return([]driftchecker.DriftReport{}, nil)
+
+	checkedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	scheduler := rest.NewScheduler(checker, &onceSchedule{fired: true}, []string{"ami"}, parser.Terraform)
+	rest.NewServer(new(MockAppRunner), new(MockValidator),
+		rest.WithScheduler(scheduler), rest.WithClock(func() time.Time { return checkedAt }))
+
+	scheduler.RunOnce(context.Background())
+
+	latest, ok := scheduler.Latest()
+	require.True(t, ok)
+	assert.Equal(t, checkedAt.UTC(), latest.CheckedAt)
+}
+
+func TestLatestWithoutSchedule(t *testing.T) {
+	baseURL := startServer(t, rest.NewServer(new(MockAppRunner), new(MockValidator)))
+
+	status, latest := getLatest(t, baseURL)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, latest.Error, "SCHEDULE")
+}
+
+func TestLatestTruncatesReports(t *testing.T) {
+	reports := []driftchecker.DriftReport{
+		{InstanceID: "i-1", Drifts: []driftchecker.DriftDetail{{Attribute: "ami"}}},
+		{InstanceID: "i-2", Drifts: []driftchecker.DriftDetail{{Attribute: "ami"}}},
+		{InstanceID: "i-3", Drifts: []driftchecker.DriftDetail{{Attribute: "ami"}}},
+	}
+	checker := new(MockDriftChecker)
+	checker.On("Check", mock.Anything, []string{"ami"}, parser.Terraform, app.RunOptions{DefaultAttributes: true}).Return(reports, nil)
+
+	scheduler := rest.NewScheduler(checker, &onceSchedule{fired: true}, []string{"ami"}, parser.Terraform)
+	scheduler.RunOnce(context.Background())
+
+	t.Run("OverTheMax", func(t *testing.T) {
+		baseURL := startServer(t, rest.NewServer(new(MockAppRunner), new(MockValidator),
+			rest.WithScheduler(scheduler), rest.WithMaxReports(2)))
+
+		status, latest := getLatest(t, baseURL)
+		assert.Equal(t, http.StatusOK, status)
+		assert.True(t, latest.Truncated)
+		assert.Equal(t, 3, latest.Total)
+		require.Len(t, latest.Reports, 2)
+		assert.Equal(t, "i-1", latest.Reports[0].InstanceID)
+		assert.Equal(t, "i-2", latest.Reports[1].InstanceID)
+	})
+
+	t.Run("WithinTheMax", func(t *testing.T) {
+		baseURL := startServer(t, rest.NewServer(new(MockAppRunner), new(MockValidator),
+			rest.WithScheduler(scheduler), rest.WithMaxReports(3)))
+
+		_, latest := getLatest(t, baseURL)
+		assert.False(t, latest.Truncated)
+		assert.Equal(t, 3, latest.Total)
+		assert.Len(t, latest.Reports, 3)
+	})
+}
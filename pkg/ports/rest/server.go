@@ -3,6 +3,7 @@ package rest
 import (
 	"context"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -15,6 +16,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// Default server timeouts. The write timeout bounds a whole POST /drift
+// request, so it is generous enough for drift checks over large fleets.
+const (
+	DefaultReadTimeout  = 15 * time.Second
+	DefaultWriteTimeout = 10 * time.Minute
+	DefaultIdleTimeout  = 60 * time.Second
+)
+
 // Server defines the behavior for starting, stopping, and retrieving the address of an HTTP server.
 type Server interface {
 	Start(port string) error
@@ -27,25 +36,140 @@ type HttpServer struct {
 	// This struct can also be extended to handle different
 	// kinds of handlers, not just this drift handler, and can act
 	// as a hub for HTTP server primitives, e.g. (*http.Server)
-	driftHandler *handlers.DriftHandler
-	server       *http.Server
-	stopCancel   context.CancelFunc
+	driftHandler  *handlers.DriftHandler
+	latestHandler *handlers.LatestHandler
+	scheduler     *Scheduler // nil unless SCHEDULE is set
+	server        *http.Server
+	stopCancel    context.CancelFunc
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	idleTimeout  time.Duration
+
+	maxReports int // Reports per response, zero serves them all
+
+	reload func() error // Swaps in fresh configuration settings on SIGHUP, nil ignores SIGHUP
+
+	newRequestID func() string    // Generates the IDs of requests arriving without one
+	now          func() time.Time // Clock of the access log and scheduled checks
+}
+
+// ServerOption customises an HttpServer created by NewServer.
+type ServerOption func(*HttpServer)
+
+// WithScheduler runs the scheduler's drift checks while the server is up
+// and serves their latest result on GET /drift/latest.
+func WithScheduler(scheduler *Scheduler) ServerOption {
+	return func(s *HttpServer) {
+		s.scheduler = scheduler
+	}
+}
+
+// WithTimeouts overrides the default read, write and idle timeouts of the
+// server. Zero durations keep the defaults.
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(s *HttpServer) {
+		if read > 0 {
+			s.readTimeout = read
+		}
+		if write > 0 {
+			s.writeTimeout = write
+		}
+		if idle > 0 {
+			s.idleTimeout = idle
+		}
+	}
+}
+
+// WithMaxReports truncates the reports of a response to max, flagging the
+// response as truncated. Zero serves every report.
+func WithMaxReports(max int) ServerOption {
+	return func(s *HttpServer) {
+		s.maxReports = max
+	}
+}
+
+// WithReload makes the server call reload on SIGHUP, e.g. to pick up new
+// cloud credentials without a restart
+func WithReload(reload func() error) ServerOption {
+	return func(s *HttpServer) {
+		s.reload = reload
+	}
+}
+
+// WithRequestIDs generates the IDs of requests that arrive without an
+// X-Request-ID header with newID instead of random ones
+func WithRequestIDs(newID func() string) ServerOption {
+	return func(s *HttpServer) {
+		s.newRequestID = newID
+	}
+}
+
+// WithClock times requests and stamps scheduled checks with now instead of
+// time.Now
+func WithClock(now func() time.Time) ServerOption {
+	return func(s *HttpServer) {
+		s.now = now
+	}
 }
 
 // NewServer creates a new instance of HttpServer with initialized drift handler.
-func NewServer(app app.AppRunner, validator validator.Validator) Server {
-	return &HttpServer{driftHandler: handlers.NewDriftHandler(app, validator)}
+func NewServer(app app.AppRunner, validator validator.Validator, opts ...ServerOption) Server {
+	s := &HttpServer{
+		driftHandler: handlers.NewDriftHandler(app, validator),
+		readTimeout:  DefaultReadTimeout,
+		writeTimeout: DefaultWriteTimeout,
+		idleTimeout:  DefaultIdleTimeout,
+		newRequestID: newRequestID,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Avoid handing the handler a typed nil store
+	var store handlers.LatestReportStore
+	if s.scheduler != nil {
+		s.scheduler.now = s.now
+		store = s.scheduler
+	}
+	s.latestHandler = handlers.NewLatestHandler(store, s.maxReports)
+	return s
+}
+
+// Routes lists the endpoints the server registers, as served on GET /
+func (s *HttpServer) Routes() []handlers.Route {
+	latest := "Result of the latest scheduled drift check"
+	if s.scheduler == nil {
+		latest += ", disabled until SCHEDULE is set"
+	}
+	return []handlers.Route{
+		{Method: http.MethodGet, Path: "/", Description: "This list of routes"},
+		{Method: http.MethodPost, Path: "/drift", Description: "Run a drift check, streamed as NDJSON with Accept: application/x-ndjson"},
+		{Method: http.MethodGet, Path: "/drift/latest", Description: latest},
+	}
 }
 
 // Start starts the HTTP server on the specified port,
 // initializes signal handling for graceful shutdown, and listens for requests.
 func (s *HttpServer) Start(port string) error {
+	addr := ":" + port
+	routes := s.Routes()
+
 	mux := http.NewServeMux()
+	mux.HandleFunc("/", handlers.NewIndexHandler(addr, routes).HandleIndex)
 	mux.HandleFunc("/drift", s.driftHandler.HandleDrift)
+	mux.HandleFunc("/drift/latest", s.latestHandler.HandleLatest)
 
 	s.server = &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+		Addr:    addr,
+		Handler: AccessLogWith(s.newRequestID, s.now)(mux),
+
+		// Slow or idle clients must not hold connections open forever
+		ReadHeaderTimeout: s.readTimeout,
+		ReadTimeout:       s.readTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
 	}
 
 	// Set up context that listens for interrupt/termination signals.
@@ -53,7 +177,34 @@ func (s *HttpServer) Start(port string) error {
 	s.stopCancel = stop
 	defer stop()
 
-	logger.Log.Info("Starting HTTP server", zap.String("addr", s.server.Addr))
+	logger.Log.Info("Starting HTTP server",
+		zap.String("addr", s.server.Addr),
+		zap.Duration("read_timeout", s.readTimeout),
+		zap.Duration("write_timeout", s.writeTimeout),
+		zap.Duration("idle_timeout", s.idleTimeout),
+		zap.Strings("routes", routeNames(routes)),
+	)
+
+	if s.scheduler != nil {
+		// Stops together with the server on shutdown signals or Stop
+		go s.scheduler.Start(ctx)
+	}
+
+	if s.reload != nil {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+		go func() {
+			for {
+				select {
+				case <-hangup:
+					_ = s.Reload()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
 
 	errChan := make(chan error, 1)
 
@@ -74,6 +225,31 @@ func (s *HttpServer) Start(port string) error {
 	}
 }
 
+// routeNames formats routes as "METHOD /path" for the startup log
+func routeNames(routes []handlers.Route) []string {
+	names := make([]string, len(routes))
+	for i, route := range routes {
+		names[i] = route.Method + " " + route.Path
+	}
+	return names
+}
+
+// Reload reloads the configuration settings as SIGHUP does. A failed
+// reload keeps the current settings.
+func (s *HttpServer) Reload() error {
+	if s.reload == nil {
+		return nil
+	}
+
+	logger.Log.Info("Reloading configuration")
+	if err := s.reload(); err != nil {
+		logger.Log.Error("Configuration reload failed, keeping the current configuration", zap.Error(err))
+		return err
+	}
+	logger.Log.Info("Configuration reload succeeded")
+	return nil
+}
+
 // Stop performs a graceful shutdown of the server,
 // allowing active requests up to 5 seconds to complete.
 func (s *HttpServer) Stop() error {
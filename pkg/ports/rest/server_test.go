@@ -2,24 +2,32 @@ package rest_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/oldmonad/ec2Drift/internal/app"
 	pkgerrors "github.com/oldmonad/ec2Drift/pkg/errors"
 	"github.com/oldmonad/ec2Drift/pkg/logger"
 	"github.com/oldmonad/ec2Drift/pkg/parser"
 	"github.com/oldmonad/ec2Drift/pkg/ports"
 	"github.com/oldmonad/ec2Drift/pkg/ports/rest"
+	"github.com/oldmonad/ec2Drift/pkg/ports/rest/handlers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestMain(m *testing.M) {
@@ -34,8 +42,8 @@ type MockAppRunner struct {
 	mock.Mock
 }
 
-func (m *MockAppRunner) Run(ctx context.Context, args []string, pt parser.ParserType, rt ports.Runtype) error {
-	return m.Called(ctx, args, pt, rt).Error(0)
+func (m *MockAppRunner) Run(ctx context.Context, args []string, pt parser.ParserType, rt ports.Runtype, opts app.RunOptions) error {
+	return m.Called(ctx, args, pt, rt, opts).Error(0)
 }
 
 type MockValidator struct {
@@ -55,6 +63,11 @@ func (m *MockValidator) ValidateFormat(format string) (parser.ParserType, error)
 	return args.Get(0).(parser.ParserType), args.Error(1)
 }
 
+func (m *MockValidator) ValidateOnlyFilters(filters []string) ([]string, error) {
+	args := m.Called(filters)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // Helper function to get a free port
 func getFreePort() (string, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
@@ -216,6 +229,129 @@ func TestStartInvalidPort(t *testing.T) {
 	assert.IsType(t, pkgerrors.ErrServerListen{}, err)
 }
 
+// TestServerReadTimeout tests that a client which never finishes its
+// request headers is disconnected once the read timeout passes
+func TestServerReadTimeout(t *testing.T) {
+	server := rest.NewServer(new(MockAppRunner), new(MockValidator),
+		rest.WithTimeouts(100*time.Millisecond, 0, 0))
+	baseURL := startServer(t, server)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(baseURL, "http://"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("GET /drift/latest HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+
+	// The server answers the stalled request with a timeout or closes it
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	buf := make([]byte, 512)
+	for {
+		if _, err = conn.Read(buf); err != nil {
+			break
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		assert.False(t, netErr.Timeout(), "the connection was not closed by the server")
+	}
+}
+
+func TestServerIndex(t *testing.T) {
+	server := rest.NewServer(new(MockAppRunner), new(MockValidator))
+	baseURL := startServer(t, server)
+
+	resp, err := http.Get(baseURL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var index handlers.IndexResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&index))
+	assert.Equal(t, server.Address(), index.Address)
+	assert.Equal(t, server.(*rest.HttpServer).Routes(), index.Routes)
+
+	paths := make([]string, len(index.Routes))
+	for i, route := range index.Routes {
+		paths[i] = route.Method + " " + route.Path
+	}
+	assert.Equal(t, []string{"GET /", "POST /drift", "GET /drift/latest"}, paths)
+	assert.Contains(t, index.Routes[2].Description, "disabled until SCHEDULE is set")
+
+	// The root pattern catches unknown paths
+	notFound, err := http.Get(baseURL + "/metrics")
+	require.NoError(t, err)
+	notFound.Body.Close()
+	assert.Equal(t, http.StatusNotFound, notFound.StatusCode)
+
+	// Scheduled checks enable GET /drift/latest
+	scheduled := rest.NewServer(new(MockAppRunner), new(MockValidator),
+		rest.WithScheduler(rest.NewScheduler(new(MockDriftChecker), &onceSchedule{fired: true}, []string{"ami"}, parser.Terraform)))
+	assert.Equal(t, "Result of the latest scheduled drift check", scheduled.(*rest.HttpServer).Routes()[2].Description)
+}
+
+// TestServerRequestIDsAndClock tests that the injected request ID generator
+// and clock are used for the response header and the access log
+func TestServerRequestIDsAndClock(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	original := logger.Log
+	logger.SetLogger(zap.New(core))
+	t.Cleanup(func() { logger.SetLogger(original) })
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := rest.NewServer(new(MockAppRunner), new(MockValidator),
+		rest.WithRequestIDs(func() string { return "req-fixed" }),
+		rest.WithClock(func() time.Time { return start }))
+	baseURL := startServer(t, server)
+
+	resp, err := http.Get(baseURL + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "req-fixed", resp.Header.Get(rest.RequestIDHeader))
+
+	// The access log is written once the handler returned
+	var requests *observer.ObservedLogs
+	require.Eventually(t, func() bool {
+		requests = logs.FilterMessage("HTTP request")
+		return requests.Len() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	fields := requests.All()[0].ContextMap()
+	assert.Equal(t, "req-fixed", fields["request_id"])
+	assert.Equal(t, start, fields["started_at"])
+	assert.Equal(t, time.Duration(0), fields["latency"])
+}
+
+func TestServerReloadOnSIGHUP(t *testing.T) {
+	// Keep SIGHUP from terminating the test binary before Start subscribes
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	var reloads atomic.Int32
+	server := rest.NewServer(new(MockAppRunner), new(MockValidator), rest.WithReload(func() error {
+		reloads.Add(1)
+		return nil
+	}))
+	startServer(t, server)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		return reloads.Load() > 0
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestServerReloadFailure(t *testing.T) {
+	reloadErr := errors.New("missing AWS_REGION")
+	server := rest.NewServer(new(MockAppRunner), new(MockValidator), rest.WithReload(func() error {
+		return reloadErr
+	}))
+
+	assert.ErrorIs(t, server.(*rest.HttpServer).Reload(), reloadErr)
+
+	// Without a reload function SIGHUP is ignored
+	assert.NoError(t, rest.NewServer(new(MockAppRunner), new(MockValidator)).(*rest.HttpServer).Reload())
+}
+
 func TestGracefulShutdownSuccess(t *testing.T) {
 	mockApp := new(MockAppRunner)
 	mockValidator := new(MockValidator)
@@ -226,7 +362,7 @@ func TestGracefulShutdownSuccess(t *testing.T) {
 	processing := make(chan struct{})
 	completed := make(chan struct{}) // Add completion channel
 
-	mockApp.On("Run", mock.Anything, mock.Anything, parser.JSON, mock.Anything).
+	mockApp.On("Run", mock.Anything, mock.Anything, parser.JSON, mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			close(processing)
 			<-completed // Wait for test to allow completion
@@ -297,7 +433,7 @@ func TestConcurrentRequestsDuringShutdown(t *testing.T) {
 	processing := make(chan struct{}, 5) // Buffered channel for 5 requests
 	blockProcessing := make(chan struct{})
 
-	mockApp.On("Run", mock.Anything, mock.Anything, parser.JSON, mock.Anything).
+	mockApp.On("Run", mock.Anything, mock.Anything, parser.JSON, mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			processing <- struct{}{} // Signal request start
 			<-blockProcessing        // Block until release
@@ -488,9 +624,12 @@ func TestPortAlreadyInUse(t *testing.T) {
 	port, err := getFreePort()
 	require.NoError(t, err)
 
-	// Occupy the port
-	occupiedServer := &http.Server{Addr: ":" + port}
-	go occupiedServer.ListenAndServe()
+	// Occupy the port, binding synchronously so the listener is guaranteed
+	// to hold the port before our server attempts to start
+	listener, err := net.Listen("tcp", ":"+port)
+	require.NoError(t, err)
+	occupiedServer := &http.Server{}
+	go occupiedServer.Serve(listener)
 	defer occupiedServer.Close()
 
 	// Try to start our server on same port
@@ -0,0 +1,169 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// Schedule returns the next time a job should run after the given time
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Parse reads a SCHEDULE value. It accepts a Go duration (15m, 1h30m),
+// the same duration prefixed with @every, or a five field cron expression
+// (minute hour day-of-month month day-of-week) in local time. As in classic
+// cron, a day matches either day field when both are restricted.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.NewErrInvalidSchedule(spec, "schedule is empty")
+	}
+
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		return parseInterval(spec, strings.TrimSpace(interval))
+	}
+	if d, err := time.ParseDuration(spec); err == nil {
+		return intervalFromDuration(spec, d)
+	}
+	return parseCron(spec)
+}
+
+// Interval runs a job at a fixed period
+type Interval time.Duration
+
+func (i Interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+func parseInterval(spec, interval string) (Schedule, error) {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return nil, errors.NewErrInvalidSchedule(spec, "interval must be a duration such as 15m")
+	}
+	return intervalFromDuration(spec, d)
+}
+
+func intervalFromDuration(spec string, d time.Duration) (Schedule, error) {
+	if d < time.Second {
+		return nil, errors.NewErrInvalidSchedule(spec, "interval must be at least 1s")
+	}
+	return Interval(d), nil
+}
+
+// Cron runs a job on the minutes matched by a five field cron expression
+type Cron struct {
+	minutes, hours, days, months, weekdays map[int]bool
+
+	// Set when neither day field starts with *, so a day matching either
+	// of them is enough, e.g. "0 0 1,15 * 1" runs on the 1st, the 15th and
+	// every Monday
+	anyDay bool
+}
+
+// cronFields lists the name and allowed range of each cron field in order
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+func parseCron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, errors.NewErrInvalidSchedule(spec, "expected a duration or 5 cron fields")
+	}
+
+	sets := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, ok := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if !ok {
+			return nil, errors.NewErrInvalidSchedule(spec, "invalid "+cronFields[i].name+" field "+strconv.Quote(field))
+		}
+		sets[i] = set
+	}
+
+	return &Cron{
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+		anyDay:   !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField expands a comma separated list of *, n, a-b and
+// their /step forms into the set of values it matches
+func parseCronField(field string, min, max int) (map[int]bool, bool) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepStr)
+			if err != nil || s < 1 {
+				return nil, false
+			}
+			step = s
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var errA, errB error
+			lo, errA = strconv.Atoi(a)
+			hi, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil || lo > hi {
+				return nil, false
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return nil, false
+			}
+			lo = n
+			if !hasStep {
+				hi = n
+			}
+		}
+		if lo < min || hi > max {
+			return nil, false
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, true
+}
+
+// Next returns the first matching minute after the given time, or the
+// zero time when nothing matches within a year (e.g. 30 February)
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(1, 0, 0); t.Before(limit); t = t.Add(time.Minute) {
+		if c.minutes[t.Minute()] && c.hours[t.Hour()] && c.months[int(t.Month())] && c.matchesDay(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the day of t matches the day fields
+func (c *Cron) matchesDay(t time.Time) bool {
+	if c.anyDay {
+		return c.days[t.Day()] || c.weekdays[int(t.Weekday())]
+	}
+	return c.days[t.Day()] && c.weekdays[int(t.Weekday())]
+}
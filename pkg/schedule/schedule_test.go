@@ -0,0 +1,94 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+	"github.com/oldmonad/ec2Drift/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, spec := range []string{"15m", "@every 15m", " 15m "} {
+		s, err := schedule.Parse(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, start.Add(15*time.Minute), s.Next(start), spec)
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "every 15 minutes",
+			spec:     "*/15 * * * *",
+			after:    time.Date(2024, 5, 1, 10, 7, 30, 0, time.UTC),
+			expected: time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "runs strictly after the given time",
+			spec:     "*/15 * * * *",
+			after:    time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily at 02:30",
+			spec:     "30 2 * * *",
+			after:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 2, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekdays at 9 with lists and ranges",
+			spec:     "0 9,17 * * 1-5",
+			after:    time.Date(2024, 5, 3, 18, 0, 0, 0, time.UTC), // Friday
+			expected: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),  // Monday
+		},
+		{
+			name:     "either restricted day field matches",
+			spec:     "0 0 1,15 * 1",
+			after:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), // Wednesday the 1st
+			expected: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),  // Monday the 6th
+		},
+		{
+			name:     "day of month matches without its weekday",
+			spec:     "0 0 1,15 * 1",
+			after:    time.Date(2024, 5, 13, 10, 0, 0, 0, time.UTC), // Monday
+			expected: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),  // Wednesday the 15th
+		},
+		{
+			name:     "starred day of month leaves day of week to match",
+			spec:     "0 0 */2 * 1",
+			after:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), // First odd Monday
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := schedule.Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, s.Next(tt.after))
+		})
+	}
+}
+
+func TestCronWithoutUpcomingRuns(t *testing.T) {
+	s, err := schedule.Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "soon", "@every often", "500ms", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *"} {
+		_, err := schedule.Parse(spec)
+		assert.ErrorAs(t, err, &errors.ErrInvalidSchedule{}, spec)
+	}
+}
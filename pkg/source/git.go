@@ -0,0 +1,173 @@
+package source
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// gitPrefix marks a state path as a git reference
+const gitPrefix = "git::"
+
+// GitTokenEnv names the environment variable holding the token used to
+// authenticate HTTPS clones
+const GitTokenEnv = "GIT_TOKEN"
+
+// GitRef points at a file inside a git repository, written as
+// git::<repository url>//<path in repository>?ref=<branch or tag>
+type GitRef struct {
+	URL  string // Repository to clone
+	Path string // File path relative to the repository root
+	Ref  string // Branch or tag, empty uses the default branch
+}
+
+// IsGitRef reports whether the state path is a git reference
+func IsGitRef(path string) bool {
+	return strings.HasPrefix(path, gitPrefix)
+}
+
+// RedactPath masks the credentials of URLs in a state path, such as a token
+// in git::https://<token>@github.com/org/repo//main.tf, so the path can be
+// recorded in reports. Other paths are returned unchanged.
+func RedactPath(path string) string {
+	i := strings.Index(path, "://")
+	if i < 0 {
+		return path
+	}
+	host := path[i+len("://"):]
+	at := strings.Index(host, "@")
+	if at < 0 {
+		return path
+	}
+	if end := strings.IndexAny(host, "/?"); end >= 0 && end < at {
+		return path
+	}
+	return path[:i+len("://")] + "****@" + host[at+1:]
+}
+
+// ParseGitRef splits a git reference into its repository, path and ref
+func ParseGitRef(raw string) (GitRef, error) {
+	spec, ok := strings.CutPrefix(raw, gitPrefix)
+	if !ok {
+		return GitRef{}, errors.NewGitSourceError(raw, fmt.Errorf("missing %q prefix", gitPrefix))
+	}
+
+	var ref GitRef
+	if base, query, hasQuery := strings.Cut(spec, "?"); hasQuery {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return GitRef{}, errors.NewGitSourceError(raw, err)
+		}
+		ref.Ref = values.Get("ref")
+		spec = base
+	}
+
+	// The repository URL and the file path are separated by the first "//"
+	// that is not part of the URL scheme
+	start := 0
+	if i := strings.Index(spec, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	sep := strings.Index(spec[start:], "//")
+	if sep < 0 {
+		return GitRef{}, errors.NewGitSourceError(raw, fmt.Errorf("missing //path to the file in the repository"))
+	}
+	ref.URL = spec[:start+sep]
+	ref.Path = spec[start+sep+len("//"):]
+
+	if ref.URL == "" || ref.Path == "" {
+		return GitRef{}, errors.NewGitSourceError(raw, fmt.Errorf("repository URL and file path are required"))
+	}
+	if !filepath.IsLocal(ref.Path) {
+		return GitRef{}, errors.NewGitSourceError(raw, fmt.Errorf("path %q must stay inside the repository", ref.Path))
+	}
+	return ref, nil
+}
+
+// ReadGitFile shallowly clones the repository into a temporary directory,
+// reads the referenced file and removes the checkout again. HTTPS clones
+// authenticate with the token in GIT_TOKEN when it is set. A file that is a
+// symlink pointing outside the checkout is rejected.
+func ReadGitFile(ctx context.Context, ref GitRef) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "ec2drift-git-")
+	if err != nil {
+		return nil, errors.NewGitSourceError(ref.URL, err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := &git.CloneOptions{
+		URL:          ref.URL,
+		Depth:        1,
+		SingleBranch: true,
+		Tags:         git.NoTags,
+	}
+	if token := os.Getenv(GitTokenEnv); token != "" && strings.HasPrefix(ref.URL, "https://") {
+		// Handed to the HTTP transport in memory, never to a command line
+		opts.Auth = &githttp.BasicAuth{Username: "x-access-token", Password: token}
+	}
+
+	checkout := filepath.Join(dir, "repo")
+	if err := cloneRef(ctx, checkout, opts, ref.Ref); err != nil {
+		return nil, errors.NewGitSourceError(ref.URL, err)
+	}
+
+	path, err := resolveInside(checkout, ref.Path)
+	if err != nil {
+		return nil, errors.NewGitSourceError(ref.URL, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewGitSourceError(ref.URL, err)
+	}
+	return data, nil
+}
+
+// cloneRef clones the named branch, or the tag of that name when there is
+// no such branch, into dir. An empty name clones the default branch.
+func cloneRef(ctx context.Context, dir string, opts *git.CloneOptions, name string) error {
+	if name == "" {
+		_, err := git.PlainCloneContext(ctx, dir, false, opts)
+		return err
+	}
+
+	opts.ReferenceName = plumbing.NewBranchReferenceName(name)
+	_, err := git.PlainCloneContext(ctx, dir, false, opts)
+	if !stderrors.As(err, &git.NoMatchingRefSpecError{}) && !stderrors.Is(err, plumbing.ErrReferenceNotFound) {
+		return err
+	}
+
+	// The failed attempt may have left a partial repository behind
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	opts.ReferenceName = plumbing.NewTagReferenceName(name)
+	_, err = git.PlainCloneContext(ctx, dir, false, opts)
+	return err
+}
+
+// resolveInside joins path to the checkout root and follows its symlinks,
+// rejecting a result outside the checkout
+func resolveInside(root, path string) (string, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("path %q resolves outside the repository", path)
+	}
+	return resolved, nil
+}
@@ -8,21 +8,40 @@ import (
 )
 
 // ValidateAttributes checks if all the requested attributes are valid.
-// If no attributes are requested, it returns all valid attributes by default.
+// Aliases such as "type" are replaced by their canonical names, which are
+// the names returned. If no attributes are requested, it returns all valid
+// attributes by default.
 // If any of the requested attributes are invalid, an error is returned containing
-// the list of invalid attributes and the valid attributes.
+// the list of invalid attributes and the valid attributes. An empty effective
+// attribute set is rejected with ErrNoAttributesSelected.
 func (v *ValidatorOptions) ValidateAttributes(requested []string) ([]string, error) {
 	// If no attributes are requested, return all valid attributes
 	if len(requested) == 0 {
-		return v.AllAttributes(), nil
+		all := v.AllAttributes()
+		if len(all) == 0 {
+			return nil, errors.NewErrNoAttributesSelected()
+		}
+		return all, nil
 	}
 
 	// Slice to collect any invalid attributes
 	var invalidAttrs []string
+	canonical := make([]string, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
 	for _, a := range requested {
+		name := a
+		if alias, ok := v.aliases[a]; ok {
+			name = alias
+		}
 		// Check if the attribute is invalid (not in the valid set)
-		if !v.validAttributes[a] {
+		if !v.validAttributes[name] {
 			invalidAttrs = append(invalidAttrs, a)
+			continue
+		}
+		// An alias and its canonical name select the same attribute
+		if !seen[name] {
+			seen[name] = true
+			canonical = append(canonical, name)
 		}
 	}
 
@@ -31,11 +50,12 @@ func (v *ValidatorOptions) ValidateAttributes(requested []string) ([]string, err
 		return nil, &errors.InvalidAttributesError{
 			InvalidAttrs: invalidAttrs,
 			ValidAttrs:   v.AllAttributes(), // Include all valid attributes for reference
+			Aliases:      v.aliases,
 		}
 	}
 
-	// Return the requested attributes if all are valid
-	return requested, nil
+	// Return the canonical names of the requested attributes
+	return canonical, nil
 }
 
 // AllAttributes returns a sorted list of all valid attribute names.
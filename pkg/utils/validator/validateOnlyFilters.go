@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/oldmonad/ec2Drift/internal/driftchecker"
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// ValidateOnlyFilters checks the selectors passed to the --only filter.
+// A selector is valid when it is a drift category (added, removed, changed),
+// a valid attribute, the parent of a nested attribute (e.g. root_block_device)
+// or a specific tag key (e.g. tags.Env).
+func (v *ValidatorOptions) ValidateOnlyFilters(filters []string) ([]string, error) {
+	if len(filters) == 0 {
+		return filters, nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, category := range driftchecker.Categories() {
+		allowed[category] = true
+	}
+	for attr := range v.validAttributes {
+		allowed[attr] = true
+		if parent, _, nested := strings.Cut(attr, "."); nested {
+			allowed[parent] = true
+		}
+	}
+
+	var invalid []string
+	for _, f := range filters {
+		if allowed[f] || (v.validAttributes["tags"] && strings.HasPrefix(f, "tags.")) {
+			continue
+		}
+		invalid = append(invalid, f)
+	}
+
+	if len(invalid) > 0 {
+		return nil, &errors.InvalidAttributesError{
+			InvalidAttrs: invalid,
+			ValidAttrs:   append(driftchecker.Categories(), v.AllAttributes()...),
+		}
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"sort"
+
+	"github.com/oldmonad/ec2Drift/pkg/errors"
+)
+
+// attributePresets are the curated attribute sets selected with --preset
+var attributePresets = map[string][]string{
+	"security": {
+		"security_groups",
+		"metadata_options.http_tokens",
+		"associate_public_ip_address",
+		"disable_api_termination",
+	},
+	"networking": {
+		"private_ip",
+		"public_ip",
+		"associate_public_ip_address",
+		"security_groups",
+	},
+	"compute": {
+		"ami",
+		"instance_type",
+		"instance_lifecycle",
+		"monitoring",
+		"host_id",
+		"affinity",
+		"capacity_reservation_id",
+	},
+	"storage": {
+		"root_device_type",
+		"root_block_device.volume_size",
+		"root_block_device.volume_type",
+		"root_block_device.encrypted",
+		"root_block_device.delete_on_termination",
+	},
+}
+
+// PresetExpander is implemented by validators that know attribute presets
+type PresetExpander interface {
+	ExpandPresets(presets []string) ([]string, error)
+}
+
+// PresetNames returns the sorted names accepted by --preset
+func PresetNames() []string {
+	return presetNames(attributePresets)
+}
+
+func presetNames(presets map[string][]string) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandPresets returns the attributes of the named presets in order,
+// without duplicates. The result still goes through ValidateAttributes
+// together with any explicitly requested attributes. An unknown preset
+// returns ErrUnknownPreset.
+func (v *ValidatorOptions) ExpandPresets(presets []string) ([]string, error) {
+	var attributes []string
+	seen := make(map[string]bool)
+	for _, name := range presets {
+		preset, ok := v.presets[name]
+		if !ok {
+			return nil, errors.NewErrUnknownPreset(name, presetNames(v.presets))
+		}
+		for _, attr := range preset {
+			if !seen[attr] {
+				seen[attr] = true
+				attributes = append(attributes, attr)
+			}
+		}
+	}
+	return attributes, nil
+}
@@ -4,6 +4,9 @@ import (
 	"github.com/oldmonad/ec2Drift/pkg/parser"
 )
 
+// ValidateFormat resolves the desired config format given with --input-format
+// or the format field of REST requests. Report formats are validated by the
+// output package instead.
 func (v *ValidatorOptions) ValidateFormat(format string) (parser.ParserType, error) {
 	// this is where the file input format would be validated but we
 	// would just return the default parser type because there is
@@ -11,7 +11,29 @@ func NewValidator() Validator {
 			"tags":                          true,
 			"root_block_device.volume_size": true,
 			"root_block_device.volume_type": true,
+			"root_block_device.encrypted":   true,
+			"root_block_device.delete_on_termination": true,
+			"private_ip":                   true,
+			"public_ip":                    true,
+			"metadata_options.http_tokens": true,
+			"disable_api_termination":      true,
+			"user_data":                    true,
+			"instance_lifecycle":           true,
+			"monitoring":                   true,
+			"host_id":                      true,
+			"capacity_reservation_id":      true,
+			"affinity":                     true,
+			"root_device_type":             true,
+			"associate_public_ip_address":  true,
+			"instance_state":               true,
 		},
+		// Common synonyms users type for canonical attribute names
+		aliases: map[string]string{
+			"type":  "instance_type",
+			"sg":    "security_groups",
+			"image": "ami",
+		},
+		presets: attributePresets,
 		supportedFormats: map[string]parser.ParserType{
 			"terraform": parser.Terraform,
 			"json":      parser.JSON,
@@ -21,12 +43,15 @@ func NewValidator() Validator {
 
 type ValidatorOptions struct {
 	validAttributes  map[string]bool
+	aliases          map[string]string   // Alias -> canonical attribute name
+	presets          map[string][]string // Preset name -> attributes, see --preset
 	supportedFormats map[string]parser.ParserType
 }
 
 type Validator interface {
 	ValidateAttributes(requested []string) ([]string, error)
-	ValidateFormat(format string) (parser.ParserType, error)
+	ValidateFormat(format string) (parser.ParserType, error) // Desired config format, not the report format
+	ValidateOnlyFilters(filters []string) ([]string, error)
 }
 
 func NewValidatorOptionsForTesting(validAttrs map[string]bool) *ValidatorOptions {
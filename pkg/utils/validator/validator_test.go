@@ -15,12 +15,27 @@ func TestValidateAttributes(t *testing.T) {
 
 	t.Run("empty requested attributes returns all valid attributes sorted", func(t *testing.T) {
 		expected := []string{
+			"affinity",
 			"ami",
+			"associate_public_ip_address",
+			"capacity_reservation_id",
+			"disable_api_termination",
+			"host_id",
+			"instance_lifecycle",
+			"instance_state",
 			"instance_type",
+			"metadata_options.http_tokens",
+			"monitoring",
+			"private_ip",
+			"public_ip",
+			"root_block_device.delete_on_termination",
+			"root_block_device.encrypted",
 			"root_block_device.volume_size",
 			"root_block_device.volume_type",
+			"root_device_type",
 			"security_groups",
 			"tags",
+			"user_data",
 		}
 
 		attrs, err := v.ValidateAttributes([]string{})
@@ -50,12 +65,27 @@ func TestValidateAttributes(t *testing.T) {
 		assert.Equal(t, expectedInvalid, invalidErr.InvalidAttrs)
 
 		expectedValid := []string{
+			"affinity",
 			"ami",
+			"associate_public_ip_address",
+			"capacity_reservation_id",
+			"disable_api_termination",
+			"host_id",
+			"instance_lifecycle",
+			"instance_state",
 			"instance_type",
+			"metadata_options.http_tokens",
+			"monitoring",
+			"private_ip",
+			"public_ip",
+			"root_block_device.delete_on_termination",
+			"root_block_device.encrypted",
 			"root_block_device.volume_size",
 			"root_block_device.volume_type",
+			"root_device_type",
 			"security_groups",
 			"tags",
+			"user_data",
 		}
 		assert.Equal(t, expectedValid, invalidErr.ValidAttrs)
 	})
@@ -76,6 +106,54 @@ func TestValidateAttributes(t *testing.T) {
 	})
 }
 
+func TestValidateAttributesAliases(t *testing.T) {
+	v := validator.NewValidator()
+
+	tests := []struct {
+		alias     string
+		canonical string
+	}{
+		{"type", "instance_type"},
+		{"sg", "security_groups"},
+		{"image", "ami"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			attrs, err := v.ValidateAttributes([]string{tt.alias})
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.canonical}, attrs)
+		})
+	}
+
+	t.Run("alias and canonical name are deduplicated", func(t *testing.T) {
+		attrs, err := v.ValidateAttributes([]string{"ami", "type", "image", "instance_type"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ami", "instance_type"}, attrs)
+	})
+
+	t.Run("unknown token lists valid names and aliases", func(t *testing.T) {
+		attrs, err := v.ValidateAttributes([]string{"sg", "flavor"})
+		require.Error(t, err)
+		assert.Nil(t, attrs)
+
+		invalidErr, ok := err.(*errors.InvalidAttributesError)
+		require.True(t, ok, "error should be of type InvalidAttributesError")
+		assert.Equal(t, []string{"flavor"}, invalidErr.InvalidAttrs)
+		assert.Contains(t, invalidErr.ValidAttrs, "instance_type")
+		assert.NotContains(t, invalidErr.ValidAttrs, "type")
+		assert.Contains(t, err.Error(), "Aliases: image=ami, sg=security_groups, type=instance_type")
+	})
+}
+
+func TestValidateAttributesEmptySelection(t *testing.T) {
+	vo := validator.NewValidatorOptionsForTesting(map[string]bool{})
+
+	attrs, err := vo.ValidateAttributes([]string{})
+	assert.Nil(t, attrs)
+	assert.ErrorAs(t, err, &errors.ErrNoAttributesSelected{})
+}
+
 func TestValidateFormat(t *testing.T) {
 	v := validator.NewValidator()
 
@@ -120,12 +198,27 @@ func TestFormattedAttributes(t *testing.T) {
 		vo := validator.NewValidator().(*validator.ValidatorOptions) // Type assertion to access unexported method
 
 		// Expected output matches the sorted attributes with formatting
-		expected := `  - ami
+		expected := `  - affinity
+  - ami
+  - associate_public_ip_address
+  - capacity_reservation_id
+  - disable_api_termination
+  - host_id
+  - instance_lifecycle
+  - instance_state
   - instance_type
+  - metadata_options.http_tokens
+  - monitoring
+  - private_ip
+  - public_ip
+  - root_block_device.delete_on_termination
+  - root_block_device.encrypted
   - root_block_device.volume_size
   - root_block_device.volume_type
+  - root_device_type
   - security_groups
   - tags
+  - user_data
 `
 		assert.Equal(t, expected, vo.FormattedAttributes())
 	})
@@ -135,3 +228,119 @@ func TestFormattedAttributes(t *testing.T) {
 		assert.Empty(t, vo.FormattedAttributes())
 	})
 }
+
+func TestValidateOnlyFilters(t *testing.T) {
+	v := validator.NewValidator()
+
+	t.Run("empty filters are returned unchanged", func(t *testing.T) {
+		filters, err := v.ValidateOnlyFilters([]string{})
+		require.NoError(t, err)
+		assert.Empty(t, filters)
+	})
+
+	t.Run("categories and attributes are accepted", func(t *testing.T) {
+		requested := []string{"added", "removed", "changed", "ami", "tags", "tags.Env", "root_block_device", "root_block_device.volume_size"}
+		filters, err := v.ValidateOnlyFilters(requested)
+		require.NoError(t, err)
+		assert.Equal(t, requested, filters)
+	})
+
+	t.Run("unknown filters return an error", func(t *testing.T) {
+		filters, err := v.ValidateOnlyFilters([]string{"added", "modified"})
+		require.Error(t, err)
+		assert.Nil(t, filters)
+
+		invalidErr, ok := err.(*errors.InvalidAttributesError)
+		require.True(t, ok, "error should be of type InvalidAttributesError")
+		assert.Equal(t, []string{"modified"}, invalidErr.InvalidAttrs)
+		assert.Contains(t, invalidErr.ValidAttrs, "added")
+		assert.Contains(t, invalidErr.ValidAttrs, "ami")
+	})
+}
+
+func TestExpandPresets(t *testing.T) {
+	v := validator.NewValidator()
+	expander, ok := v.(validator.PresetExpander)
+	require.True(t, ok, "the validator expands presets")
+
+	tests := []struct {
+		name     string
+		presets  []string
+		expected []string
+	}{
+		{
+			name:    "security",
+			presets: []string{"security"},
+			expected: []string{
+				"security_groups",
+				"metadata_options.http_tokens",
+				"associate_public_ip_address",
+				"disable_api_termination",
+			},
+		},
+		{
+			name:     "networking",
+			presets:  []string{"networking"},
+			expected: []string{"private_ip", "public_ip", "associate_public_ip_address", "security_groups"},
+		},
+		{
+			name:    "compute",
+			presets: []string{"compute"},
+			expected: []string{
+				"ami",
+				"instance_type",
+				"instance_lifecycle",
+				"monitoring",
+				"host_id",
+				"affinity",
+				"capacity_reservation_id",
+			},
+		},
+		{
+			name:    "storage",
+			presets: []string{"storage"},
+			expected: []string{
+				"root_device_type",
+				"root_block_device.volume_size",
+				"root_block_device.volume_type",
+				"root_block_device.encrypted",
+				"root_block_device.delete_on_termination",
+			},
+		},
+		{
+			name:    "overlapping presets are merged without duplicates",
+			presets: []string{"security", "networking"},
+			expected: []string{
+				"security_groups",
+				"metadata_options.http_tokens",
+				"associate_public_ip_address",
+				"disable_api_termination",
+				"private_ip",
+				"public_ip",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs, err := expander.ExpandPresets(tt.presets)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, attrs)
+
+			// Every preset attribute is a valid attribute
+			validated, err := v.ValidateAttributes(attrs)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, validated)
+		})
+	}
+
+	t.Run("unknown presets return an error", func(t *testing.T) {
+		attrs, err := expander.ExpandPresets([]string{"security", "billing"})
+		assert.Nil(t, attrs)
+		var presetErr errors.ErrUnknownPreset
+		require.ErrorAs(t, err, &presetErr)
+		assert.Equal(t, "billing", presetErr.Preset)
+		assert.Equal(t, []string{"compute", "networking", "security", "storage"}, presetErr.Valid)
+		assert.Equal(t, validator.PresetNames(), presetErr.Valid)
+	})
+}